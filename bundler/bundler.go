@@ -0,0 +1,58 @@
+// Package bundler exposes crev's bundling as a library, so that bundles can be generated for
+// code that does not live on the local disk, e.g. in an embed.FS, a database or blob storage.
+package bundler
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+)
+
+// ErrNoFiles is returned when the options select no files from the filesystem. It is the same
+// error as files.ErrNoFilesSelected.
+var ErrNoFiles = files.ErrNoFilesSelected
+
+// Options contains the configuration options for bundling an fs.FS.
+// All paths and patterns are slash-separated and relative to the root of the filesystem.
+type Options struct {
+	ExplicitFiles   []string
+	IncludePatterns []string
+	ExcludePatterns []string
+	MaxConcurrency  int
+}
+
+// DefaultOptions returns Options that include every file in the filesystem.
+func DefaultOptions() Options {
+	return Options{
+		IncludePatterns: []string{"**/*"},
+		MaxConcurrency:  100,
+	}
+}
+
+// FromFS selects files from fsys according to opts and returns the rendered bundle.
+func FromFS(fsys fs.FS, opts Options) (string, error) {
+	if opts.MaxConcurrency <= 0 {
+		opts.MaxConcurrency = DefaultOptions().MaxConcurrency
+	}
+
+	entries, err := files.SelectEntriesFS(fsys, files.Selection{
+		IncludePatterns: opts.IncludePatterns,
+		ExcludePatterns: opts.ExcludePatterns,
+		ExplicitFiles:   opts.ExplicitFiles,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error getting file paths: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", ErrNoFiles
+	}
+
+	fileContentMap, err := files.GetContentMapOfEntriesFS(fsys, entries, opts.MaxConcurrency)
+	if err != nil {
+		return "", fmt.Errorf("error getting file contents: %w", err)
+	}
+
+	return formatting.RenderText(formatting.NewBundle(files.EntryPaths(entries), fileContentMap)), nil
+}
@@ -0,0 +1,60 @@
+package bundler_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/devinbarry/crev/bundler"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFromFS tests bundling an in-memory filesystem with include and exclude patterns.
+func TestFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":               {Data: []byte("package main")},
+		"internal/util/util.go": {Data: []byte("package util")},
+		"docs/readme.md":        {Data: []byte("# Docs")},
+	}
+
+	opts := bundler.DefaultOptions()
+	opts.ExcludePatterns = []string{"docs"}
+
+	result, err := bundler.FromFS(fsys, opts)
+	require.NoError(t, err, "FromFS failed")
+
+	require.Contains(t, result, "Project Directory Structure:")
+	require.Contains(t, result, "internal/util/util.go\nContent: \npackage util")
+	require.Contains(t, result, "main.go\nContent: \npackage main")
+	require.NotContains(t, result, "readme.md")
+}
+
+// TestFromFSExplicitFiles tests that explicit files override exclude patterns.
+func TestFromFSExplicitFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":        {Data: []byte("package main")},
+		"docs/readme.md": {Data: []byte("# Docs")},
+	}
+
+	opts := bundler.Options{
+		ExplicitFiles:   []string{"docs/readme.md"},
+		ExcludePatterns: []string{"**/*.md"},
+	}
+
+	result, err := bundler.FromFS(fsys, opts)
+	require.NoError(t, err, "FromFS failed")
+	require.Contains(t, result, "# Docs")
+	require.NotContains(t, result, "package main")
+}
+
+// TestFromFSNoFiles tests that an error is returned when nothing is selected.
+func TestFromFSNoFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go": {Data: []byte("package main")},
+	}
+
+	opts := bundler.DefaultOptions()
+	opts.ExcludePatterns = []string{"**/*.go"}
+
+	_, err := bundler.FromFS(fsys, opts)
+	require.ErrorIs(t, err, bundler.ErrNoFiles)
+}
@@ -0,0 +1,174 @@
+// Description: This file implements the "annotate" command, which inserts review findings as comments into the source files.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/findings"
+	"github.com/spf13/cobra"
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate [review]",
+	Short: "Insert the findings of a review as comments into the source files",
+	Long: `Insert each finding of a review as a comment above the line it refers to, e.g.
+
+  // TODO(crev): [warning] Unused variable
+
+The review is parsed like "crev findings" does: from the given file, crev-review.md by default,
+or stdin if the file is "-". File paths are relative to the current directory. Findings for
+files that do not exist, lines past the end of a file or file types without a known comment
+syntax are skipped and reported.
+
+Example usage:
+  # Preview the comments that would be inserted
+  crev annotate answer.md --dry-run
+
+  # Insert the findings as NOTE comments
+  crev annotate answer.md --tag NOTE`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source := "crev-review.md"
+		if len(args) > 0 {
+			source = args[0]
+		}
+		result, err := readFindings(cmd.InOrStdin(), source)
+		if err != nil {
+			return err
+		}
+		tag, _ := cmd.Flags().GetString("tag")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return Annotate(cmd.OutOrStdout(), result, tag, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+	addAnnotateFlags(annotateCmd)
+}
+
+// addAnnotateFlags adds the annotate flags to cmd.
+func addAnnotateFlags(cmd *cobra.Command) {
+	cmd.Flags().String("tag", "TODO", "Tag of the inserted comments, e.g. TODO or NOTE")
+	cmd.Flags().Bool("dry-run", false, "Print the comments that would be inserted without changing any file")
+}
+
+// commentStyle is the syntax of a line comment and the file extensions (and exact file names) using it
+type commentStyle struct {
+	start, end string
+	extensions []string
+}
+
+// commentStyles contains the comment syntax of the supported file types
+var commentStyles = []commentStyle{
+	{"//", "", []string{".go", ".js", ".jsx", ".ts", ".tsx", ".java", ".kt", ".scala", ".c", ".h", ".cpp", ".hpp",
+		".cs", ".swift", ".rs", ".php", ".dart", ".proto", ".scss"}},
+	{"#", "", []string{".py", ".rb", ".sh", ".bash", ".pl", ".r", ".yaml", ".yml", ".toml", "makefile", "dockerfile"}},
+	{"--", "", []string{".sql", ".lua", ".hs"}},
+	{"/*", " */", []string{".css"}},
+	{"<!--", " -->", []string{".html", ".xml", ".md"}},
+}
+
+// commentFor returns the comment syntax for a file, and false if it is unknown
+func commentFor(file string) (commentStyle, bool) {
+	name := strings.ToLower(path.Base(filepath.ToSlash(file)))
+	for _, style := range commentStyles {
+		if slices.Contains(style.extensions, name) || slices.Contains(style.extensions, path.Ext(name)) {
+			return style, true
+		}
+	}
+	return commentStyle{}, false
+}
+
+// Annotate inserts the findings as comments tagged with tag above the lines they refer to,
+// and writes a line to w for every inserted or skipped finding.
+func Annotate(w io.Writer, result []findings.Finding, tag string, dryRun bool) error {
+	byFile := make(map[string][]findings.Finding)
+	var fileNames []string
+	for _, f := range result {
+		if _, ok := byFile[f.File]; !ok {
+			fileNames = append(fileNames, f.File)
+		}
+		byFile[f.File] = append(byFile[f.File], f)
+	}
+	sort.Strings(fileNames)
+
+	inserted, skipped, changedFiles := 0, 0, 0
+	for _, file := range fileNames {
+		fileFindings := byFile[file]
+		syntax, ok := commentFor(file)
+		if !ok {
+			fmt.Fprintf(w, "Skipped %s: unknown comment syntax\n", file)
+			skipped += len(fileFindings)
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(w, "Skipped %s: %v\n", file, err)
+			skipped += len(fileFindings)
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", file, err)
+		}
+		lines := strings.Split(string(content), "\n")
+		lineCount := len(lines)
+		if strings.HasSuffix(string(content), "\n") {
+			lineCount--
+		}
+
+		// Insert from the bottom up, so the line numbers of the remaining findings stay valid
+		sort.SliceStable(fileFindings, func(i, j int) bool { return fileFindings[i].Line > fileFindings[j].Line })
+		changed := false
+		for _, f := range fileFindings {
+			if f.Line > lineCount {
+				fmt.Fprintf(w, "Skipped %s:%d: the file has %d lines\n", file, f.Line, lineCount)
+				skipped++
+				continue
+			}
+			target := lines[f.Line-1]
+			indent := target[:len(target)-len(strings.TrimLeft(target, " \t"))]
+			comment := indent + annotationComment(syntax, tag, f)
+			lines = append(lines[:f.Line-1], append([]string{comment}, lines[f.Line-1:]...)...)
+			fmt.Fprintf(w, "%s:%d: %s\n", file, f.Line, strings.TrimSpace(comment))
+			inserted++
+			changed = true
+		}
+
+		if changed && !dryRun {
+			if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+				return fmt.Errorf("unable to write %s: %w", file, err)
+			}
+			changedFiles++
+		}
+	}
+
+	if dryRun {
+		fmt.Fprintf(w, "Dry run: %d comments would be inserted, %d findings skipped\n", inserted, skipped)
+	} else {
+		fmt.Fprintf(w, "Inserted %d comments into %d files, %d findings skipped\n", inserted, changedFiles, skipped)
+	}
+	return nil
+}
+
+// annotationComment returns the comment line for a finding, without indentation
+func annotationComment(syntax commentStyle, tag string, f findings.Finding) string {
+	text := f.Message
+	if f.Severity != "" {
+		text = "[" + f.Severity + "] " + text
+	}
+	if f.EndLine > f.Line {
+		text += fmt.Sprintf(" (lines %s)", f.Lines())
+	}
+	// Keep the comment on a single line
+	text = strings.Join(strings.Fields(text), " ")
+	return fmt.Sprintf("%s %s(crev): %s%s", syntax.start, tag, text, syntax.end)
+}
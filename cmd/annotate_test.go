@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// executeAnnotateCmd executes the annotate command with given arguments and returns its output
+func (env *testEnv) executeAnnotateCmd(args ...string) (string, error) {
+	annotateCmd.ResetFlags()
+	addAnnotateFlags(annotateCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	env.t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	rootCmd.SetArgs(append([]string{"annotate"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+const annotateReview = `src/main.go:3-4: [warning] Unused variable
+src/main.go:1: Add a package comment
+scripts/build.py:2: [error] Command injection
+scripts/build.py:9: Past the end
+data.bin:1: Unknown syntax
+`
+
+// TestAnnotateCommand tests inserting findings as comments above their lines
+func TestAnnotateCommand(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":      "package main\n\nfunc main() {\n\tx := 1\n}\n",
+		"scripts/build.py": "import os\nos.system(cmd)\n",
+		"data.bin":         "BIN",
+		"crev-review.md":   annotateReview,
+	})
+
+	out, err := env.executeAnnotateCmd()
+	require.NoError(t, err)
+	require.Contains(t, out, "Skipped data.bin: unknown comment syntax\n")
+	require.Contains(t, out, "Skipped scripts/build.py:9: the file has 2 lines\n")
+	require.Contains(t, out, "Inserted 3 comments into 2 files, 2 findings skipped\n")
+
+	env.assertFileContents("src/main.go", []string{
+		"// TODO(crev): Add a package comment\npackage main\n\n// TODO(crev): [warning] Unused variable (lines 3-4)\nfunc main() {\n",
+	}, nil)
+	env.assertFileContents("scripts/build.py", []string{"import os\n# TODO(crev): [error] Command injection\nos.system(cmd)\n"}, nil)
+}
+
+// TestAnnotateCommandDryRun tests that no file is changed in a dry run
+func TestAnnotateCommandDryRun(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go": "package main\n\nfunc main() {\n\tx := 1\n}\n",
+		"review.md":   "src/main.go:4: [warning] Unused variable\n",
+	})
+
+	out, err := env.executeAnnotateCmd("review.md", "--dry-run", "--tag", "NOTE")
+	require.NoError(t, err)
+	require.Equal(t, "src/main.go:4: // NOTE(crev): [warning] Unused variable\nDry run: 1 comments would be inserted, 0 findings skipped\n", out)
+
+	content, err := os.ReadFile("src/main.go")
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() {\n\tx := 1\n}\n", string(content))
+}
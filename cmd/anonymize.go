@@ -0,0 +1,105 @@
+// Description: This file contains the anonymization of the machine specific prefixes of the paths shown in a bundle.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pathAlias replaces the prefix of a path with an alias, or strips it if the alias is empty.
+type pathAlias struct {
+	prefix string
+	alias  string
+}
+
+// pathAnonymizer rewrites the paths shown in the tree and file headers of a bundle, so it can be
+// shared without leaking the home directory, temp directory or user name of the local machine.
+type pathAnonymizer struct {
+	aliases  []pathAlias
+	username string
+}
+
+// newPathAnonymizer returns the anonymizer of the aliases, given as PREFIX=ALIAS, and with
+// anonymize the home directory (~), the temp directory ($TMPDIR) and the user name (user).
+// It returns nil if there is nothing to anonymize.
+func newPathAnonymizer(anonymize bool, aliases []string) (*pathAnonymizer, error) {
+	a := &pathAnonymizer{}
+	for _, alias := range aliases {
+		prefix, name, ok := strings.Cut(alias, "=")
+		if !ok || prefix == "" {
+			return nil, fmt.Errorf("invalid path alias %q: expected PREFIX=ALIAS", alias)
+		}
+		a.aliases = append(a.aliases, pathAlias{prefix: cleanPrefix(prefix), alias: name})
+	}
+	if anonymize {
+		if tmp := os.TempDir(); tmp != "" {
+			a.aliases = append(a.aliases, pathAlias{prefix: cleanPrefix(tmp), alias: "$TMPDIR"})
+		}
+		if home, err := os.UserHomeDir(); err == nil && home != "" {
+			a.aliases = append(a.aliases, pathAlias{prefix: cleanPrefix(home), alias: "~"})
+		}
+		if u, err := user.Current(); err == nil {
+			// Windows user names are prefixed with their domain
+			a.username = u.Username[strings.LastIndex(u.Username, `\`)+1:]
+		}
+	}
+	if len(a.aliases) == 0 && a.username == "" {
+		return nil, nil
+	}
+	// The most specific prefix wins, e.g. a temp directory inside the home directory
+	sort.SliceStable(a.aliases, func(i, j int) bool { return len(a.aliases[i].prefix) > len(a.aliases[j].prefix) })
+	return a, nil
+}
+
+// cleanPrefix returns the slash-separated form of a path prefix.
+func cleanPrefix(prefix string) string {
+	return path.Clean(filepath.ToSlash(prefix))
+}
+
+// Path returns the anonymized form of a slash-separated path: the first matching prefix is
+// replaced with its alias, and path elements equal to the user name with "user".
+func (a *pathAnonymizer) Path(p string) string {
+	if a == nil {
+		return p
+	}
+	for _, alias := range a.aliases {
+		rest, ok := strings.CutPrefix(p, alias.prefix)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasSuffix(alias.prefix, "/")) {
+			continue
+		}
+		if alias.alias == "" {
+			p = strings.TrimPrefix(rest, "/")
+		} else {
+			p = alias.alias + rest
+		}
+		break
+	}
+	if a.username != "" {
+		elements := strings.Split(p, "/")
+		for i, element := range elements {
+			if element == a.username {
+				elements[i] = "user"
+			}
+		}
+		p = strings.Join(elements, "/")
+	}
+	return p
+}
+
+// Paths returns the anonymized file paths and the file contents keyed by their anonymized path.
+func (a *pathAnonymizer) Paths(filePaths []string, fileContentMap map[string]string) ([]string, map[string]string) {
+	anonymizedPaths := make([]string, len(filePaths))
+	for i, p := range filePaths {
+		anonymizedPaths[i] = a.Path(p)
+	}
+	anonymizedContents := make(map[string]string, len(fileContentMap))
+	for p, content := range fileContentMap {
+		anonymizedContents[a.Path(p)] = content
+	}
+	return anonymizedPaths, anonymizedContents
+}
@@ -0,0 +1,102 @@
+// Description: This file contains the --append mode, which adds the files of a run to the bundle written by earlier runs.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/formatting"
+)
+
+// validateAppend checks that the options can be used to append to an existing bundle.
+func validateAppend(opts BundleOptions) error {
+	if opts.Stdout || opts.Format != formatText || len(opts.EncryptTo) > 0 || opts.MarkUntrusted {
+		return fmt.Errorf("--append cannot be used with --stdout, --encrypt-to, --mark-untrusted or --format other than %s", formatText)
+	}
+	return nil
+}
+
+// readAppendedBundle returns the bundle written to outputTarget by an earlier run, or nil if there is none.
+func readAppendedBundle(outputTarget string) (*formatting.Bundle, error) {
+	if _, err := os.Stat(outputTarget); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	bundle, err := readBundle(outputTarget, "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot append to %s: %w", outputTarget, err)
+	}
+	return bundle, nil
+}
+
+// appendBundleFiles adds the files of the existing bundle to the file paths and contents of the
+// run. Files that are in both are reported as an error, as the bundle can only hold one of them.
+func appendBundleFiles(existing *formatting.Bundle, outputTarget string, filePaths []string, fileContentMap map[string]string) ([]string, error) {
+	var duplicates []string
+	for _, file := range existing.Files {
+		if _, ok := fileContentMap[file.Path]; ok {
+			duplicates = append(duplicates, file.Path)
+			continue
+		}
+		filePaths = append(filePaths, file.Path)
+		fileContentMap[file.Path] = file.Content
+	}
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return nil, fmt.Errorf("cannot append to %s: %d files are already in the bundle: %s",
+			outputTarget, len(duplicates), strings.Join(duplicates, ", "))
+	}
+	return filePaths, nil
+}
+
+// appendIndexChecksums adds the checksums of the existing index next to outputTarget, if any, to
+// checksums, so the merged index keeps them for the files of earlier runs.
+func appendIndexChecksums(outputTarget string, checksums map[string]string) error {
+	f, err := os.Open(filepath.Join(filepath.Dir(outputTarget), indexFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening index: %w", err)
+	}
+	defer f.Close()
+	index, err := formatting.ReadIndex(f)
+	if err != nil {
+		return err
+	}
+	for _, entry := range index.Files {
+		if _, ok := checksums[entry.Path]; !ok && entry.SHA256 != "" {
+			checksums[entry.Path] = entry.SHA256
+		}
+	}
+	return nil
+}
+
+// appendSections returns the layout of the existing bundle with the custom sections of the run it
+// does not have yet, added before its first built-in section. The built-in sections of the run
+// are already part of the layout.
+func appendSections(existing, added []formatting.Section) []formatting.Section {
+	layout := existing
+	if len(layout) == 0 {
+		layout = formatting.DefaultSections
+	}
+	var custom []formatting.Section
+	for _, section := range added {
+		if section.Kind == formatting.CustomSection && !slices.Contains(layout, section) {
+			custom = append(custom, section)
+		}
+	}
+	if len(custom) == 0 {
+		return existing
+	}
+	i := slices.IndexFunc(layout, func(s formatting.Section) bool { return s.Kind != formatting.CustomSection })
+	if i < 0 {
+		i = len(layout)
+	}
+	return slices.Concat(layout[:i], custom, layout[i:])
+}
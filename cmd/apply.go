@@ -0,0 +1,163 @@
+// Description: This file implements the "apply" command, which applies the file changes suggested by a model to the workspace.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/patch"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <answer>",
+	Short: "Apply the file changes suggested in the answer of a model",
+	Long: `Extract the file changes suggested in the answer of a model, or in any text file, and apply
+them to the files in the current directory. The answer is read from stdin if it is "-".
+
+Supported changes:
+- Unified diffs (e.g. in a fenced diff block), including new and deleted files
+- Full file replacements: a fenced code block with the path of the file after the language
+  (e.g. "` + "```go cmd/root.go" + `") or on the line before the block (e.g. "File: cmd/root.go")
+
+A hunk that does not match the content of its file at its line is applied at the nearest line
+where it matches. If a hunk matches nowhere, the conflicts are reported and no file is changed.
+
+Example usage:
+  # Preview the changes
+  crev apply answer.md --dry-run
+
+  # Apply the changes of an answer copied to the clipboard
+  pbpaste | crev apply -`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		var content []byte
+		var err error
+		if args[0] == "-" {
+			content, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			content, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read answer: %w", err)
+		}
+		return Apply(cmd.OutOrStdout(), string(content), dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	addApplyFlags(applyCmd)
+}
+
+// addApplyFlags adds the apply flags to cmd.
+func addApplyFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Print the changes that would be applied without changing any file")
+}
+
+// changeStatus is the letter printed for each kind of change, like "git status --short"
+var changeStatus = map[patch.Kind]string{
+	patch.Modify:  "M",
+	patch.Replace: "M",
+	patch.Create:  "A",
+	patch.Delete:  "D",
+}
+
+// Apply applies the changes found in text to the files in the current directory. All changes are
+// computed before any file is written, so a conflict leaves the workspace untouched.
+func Apply(w io.Writer, text string, dryRun bool) error {
+	changes, err := patch.Extract(text)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return fmt.Errorf("no diffs or file replacements found")
+	}
+
+	type result struct {
+		change  patch.Change
+		status  string
+		content string
+		mode    os.FileMode
+	}
+	var results []result
+	var conflicts []string
+	contents := make(map[string]string) // content after the previous changes to the same file
+	for _, change := range changes {
+		file := filepath.FromSlash(change.Path)
+		original, seen := contents[file]
+		mode := os.FileMode(0644)
+		info, statErr := os.Stat(file)
+		exists := statErr == nil
+		if exists {
+			mode = info.Mode().Perm()
+		}
+		if !seen && exists {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("unable to read %s: %w", change.Path, err)
+			}
+			original = string(content)
+		}
+
+		status := changeStatus[change.Kind]
+		switch {
+		case change.Kind == patch.Create && exists:
+			conflicts = append(conflicts, change.Path+": the file already exists")
+			continue
+		case (change.Kind == patch.Modify || change.Kind == patch.Delete) && !exists && !seen:
+			conflicts = append(conflicts, change.Path+": the file does not exist")
+			continue
+		case change.Kind == patch.Replace && !exists && !seen:
+			status = "A"
+		}
+
+		updated, err := patch.Apply(change, original)
+		var conflict *patch.ConflictError
+		if errors.As(err, &conflict) {
+			conflicts = append(conflicts, conflict.Error())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		contents[file] = updated
+		results = append(results, result{change: change, status: status, content: updated, mode: mode})
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("no changes applied due to conflicts:\n  %s", strings.Join(conflicts, "\n  "))
+	}
+
+	for _, r := range results {
+		fmt.Fprintf(w, "%s %s\n", r.status, r.change.Path)
+		if dryRun {
+			continue
+		}
+		file := filepath.FromSlash(r.change.Path)
+		if r.change.Kind == patch.Delete {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("unable to delete %s: %w", r.change.Path, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return fmt.Errorf("unable to create directory for %s: %w", r.change.Path, err)
+		}
+		if err := os.WriteFile(file, []byte(r.content), r.mode); err != nil {
+			return fmt.Errorf("unable to write %s: %w", r.change.Path, err)
+		}
+	}
+
+	if dryRun {
+		fmt.Fprintf(w, "Dry run: %d changes would be applied\n", len(results))
+	} else {
+		fmt.Fprintf(w, "Applied %d changes\n", len(results))
+	}
+	return nil
+}
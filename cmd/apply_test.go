@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// executeApplyCmd executes the apply command with given arguments and returns its output
+func (env *testEnv) executeApplyCmd(args ...string) (string, error) {
+	applyCmd.ResetFlags()
+	addApplyFlags(applyCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	env.t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	rootCmd.SetArgs(append([]string{"apply"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+const applyAnswer = "```diff\n" +
+	"--- a/main.go\n" +
+	"+++ b/main.go\n" +
+	"@@ -3,1 +3,1 @@\n" +
+	"-func main() {}\n" +
+	"+func main() { run() }\n" +
+	"--- a/old.go\n" +
+	"+++ /dev/null\n" +
+	"```\n" +
+	"\n" +
+	"File: run.go\n" +
+	"```go\n" +
+	"package main\n" +
+	"\n" +
+	"func run() {}\n" +
+	"```\n"
+
+// TestApplyCommand tests applying diffs and file replacements
+func TestApplyCommand(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":   "package main\n\nfunc main() {}\n",
+		"old.go":    "package main\n",
+		"answer.md": applyAnswer,
+	})
+
+	out, err := env.executeApplyCmd("answer.md")
+	require.NoError(t, err)
+	require.Equal(t, "M main.go\nD old.go\nA run.go\nApplied 3 changes\n", out)
+
+	env.assertFileContents("main.go", []string{"package main\n\nfunc main() { run() }\n"}, nil)
+	env.assertFileContents("run.go", []string{"package main\n\nfunc run() {}\n"}, nil)
+	require.NoFileExists(t, "old.go")
+}
+
+// TestApplyCommandDryRun tests that no file is changed in a dry run
+func TestApplyCommandDryRun(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":   "package main\n\nfunc main() {}\n",
+		"old.go":    "package main\n",
+		"answer.md": applyAnswer,
+	})
+
+	out, err := env.executeApplyCmd("answer.md", "--dry-run")
+	require.NoError(t, err)
+	require.Contains(t, out, "Dry run: 3 changes would be applied\n")
+
+	content, err := os.ReadFile("main.go")
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+	require.FileExists(t, "old.go")
+	require.NoFileExists(t, "run.go")
+}
+
+// TestApplyCommandConflict tests that a conflict leaves all files untouched
+func TestApplyCommandConflict(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":   "package main\n\nfunc main() { start() }\n",
+		"old.go":    "package main\n",
+		"answer.md": applyAnswer,
+	})
+
+	_, err := env.executeApplyCmd("answer.md")
+	env.assertErrorContains(err, "no changes applied due to conflicts:\n  main.go: hunk 1 does not apply at line 3")
+	require.FileExists(t, "old.go")
+	require.NoFileExists(t, "run.go")
+}
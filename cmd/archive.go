@@ -0,0 +1,57 @@
+// Description: This file contains the --keep archive of the bundles written by earlier runs.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// historyDir is the directory below the output directory where --keep archives the bundles.
+	historyDir = ".crev/history"
+	// archiveTimeFormat is the format of the time of the run in the name of an archived bundle,
+	// which sorts the archived bundles by time.
+	archiveTimeFormat = "20060102T150405Z"
+)
+
+// archiveBundle copies the bundle written to outputTarget to historyDir below its directory, with
+// the time of the run in its name, e.g. crev-project-20240102T150405Z.txt.gz, and removes all but
+// the newest keep archived bundles. It returns the path of the archived bundle.
+func archiveBundle(outputTarget string, keep int, now time.Time) (string, error) {
+	dir := filepath.Join(filepath.Dir(outputTarget), filepath.FromSlash(historyDir))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating the bundle history: %w", err)
+	}
+	content, err := os.ReadFile(outputTarget)
+	if err != nil {
+		return "", fmt.Errorf("error archiving the bundle: %w", err)
+	}
+	stem, ext := splitBundleName(filepath.Base(outputTarget))
+	archived := filepath.Join(dir, stem+"-"+now.UTC().Format(archiveTimeFormat)+ext)
+	if err := os.WriteFile(archived, content, 0644); err != nil {
+		return "", fmt.Errorf("error archiving the bundle: %w", err)
+	}
+
+	// Remove the oldest archived bundles
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("error reading the bundle history: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), stem+"-") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names[:max(len(names)-keep, 0)] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return "", fmt.Errorf("error pruning the bundle history: %w", err)
+		}
+	}
+	return archived, nil
+}
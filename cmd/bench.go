@@ -0,0 +1,110 @@
+// Description: This file implements the "bench" command, which times the phases of bundling the current repository.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/devinbarry/crev/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [path]",
+	Short: "Time the phases of bundling the project",
+	Long: `Time the file discovery, reading and rendering of a bundle separately, using the same file
+selection rules and config as "crev bundle". The bundle is rendered to memory, nothing is written.
+
+Use it to diagnose slow bundles, e.g. expensive include/exclude patterns show up in discovery
+and slow disks or network filesystems in reading. The hidden --cpuprofile and --memprofile flags
+of every command write pprof profiles for a closer look.
+
+Example usage:
+  # Time bundling the current directory, best of three runs
+  crev bench
+
+  # Time ten runs and write a CPU profile
+  crev bench --runs 10 --cpuprofile cpu.pprof`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runs, _ := cmd.Flags().GetInt("runs")
+		return Bench(cmd.OutOrStdout(), selectionOptions(cmd, args), runs)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	addBenchFlags(benchCmd)
+}
+
+// addBenchFlags adds the bench flags to cmd.
+func addBenchFlags(cmd *cobra.Command) {
+	addSelectionFlags(cmd)
+	cmd.Flags().Int("runs", 3, "Number of times to run each phase")
+}
+
+// benchPhases are the phases of bundling timed by Bench, in order
+var benchPhases = []string{"discover", "read", "render"}
+
+// Bench bundles the files selected by opts runs times and writes the fastest and mean duration
+// of each phase to w.
+func Bench(w io.Writer, opts BundleOptions, runs int) error {
+	if runs < 1 {
+		return fmt.Errorf("invalid number of runs %d: must be at least 1", runs)
+	}
+
+	timings := make(map[string][]time.Duration)
+	var fileCount, size int
+	for run := 0; run < runs; run++ {
+		start := time.Now()
+		entries, err := selectEntries(opts)
+		if err != nil {
+			return err
+		}
+		timings["discover"] = append(timings["discover"], time.Since(start))
+
+		start = time.Now()
+		fileContentMap, err := files.GetContentMapOfEntriesFS(files.HostFS(opts.RootDir), entries, opts.MaxConcurrency)
+		if err != nil {
+			return fmt.Errorf("error getting file contents: %w", err)
+		}
+		timings["read"] = append(timings["read"], time.Since(start))
+
+		start = time.Now()
+		counter := &countingWriter{w: io.Discard}
+		bundle := formatting.NewBundle(files.EntryPaths(entries), fileContentMap)
+		if err := formatting.WriteText(counter, bundle); err != nil {
+			return err
+		}
+		timings["render"] = append(timings["render"], time.Since(start))
+
+		fileCount, size = bundle.Stats.FileCount, counter.n
+	}
+
+	fmt.Fprintf(w, "Files: %d, bundle size: %s, runs: %d\n\n", fileCount, report.FormatBytes(size), runs)
+	fmt.Fprintf(w, "%-10s %12s %12s\n", "Phase", "Fastest", "Mean")
+	var totalFastest, totalMean time.Duration
+	for _, phase := range benchPhases {
+		fastest, mean := durationStats(timings[phase])
+		totalFastest += fastest
+		totalMean += mean
+		fmt.Fprintf(w, "%-10s %12s %12s\n", phase, fastest.Round(time.Microsecond), mean.Round(time.Microsecond))
+	}
+	fmt.Fprintf(w, "%-10s %12s %12s\n", "total", totalFastest.Round(time.Microsecond), totalMean.Round(time.Microsecond))
+	return nil
+}
+
+// durationStats returns the shortest and the mean of durations
+func durationStats(durations []time.Duration) (fastest, mean time.Duration) {
+	var total time.Duration
+	for i, d := range durations {
+		if i == 0 || d < fastest {
+			fastest = d
+		}
+		total += d
+	}
+	return fastest, total / time.Duration(len(durations))
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// executeBenchCmd runs the bench command with fresh flags and returns its output
+func (env *testEnv) executeBenchCmd(args ...string) (string, error) {
+	benchCmd.ResetFlags()
+	addBenchFlags(benchCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	env.t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs(append([]string{"bench"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// TestBenchCmd tests that the bench command times each phase of bundling the selected files
+func TestBenchCmd(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go": "package main",
+		"docs/a.md":   "# A",
+	})
+
+	out, err := env.executeBenchCmd(".", "--runs", "2", "--exclude", "docs")
+	require.NoError(t, err)
+	require.Contains(t, out, "Files: 1, bundle size: ")
+	require.Contains(t, out, "runs: 2")
+	for _, phase := range []string{"discover", "read", "render", "total"} {
+		require.Regexp(t, `(?m)^`+phase+` +\S+ +\S+$`, out)
+	}
+
+	_, err = env.executeBenchCmd(".", "--runs", "0")
+	env.assertErrorContains(err, "invalid number of runs 0")
+}
@@ -0,0 +1,148 @@
+// Description: This file contains the token budget of the bundle command.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/budget"
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+)
+
+// Strategies of --trim to fit the token budget.
+const (
+	trimDrop     = "drop"
+	trimTruncate = "truncate"
+)
+
+// trimStrategies are the supported values of --trim.
+var trimStrategies = []string{trimDrop, trimTruncate}
+
+// maxBudgetRounds is the number of times the files are trimmed before giving up, as the
+// truncation markers and tree notes added by a round can exceed the budget again.
+const maxBudgetRounds = 5
+
+// truncationMarkerSize is the room left for the marker after the content of a truncated file.
+const truncationMarkerSize = len("… truncated (999999 more lines)")
+
+// pinnedPaths returns the paths of the explicit files and directories as shown in the bundle,
+// which are never trimmed to fit the token budget.
+func pinnedPaths(opts BundleOptions) ([]string, error) {
+	explicitPaths, err := explicitSelectionPaths(opts.RootDir, opts.ExplicitFiles)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range explicitPaths {
+		if !opts.RelativePaths {
+			p = filepath.ToSlash(filepath.Join(opts.RootDir, p))
+		}
+		explicitPaths[i] = opts.Anonymizer.Path(p)
+	}
+	return explicitPaths, nil
+}
+
+// isPinned reports whether filePath is one of the pinned paths or inside one of them.
+func isPinned(filePath string, pinned []string) bool {
+	return slices.ContainsFunc(pinned, func(p string) bool {
+		return filePath == p || strings.HasPrefix(filePath, strings.TrimSuffix(p, "/")+"/")
+	})
+}
+
+// fileOverhead returns the size of the header and delimiters of a file in the bundle, without
+// code fences and untrusted content markers.
+func fileOverhead(filePath string) int {
+	return len("File: \n") + len(filePath) + len("\nContent: \n") + len("\n\n")
+}
+
+// renderedSize returns the size of the bundle in the format of the bundle file.
+func renderedSize(bundle *formatting.Bundle, formatter formatting.Formatter) int {
+	counter := &countingWriter{w: io.Discard}
+	_ = formatter.Write(counter, bundle)
+	return counter.n
+}
+
+// fitTokenBudget drops or, with the truncate strategy, truncates the files of the bundle with the
+// lowest priority (see budget.Priority) until it fits in opts.MaxTokens, and logs the trimmed
+// files. Dropped files stay in the project tree with a note. The bundle is rebuilt with build
+// from the trimmed contents and notes.
+func fitTokenBudget(logger *slog.Logger, bundle *formatting.Bundle, fileContentMap, treeNotes map[string]string, pinned []string, opts BundleOptions, build func(fileContentMap, treeNotes map[string]string) (*formatting.Bundle, error)) (*formatting.Bundle, error) {
+	maxBytes := budget.Bytes(opts.MaxTokens)
+	formatter := bundleFormatter(opts.Format)
+	size := renderedSize(bundle, formatter)
+	if size <= maxBytes {
+		return bundle, nil
+	}
+	original := fileContentMap
+	fileContentMap = maps.Clone(fileContentMap)
+	treeNotes = maps.Clone(treeNotes)
+	if treeNotes == nil {
+		treeNotes = make(map[string]string)
+	}
+
+	var dropped, truncated []string
+	for round := 0; size > maxBytes; round++ {
+		if round == maxBudgetRounds {
+			return nil, fmt.Errorf("the bundle does not fit in %d tokens (about %d after trimming)", opts.MaxTokens, budget.Tokens(size))
+		}
+		var candidates []budget.File
+		for _, file := range bundle.Files {
+			if _, ok := fileContentMap[file.Path]; ok {
+				candidates = append(candidates, budget.File{
+					Path:   file.Path,
+					Size:   len(file.Content) + fileOverhead(file.Path),
+					Pinned: isPinned(file.Path, pinned),
+				})
+			}
+		}
+
+		excess := size - maxBytes
+		if opts.TrimStrategy == trimTruncate {
+			limits, ok := budget.Truncate(candidates, excess)
+			if !ok {
+				return nil, fmt.Errorf("the bundle does not fit in %d tokens even with all files truncated, raise --max-tokens", opts.MaxTokens)
+			}
+			for filePath, limit := range limits {
+				// Truncate the original content again, so a marker never ends up in the content
+				fileContentMap[filePath] = files.TruncateBytes(original[filePath], limit-fileOverhead(filePath)-truncationMarkerSize)
+				if !slices.Contains(truncated, filePath) {
+					truncated = append(truncated, filePath)
+				}
+			}
+		} else {
+			paths, ok := budget.Drop(candidates, excess)
+			if !ok {
+				return nil, fmt.Errorf("the bundle does not fit in %d tokens even without all files that are not given with --files, raise --max-tokens", opts.MaxTokens)
+			}
+			for _, filePath := range paths {
+				delete(fileContentMap, filePath)
+				treeNotes[filePath] = "omitted to fit --max-tokens"
+				dropped = append(dropped, filePath)
+			}
+		}
+
+		var err error
+		if bundle, err = build(fileContentMap, treeNotes); err != nil {
+			return nil, err
+		}
+		size = renderedSize(bundle, formatter)
+	}
+
+	// Report what was trimmed, so the reviewer knows what is missing
+	slices.Sort(dropped)
+	slices.Sort(truncated)
+	if len(dropped) > 0 {
+		logger.Info(fmt.Sprintf("Dropped %d files to fit in %d tokens: %s", len(dropped), opts.MaxTokens, strings.Join(dropped, ", ")),
+			"phase", "budget", "max_tokens", opts.MaxTokens, "dropped", dropped)
+	}
+	if len(truncated) > 0 {
+		logger.Info(fmt.Sprintf("Truncated %d files to fit in %d tokens: %s", len(truncated), opts.MaxTokens, strings.Join(truncated, ", ")),
+			"phase", "budget", "max_tokens", opts.MaxTokens, "truncated", truncated)
+	}
+	return bundle, nil
+}
@@ -2,20 +2,27 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/devinbarry/crev/internal/report"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"os"
+	"slices"
+	"strings"
 )
 
-var generateCmd = &cobra.Command{
+var bundleCmd = &cobra.Command{
 	Use:   "bundle [path]",
 	Short: "Bundle your project files into a single file",
 	Long: `Bundle your project files into a single file, starting from the specified directory.
 
 File Selection Rules:
 1. If --files is specified:
-   - Files must exist
+   - Files must exist, relative to the bundled directory or the working directory
    - Listed files are always included, regardless of exclude patterns
+   - Listed directories include every file below them, e.g. --files internal/auth
    - Additional files can be added via include patterns
    - Exclude patterns still apply to files matched by include patterns
 
@@ -28,10 +35,108 @@ File Selection Rules:
    - Default include pattern "**/*" is used
    - Files matching any exclude pattern are excluded
 
+4. If --safe-mode is specified (or 'safe-mode: true' in config):
+   - Only files with an allowlisted text extension are included
+   - The allowlist can be replaced via the 'safe-extensions' config key
+   - Files specified via --files are always included
+
+5. Built-in ignore lists exclude hidden files, crev files, images, fonts and a few
+   generated files. Dependency manifests such as go.mod are also ignored unless
+   --include-manifests is specified. Use --no-default-excludes (or
+   'no-default-excludes: true' in config) to disable the lists and fully control the
+   selection with include and exclude patterns.
+   The lists can be changed with the 'defaults.prefixes', 'defaults.extensions' and
+   'defaults.files' config keys: "+entry" adds an entry, "-entry" removes one and
+   other entries replace the built-in list.
+   Bundles and indexes written by crev to the output directory are always excluded,
+   even with --no-default-excludes, so a bundle never contains the previous one.
+
+6. If --git-tracked-only is specified (or 'git-tracked-only: true' in config):
+   - Only files tracked by git (as listed by "git ls-files") are included, so ignored
+     and untracked scratch files are skipped
+   - Include and exclude patterns still apply to the tracked files
+   - Files specified via --files are always included
+
+7. If --detect-shebang is specified (or 'detect-shebang: true' in config):
+   - Scripts without an extension are matched against the include patterns with the
+     extension of the language of their shebang line, e.g. a "#!/usr/bin/env python3"
+     script named deploy is matched as deploy.py by '**/*.py'
+
+8. If --include-containing is specified:
+   - Only files whose content matches the regular expression are included
+   - Directories without matching files are left out of the project tree
+   - Files specified via --files are always included
+
+9. If --pragmas is specified (or 'pragmas: true' in config):
+   - A "crev:ignore" comment in the first 10 lines of a file, e.g. "// crev:ignore",
+     excludes it, unless it is specified via --files
+   - A "crev:include" comment, e.g. "# crev:include", includes the file regardless of the
+     include and exclude patterns, unless it is inside an excluded directory
+
+10. If --select is specified (or 'select: EXPR' in config):
+   - Only files matching the expression are included, files specified via --files are
+     always included
+   - Predicates: glob:PATTERN, lang:NAME (e.g. lang:go, lang:py), test, size<N (b, kb,
+     mb, gb), mtime<N (time since the last change in s, m, h, d, w) and git:STATUS
+     (tracked, modified, staged, untracked); size and mtime also support <=, >, >= and =
+   - Predicates are combined with and, or, not and parentheses, e.g.
+     'lang:go and not test and size<50kb' or '(lang:ts or lang:tsx) and git:modified'
+
+11. If --ignore-source is specified (or 'ignore-source: [...]' in config):
+   - Files ignored by the listed ignore files of the bundled directory are excluded,
+     e.g. --ignore-source dockerignore bundles what a docker build of it would see
+   - Supported sources: dockerignore (.dockerignore, with the docker build syntax)
+   - Files specified via --files are always included
+
+12. If the bundled directory has a .crevignore file:
+   - Files it ignores are excluded, on top of the exclude patterns of the config and flags
+   - It has the full .gitignore syntax: "/" anchors a pattern, a trailing "/" only
+     matches directories, "!" re-includes files outside of ignored directories
+   - Files specified via --files are always included
+
+Minified Assets:
+- JS and CSS files that look minified (very long lines, little whitespace) or are named
+  *.min.js/*.min.css are listed in the tree, but their content is replaced with a placeholder
+- Use --include-minified to bundle their content anyway
+
+Large Repositories:
+- The bundling stops with an error if more than --max-files files (default 20000) are
+  selected, narrow the patterns or use --max-files 0 to disable the limit
+
+Long Files:
+- Use --max-lines-per-file N to cut files after N lines, followed by a
+  "… truncated (M more lines)" marker
+- Use --max-lines-filter N to leave files with more than N lines out of the bundle and
+  the project tree entirely, e.g. generated or data-heavy sources; the number of dropped
+  files is shown in the summary. Files specified via --files are always included
+- Use --head-lines N for a compact orientation bundle of a huge codebase with only the
+  first N lines of every file (the smaller limit applies if both are set)
+- Use --max-file-size SIZE (e.g. 500kb) to omit the content of larger files, such as
+  lockfiles and data fixtures; they are listed in the tree with their size
+
+Token Budget:
+- Use --max-tokens N to fit the bundle in a token budget (estimated as 1 token per 3
+  bytes): the files of lowest priority (lock files, vendored and generated code, data,
+  tests, docs, then source files, deeper files first) are dropped and only listed in the
+  tree, or with --trim truncate the largest files are cut to a common size
+- The trimmed files are reported, files specified via --files are never trimmed
+- Use --split-tokens N to write the bundle in parts of at most N tokens for models with
+  smaller context windows (crev-project.part1.txt, crev-project.part2.txt, ...); every part
+  repeats the project tree and no file is split across parts
+- Use --model NAME (gpt-4o, claude-sonnet or gemini-1.5-pro) to set the token budget to
+  the context window of the model, less room for the prompt and the answer, and the format
+  to the one recommended for it (xml for claude-sonnet, text otherwise); --max-tokens and
+  --format override the preset. Tokens are estimated the same way for all models
+
 Config File Integration:
-- Values in .crev-config.yaml are used as defaults
+- Values in .crev-config.yaml (or .crev-config.toml, .crev-config.json, .crev.json) are used as defaults
 - Command line flags override config file values
 - Config file include/exclude patterns are merged with command line patterns
+- Config file redact rules (regex pattern and replacement pairs) are applied to all file content
+- Config file sections set the order of the "tree", "files", "symbols" and "imports" sections and add custom sections,
+  e.g. review instructions, with a title and a content or a file containing it
+- Config file profiles (e.g. 'profiles: {ci: {...}, local: {...}}') override the other values
+  when selected with --env NAME; the ci profile is selected automatically if CI is set
 
 Example usage:
   # Use default include pattern (**/*) with default excludes
@@ -43,14 +148,128 @@ Example usage:
   # Force include a file that would normally be excluded
   crev bundle --files src/vendor/important.go --include='src/**' --exclude='src/vendor/**'
 
+  # Check the include/exclude patterns: list the selected files with their size and tokens
+  crev bundle --dry-run --include='src/**'
+
   # Use custom include patterns with default excludes
   crev bundle --include='src/**' --include='lib/**'
 
   # Combine include and exclude patterns
   crev bundle --include='src/**' --exclude='src/vendor/**'
 
+  # Only bundle files with known text extensions
+  crev bundle --safe-mode
+
+  # Disable the built-in ignore lists, e.g. to bundle dotfiles
+  crev bundle --no-default-excludes --exclude='.git/**'
+
+  # Bundle go.mod and other dependency manifests for context
+  crev bundle --include-manifests
+
+  # Only bundle the files committed to git
+  crev bundle --git-tracked-only
+
+  # Bundle a service as its docker build sees it
+  crev bundle services/api --ignore-source dockerignore
+
+  # Bundle Python files, including extensionless scripts like bin/deploy
+  crev bundle --include='**/*.py' --detect-shebang
+
+  # Only bundle the files mentioning PaymentService
+  crev bundle --include-containing 'PaymentService'
+
   # Bundle from a different directory
-  crev bundle /path/to/project`,
+  crev bundle /path/to/project
+
+  # Bundle from a different directory with paths relative to it, e.g. src/main.go
+  crev bundle /path/to/project --relative-paths
+
+  # Bundle the whole project from a deep subdirectory, found by its .git, go.mod or config file
+  crev bundle --root auto
+
+  # Write the bundle to stdout, e.g. to pipe it into another tool
+  crev bundle --stdout | pbcopy
+
+  # Choose the bundle file, which is never bundled itself
+  crev bundle -o reviews/api.txt
+
+  # Write a single JSON document with the tree and the files to crev-project.json
+  crev bundle --format json
+
+  # Enclose each file in <document> tags for long-context prompts, in crev-project.xml
+  crev bundle --format xml
+
+  # Write a self-contained HTML page with collapsible, highlighted files for human review
+  crev bundle --format html
+
+  # Write one JSON object per file to crev-project.ndjson, streamed as the files are read
+  crev bundle --format ndjson
+
+  # Write a gzip compressed bundle to crev-project.txt.gz
+  crev bundle --compress gzip
+
+  # Write a zstd compressed bundle with a higher compression level to crev-project.txt.zst
+  crev bundle --compress zstd --compress-level 19
+
+  # Encrypt the bundle with age, it can be extracted with "crev unbundle --identity key.txt"
+  crev bundle --encrypt-to age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+
+  # Keep the project tree compact by collapsing directories deeper than three levels
+  crev bundle --depth 3
+
+  # Start the project tree at a root node named after the project, like the tree command
+  crev bundle --tree-label my-project
+
+  # Only bundle the file contents, without the project tree
+  crev bundle --no-tree
+
+  # Wrap the content of each file in a ~~~ code fence without language tag
+  crev bundle --fence --fence-char '~' --fence-language=false
+
+  # Mark the file contents as untrusted data for LLM workflows, against prompt injections
+  crev bundle --mark-untrusted
+
+  # Review the bundle in your editor before sending it
+  crev bundle --open
+
+  # Share the bundle with a teammate as a secret GitHub gist
+  crev bundle --gist
+
+  # Write crev-project.index.json to extract single files without parsing the whole bundle
+  crev bundle --index
+
+  # Share a bundle of another directory without leaking local paths
+  crev bundle ~/work/api --anonymize-paths --path-alias ~/work=work
+
+  # Keep the last 10 bundles in .crev/history/ as an audit trail of what was shared
+  crev bundle --keep 10
+
+  # Assemble one bundle from two directories
+  crev bundle api && crev bundle web --append
+
+  # Append an index of the functions, types and classes of each file to the bundle
+  crev bundle --symbols
+
+  # Append a map of which bundled files import which
+  crev bundle --imports
+
+  # Start the bundle with the last 10 commit messages and the head of the changelog
+  crev bundle --history 10 --changelog-lines 40
+
+  # Start the bundle with the test coverage of each file, least covered first
+  go test -coverprofile=coverage.out ./... && crev bundle --coverage coverage.out
+
+  # Add crev-project.txt to .gitignore on the first run
+  crev bundle --gitignore
+
+  # Emit structured JSON log events for CI systems and wrappers
+  crev bundle --log-format json
+
+  # Run in CI: relative paths, JSON log events and a failing exit code on warnings
+  crev bundle --ci
+
+  # Only print the path of the bundle, e.g. for use in shell scripts
+  bundle=$(crev bundle --quiet)`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get current working directory for output file path
@@ -62,9 +281,17 @@ Example usage:
 		// Create bundle options
 		opts := DefaultBundleOptions()
 
-		// Set root directory
-		if len(args) > 0 {
+		// Set root directory, a directory argument takes precedence over a root from the config
+		root := viper.GetString("root")
+		switch {
+		case len(args) > 0 && cmd.Flags().Changed("root"):
+			return fmt.Errorf("--root cannot be used with a directory argument")
+		case len(args) > 0:
 			opts.RootDir = args[0]
+		case root == rootAuto:
+			opts.AutoRoot = true
+		case root != "":
+			opts.RootDir = root
 		}
 
 		// Set output directory
@@ -74,9 +301,141 @@ Example usage:
 		explicitFiles := viper.GetStringSlice("files")
 		includePatterns := viper.GetStringSlice("include")
 		opts.ExcludePatterns = viper.GetStringSlice("exclude")
+		opts.WarnUnmatchedExcludes = cmd.Flags().Changed("exclude")
+
+		// Get safe mode settings
+		opts.SafeMode = viper.GetBool("safe-mode")
+		opts.SafeExtensions = viper.GetStringSlice("safe-extensions")
+
+		// Get minified asset handling
+		opts.IncludeMinified = viper.GetBool("include-minified")
+		opts.MaxFiles = viper.GetInt("max-files")
+		opts.MaxLinesPerFile = viper.GetInt("max-lines-per-file")
+		if opts.MaxLinesPerFile < 0 {
+			return fmt.Errorf("invalid max-lines-per-file %d: must be 0 (no limit) or more", opts.MaxLinesPerFile)
+		}
+		if err := applyModelPreset(viper.GetString("model")); err != nil {
+			return err
+		}
+		opts.MaxTokens = viper.GetInt("max-tokens")
+		if opts.MaxTokens < 0 {
+			return fmt.Errorf("invalid max-tokens %d: must be 0 (no budget) or more", opts.MaxTokens)
+		}
+		opts.SplitTokens = viper.GetInt("split-tokens")
+		if opts.SplitTokens < 0 {
+			return fmt.Errorf("invalid split-tokens %d: must be 0 (single bundle) or more", opts.SplitTokens)
+		}
+		opts.TrimStrategy = viper.GetString("trim")
+		if !slices.Contains(trimStrategies, opts.TrimStrategy) {
+			return fmt.Errorf("unsupported trim strategy %q (supported: %s)", opts.TrimStrategy, strings.Join(trimStrategies, ", "))
+		}
+		if maxFileSize := viper.GetString("max-file-size"); maxFileSize != "" {
+			if opts.MaxFileSize, err = files.ParseSize(maxFileSize); err != nil {
+				return fmt.Errorf("invalid max-file-size: %w", err)
+			}
+		}
+		opts.MaxLinesFilter = viper.GetInt("max-lines-filter")
+		if opts.MaxLinesFilter < 0 {
+			return fmt.Errorf("invalid max-lines-filter %d: must be 0 (no limit) or more", opts.MaxLinesFilter)
+		}
+		opts.HeadLines = viper.GetInt("head-lines")
+		if opts.HeadLines < 0 {
+			return fmt.Errorf("invalid head-lines %d: must be 0 (no limit) or more", opts.HeadLines)
+		}
+
+		// Get the built-in ignore lists setting
+		opts.NoDefaultExcludes = viper.GetBool("no-default-excludes")
+		opts.DefaultExcludes = configuredDefaultExcludes()
+		opts.IncludeManifests = viper.GetBool("include-manifests")
+
+		// Get the git selection mode
+		opts.GitTrackedOnly = viper.GetBool("git-tracked-only")
+
+		// Get the ignore files to honor
+		opts.IgnoreSources = viper.GetStringSlice("ignore-source")
+
+		// Get the language-aware matching mode
+		opts.DetectShebang = viper.GetBool("detect-shebang")
+
+		// Get the in-file pragmas mode
+		opts.Pragmas = viper.GetBool("pragmas")
+
+		// Get the select expression and the content filter
+		opts.Select = viper.GetString("select")
+		opts.IncludeContaining = viper.GetString("include-containing")
+
+		// Get redaction rules from the config
+		if err := viper.UnmarshalKey("redact", &opts.RedactRules); err != nil {
+			return fmt.Errorf("invalid redact config: %w", err)
+		}
 
-		// Get verbose flag
+		// Get the code fences
+		if viper.GetBool("fence") {
+			opts.Fence, err = fenceOption()
+			if err != nil {
+				return err
+			}
+		}
+
+		opts.MarkUntrusted = viper.GetBool("mark-untrusted")
+		opts.Open, _ = cmd.Flags().GetBool("open")
+		opts.GistPublic, _ = cmd.Flags().GetBool("gist-public")
+		opts.Gist, _ = cmd.Flags().GetBool("gist")
+		opts.Gist = opts.Gist || opts.GistPublic
+
+		// Get the sections of the bundle from the config
+		opts.Sections, err = configuredSections()
+		if err != nil {
+			return err
+		}
+
+		// Get output and verbose flags
+		opts.RelativePaths = viper.GetBool("relative-paths")
+		opts.Anonymizer, err = newPathAnonymizer(viper.GetBool("anonymize-paths"), viper.GetStringSlice("path-alias"))
+		if err != nil {
+			return err
+		}
+		opts.DryRun = viper.GetBool("dry-run")
+		if opts.Output, err = outputSetting(cmd.Flags().Changed("output")); err != nil {
+			return err
+		}
+		opts.Stdout = viper.GetBool("stdout") || opts.Output == files.StdoutSink
+		opts.Compress = viper.GetString("compress")
+		opts.CompressLevel = viper.GetInt("compress-level")
+		opts.EncryptTo = viper.GetStringSlice("encrypt-to")
+		opts.Index = viper.GetBool("index")
+		opts.Append = viper.GetBool("append")
+		opts.Keep = viper.GetInt("keep")
+		if opts.Keep < 0 {
+			return fmt.Errorf("invalid keep %d: must be 0 (disabled) or more", opts.Keep)
+		}
+		opts.CaseCollisions = viper.GetString("case-collisions")
+		if err := validateCaseCollisions(opts.CaseCollisions); err != nil {
+			return err
+		}
+		opts.Format = viper.GetString("format")
+		opts.Symbols = viper.GetBool("symbols")
+		opts.History = viper.GetInt("history")
+		opts.ChangelogLines = viper.GetInt("changelog-lines")
+		if opts.History < 0 || opts.ChangelogLines < 0 {
+			return fmt.Errorf("invalid history %d or changelog-lines %d: must be 0 (disabled) or more", opts.History, opts.ChangelogLines)
+		}
+		opts.Coverage = viper.GetString("coverage")
+		opts.Imports = viper.GetBool("imports")
+		opts.Gitignore = viper.GetBool("gitignore")
+		opts.NoTree = viper.GetBool("no-tree")
+		opts.TreeDepth = viper.GetInt("depth")
+		opts.TreeLabel = viper.GetString("tree-label")
 		opts.Verbose = viper.GetBool("verbose")
+		opts.LogFormat = viper.GetString("log-format")
+		opts.Quiet = viper.GetBool("quiet")
+
+		// CI mode makes the bundle reproducible, the log machine readable and warnings fatal
+		if viper.GetBool("ci") {
+			opts.RelativePaths = true
+			opts.LogFormat = report.LogFormatJSON
+			opts.Strict = true
+		}
 
 		// If files are explicitly specified, we don't modify include patterns
 		if len(explicitFiles) > 0 {
@@ -97,25 +456,168 @@ Example usage:
 	},
 }
 
+// fenceOption returns the code fences configured by the fence flags and config keys
+func fenceOption() (*formatting.Fence, error) {
+	char := viper.GetString("fence-char")
+	if len(char) != 1 {
+		return nil, fmt.Errorf("invalid fence character %q (supported: ` or ~)", char)
+	}
+	fence := &formatting.Fence{
+		Char:     char[0],
+		Length:   viper.GetInt("fence-length"),
+		Language: viper.GetBool("fence-language"),
+	}
+	if err := formatting.ValidateFence(*fence); err != nil {
+		return nil, err
+	}
+	return fence, nil
+}
+
 func init() {
-	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(bundleCmd)
+	addBundleFlags(bundleCmd)
+}
+
+// flagOnlyFlags are the bundle flags that are not bound to viper, so they cannot be set by a
+// config file. They run commands or publish the bundle, which a config from the repository or a
+// remote base config must not be able to trigger.
+var flagOnlyFlags = []string{"open", "gist", "gist-public"}
+
+// addBundleFlags adds the bundle flags to cmd and binds them to viper, except flagOnlyFlags.
+func addBundleFlags(cmd *cobra.Command) {
+	defineBundleFlags(cmd)
+
+	// Bind flags to viper
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if slices.Contains(flagOnlyFlags, flag.Name) {
+			return
+		}
+		viper.BindPFlag(flag.Name, flag)
+	})
+}
 
-	// Add flags without defaults - we'll handle defaults in the RunE function
-	generateCmd.Flags().StringSliceP("files", "f", nil,
+// defineBundleFlags adds the bundle flags to cmd without binding them to viper.
+// Flags are added without defaults - we'll handle defaults in the RunE function.
+func defineBundleFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceP("files", "f", nil,
 		"Specify files to always include (overrides exclude patterns for these files)")
 
-	generateCmd.Flags().StringSliceP("include", "i", nil,
+	cmd.Flags().StringSliceP("include", "i", nil,
 		"Include files matching these glob patterns (e.g., 'src/**', '**/*.go')")
 
-	generateCmd.Flags().StringSliceP("exclude", "e", nil,
+	cmd.Flags().StringSliceP("exclude", "e", nil,
 		"Exclude files matching these glob patterns (except those specified by --files)")
 
-	// Add verbose flag
-	generateCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	cmd.Flags().Bool("safe-mode", false,
+		"Only bundle files with allowlisted text extensions (configurable via 'safe-extensions')")
 
-	// Bind flags to viper
-	viper.BindPFlag("files", generateCmd.Flags().Lookup("files"))
-	viper.BindPFlag("include", generateCmd.Flags().Lookup("include"))
-	viper.BindPFlag("exclude", generateCmd.Flags().Lookup("exclude"))
-	viper.BindPFlag("verbose", generateCmd.Flags().Lookup("verbose"))
+	cmd.Flags().Bool("include-minified", false,
+		"Include the content of minified JS/CSS files instead of a placeholder")
+
+	cmd.Flags().Int("max-files", defaultMaxFiles,
+		"Stop with an error if more files are selected, to catch too broad patterns (0: no limit)")
+
+	cmd.Flags().Int("max-lines-per-file", 0,
+		"Truncate files after this many lines with a \"… truncated (N more lines)\" marker (0: no limit)")
+
+	cmd.Flags().Int("max-tokens", 0,
+		"Trim the least important files (lock files, data, generated code, tests, docs) until the bundle fits in N tokens (0: no budget)")
+	cmd.Flags().String("model", "",
+		"Set --max-tokens and --format to the preset of a model: "+strings.Join(modelNames(), ", "))
+	cmd.Flags().String("trim", trimDrop,
+		"How files are trimmed to fit --max-tokens: drop (listed in the tree only) or truncate (the largest files are cut to a common size)")
+	cmd.Flags().Int("split-tokens", 0,
+		"Write the bundle in parts of at most N tokens (crev-project.part1.txt, ...), each with the project tree, never splitting a file (0: single bundle)")
+	cmd.Flags().String("max-file-size", "",
+		"Omit the content of files larger than this size, e.g. 500kb or 2mb, and only list them in the tree with their size")
+	cmd.Flags().Int("max-lines-filter", 0,
+		"Leave files with more lines out of the bundle entirely, e.g. large data files (0: no limit)")
+
+	cmd.Flags().Int("head-lines", 0,
+		"Preview mode: only bundle the first N lines of every file, with a truncation marker, for a compact overview")
+
+	cmd.Flags().Bool("no-default-excludes", false,
+		"Disable the built-in ignore lists (hidden files, images, fonts, ...) for full control of the selection")
+
+	cmd.Flags().Bool("include-manifests", false,
+		"Bundle dependency manifests (go.mod, go.sum, poetry.lock) that are ignored by default")
+
+	cmd.Flags().Bool("git-tracked-only", false,
+		"Only bundle files tracked by git, skipping ignored and untracked local files")
+
+	cmd.Flags().StringSlice("ignore-source", nil,
+		"Exclude the files ignored by these ignore files of the bundled directory (supported: dockerignore)")
+
+	cmd.Flags().Bool("detect-shebang", false,
+		"Match extensionless scripts against include patterns by the language of their shebang (e.g. '**/*.py')")
+
+	cmd.Flags().Bool("pragmas", false,
+		"Honor '// crev:ignore' and '# crev:include' comments in the first lines of files (except those specified by --files)")
+
+	cmd.Flags().String("select", "",
+		"Only bundle files matching this expression of predicates, e.g. 'lang:go and not test and size<50kb' (except those specified by --files)")
+
+	cmd.Flags().String("include-containing", "",
+		"Only bundle files whose content matches this regular expression (except those specified by --files)")
+
+	// Add output flags
+	cmd.Flags().Bool("relative-paths", false,
+		"Show paths in the tree and file headers relative to the bundled directory instead of the working directory")
+	cmd.Flags().Bool("anonymize-paths", false,
+		"Replace the home directory (~), temp directory ($TMPDIR) and user name (user) in the paths of the bundle")
+	cmd.Flags().StringSlice("path-alias", nil,
+		"Replace a path prefix in the paths of the bundle, as PREFIX=ALIAS, or strip it with PREFIX= (can be repeated)")
+	cmd.Flags().Bool("stdout", false, "Write the bundle to stdout instead of crev-project.txt")
+	cmd.Flags().Bool("dry-run", false,
+		"Print the files that would be bundled with their size and token estimate, without reading them or writing the bundle")
+	cmd.Flags().StringP("output", "o", "",
+		"File or directory (with a trailing /) to write the bundle to instead of crev-project.txt in the working directory, - for stdout, or |command")
+	cmd.Flags().String("root", "",
+		"Directory to bundle, or 'auto' for the nearest parent directory with a .git, go.mod or crev config file")
+	cmd.Flags().Bool("symbols", false,
+		"Append an index of the functions, types and classes defined in each file to the bundle")
+	cmd.Flags().Int("history", 0,
+		"Add the messages of the last N commits to the top of the bundle, for awareness of recent intent (0: disabled)")
+	cmd.Flags().Int("changelog-lines", 0,
+		"Add the first N lines of CHANGELOG.md (or CHANGELOG, CHANGES.md, HISTORY.md) to the top of the bundle (0: disabled)")
+	cmd.Flags().String("coverage", "",
+		"Go coverage profile or lcov tracefile whose coverage of each file is added to the top of the bundle")
+	cmd.Flags().Bool("imports", false,
+		"Append a graph of which bundled files import which, to show the coupling between modules")
+	cmd.Flags().String("format", formatText,
+		"Format of the bundle: text, json for a single document (tree, files with path, size, token estimate and content), "+
+			"xml for <document index=\"1\" path=\"...\"> tags as recommended for long-context prompts, "+
+			"html for a self-contained page with syntax highlighting for human review, "+
+			"or ndjson for one JSON object per file (path, metadata, content) written as it is read")
+	cmd.Flags().Bool("open", false, "Open the bundle in the 'editor' from the global config, $VISUAL, $EDITOR or $PAGER once it is written")
+	cmd.Flags().Bool("gist", false, "Upload the bundle as a secret GitHub gist and print its URL (token from GITHUB_TOKEN, GH_TOKEN or gh)")
+	cmd.Flags().Bool("gist-public", false, "Like --gist, but create a public gist")
+	cmd.Flags().String("compress", "", "Compress the bundle (supported: gzip, zstd), e.g. crev-project.txt.gz")
+	cmd.Flags().Int("compress-level", 0, "Compression level (gzip: 1-9, zstd: 1-22, default: algorithm default)")
+	cmd.Flags().StringSlice("encrypt-to", nil, "Encrypt the bundle with age to these recipients (age1...), e.g. crev-project.txt.age")
+
+	cmd.Flags().Bool("fence", false, "Wrap the content of each file in a Markdown code fence")
+	cmd.Flags().String("fence-char", "`", "Character of the code fences (backtick or ~)")
+	cmd.Flags().Int("fence-length", formatting.MinFenceLength,
+		"Minimum length of the code fences, longer fences are used if the content contains fences")
+	cmd.Flags().Bool("fence-language", true, "Add the language of the file to the opening code fence (e.g. the \"go\" of a Go file)")
+	cmd.Flags().Bool("mark-untrusted", false,
+		"Enclose the content of each file in untrusted content markers, so instructions in files are not followed by LLMs")
+	cmd.Flags().Bool("no-tree", false, "Omit the project tree section, e.g. when only the file contents matter")
+	cmd.Flags().Int("depth", 0, "Only show this many levels of the project tree, collapsing deeper directories")
+	cmd.Flags().String("tree-label", "", "Label of the root node of the project tree, e.g. the project name or '.'")
+	cmd.Flags().String("case-collisions", caseCollisionsWarn,
+		"What to do with selected paths that only differ by case and break unbundling on macOS and Windows: warn, error or dedupe (keep the first)")
+	cmd.Flags().Int("keep", 0,
+		"Archive each bundle in .crev/history/ with the time of the run in its name and keep the newest N (0: disabled)")
+	cmd.Flags().Bool("append", false,
+		"Add the files to the bundle written by an earlier run, e.g. of another directory or profile, instead of replacing it")
+	cmd.Flags().Bool("index", false, "Also write crev-project.index.json with the byte and line offsets of each file in the bundle")
+	cmd.Flags().Bool("gitignore", false, "Add the bundle file to the .gitignore of the output directory if it is not listed yet")
+
+	// Add verbose flag
+	cmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	cmd.Flags().BoolP("quiet", "q", false, "Suppress all logging and only print the path of the output file")
+	cmd.Flags().String("log-format", "text", "Format of log output (text, json); json emits one event per line on stderr")
+	cmd.Flags().Bool("ci", false, "CI mode: relative paths, JSON log events without colors, and a non-zero exit on warnings")
 }
@@ -1,12 +1,23 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"github.com/devinbarry/crev/internal/coverage"
 	"github.com/devinbarry/crev/internal/files"
 	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/devinbarry/crev/internal/redact"
+	"github.com/devinbarry/crev/internal/report"
+	"github.com/devinbarry/crev/internal/symbols"
+	"io"
 	"log"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -17,41 +28,167 @@ type BundleOptions struct {
 	IncludePatterns []string
 	ExcludePatterns []string
 	OutputDir       string
-	MaxConcurrency  int
-	Verbose         bool
+	// Output is the file or directory the bundle is written to instead of crev-project.txt in
+	// OutputDir, or a sink of files.OpenSink such as "|command", see resolveOutput.
+	Output string
+	// OutputName is the name of the bundle file in OutputDir chosen with Output. It is excluded from
+	// the selection like the default bundle names, see outputExcludePatterns.
+	OutputName string
+	// AutoRoot replaces RootDir with the project root of the working directory, see findProjectRoot.
+	AutoRoot       bool
+	MaxConcurrency int
+	Stdout         bool
+	Compress       string
+	CompressLevel  int
+	EncryptTo      []string
+	// TreeDepth limits the number of levels shown in the project tree, 0 shows all levels.
+	TreeDepth int
+	// NoTree omits the project tree section from the bundle.
+	NoTree bool
+	// Fence, if not nil, wraps the content of each file in a Markdown code fence.
+	Fence *formatting.Fence
+	// MarkUntrusted encloses the content of each file in untrusted content markers with a random
+	// nonce, preceded by a notice section telling models not to follow instructions inside them.
+	MarkUntrusted bool
+	// Sections is the order of the sections of the bundle, including custom sections, see sections.go.
+	Sections []formatting.Section
+	// History adds the messages of the last commits to the top of the bundle, see historySections.
+	History int
+	// ChangelogLines adds the first lines of the changelog to the top of the bundle.
+	ChangelogLines int
+	// Coverage is a Go coverage profile or lcov tracefile whose coverage of the bundled files is
+	// added to the top of the bundle and to the NDJSON records.
+	Coverage string
+	// TreeLabel is the label of the root node of the project tree, which has no root node if empty.
+	TreeLabel string
+	// CaseCollisions is the policy for selected paths that only differ by case: warn, error or dedupe.
+	CaseCollisions string
+	// Append adds the files to the bundle written by earlier runs instead of replacing it, see append.go.
+	Append bool
+	// Keep archives the bundle in .crev/history/ of the output directory and keeps the newest Keep
+	// archived bundles, see archive.go.
+	Keep int
+	// Index writes crev-project.index.json with the position of each file inside the bundle.
+	Index bool
+	// Gitignore adds the bundle files to the .gitignore of the output directory if they are not listed yet.
+	Gitignore       bool
+	RedactRules     []redact.Rule
+	SafeMode        bool
+	SafeExtensions  []string
+	IncludeMinified bool
+	// Open opens the bundle in the configured editor or pager once it is written, see editorCommand.
+	Open bool
+	// Gist publishes the bundle as a secret GitHub gist, or a public one if GistPublic is set.
+	Gist       bool
+	GistPublic bool
+	// MaxFiles stops the bundling with an error if more files are selected, 0 disables the limit.
+	MaxFiles int
+	// MaxLinesPerFile truncates files after this many lines, 0 keeps all lines.
+	MaxLinesPerFile int
+	// MaxTokens drops or truncates the least important files until the bundle fits in this many
+	// tokens, see fitTokenBudget, 0 disables the budget.
+	MaxTokens int
+	// TrimStrategy is how files are trimmed to fit MaxTokens: "drop" (default) or "truncate".
+	TrimStrategy string
+	// DryRun prints the selected files with their size and token estimate instead of bundling
+	// them, see writeDryRun.
+	DryRun bool
+	// SplitTokens writes the bundle in parts of at most this many tokens, each with the project
+	// tree, see writeParts, 0 writes a single bundle.
+	SplitTokens int
+	// MaxFileSize omits the content of the files larger than this many bytes, which are only
+	// listed in the project tree with their size, 0 disables the limit.
+	MaxFileSize int64
+	// MaxLinesFilter drops the files with more lines from the selection, 0 disables the filter.
+	MaxLinesFilter int
+	// HeadLines keeps only the first lines of every file for a compact preview bundle, 0 keeps all lines.
+	// If MaxLinesPerFile is set as well, the smaller limit applies.
+	HeadLines int
+	// DefaultExcludes are the ignore lists added to the exclude patterns, see defaults.go.
+	DefaultExcludes DefaultExcludes
+	// NoDefaultExcludes disables the ignore lists.
+	NoDefaultExcludes bool
+	// IncludeManifests removes the dependency manifests (go.mod, go.sum, ...) from the ignore lists.
+	IncludeManifests bool
+	// GitTrackedOnly restricts the selection to the files tracked by git.
+	GitTrackedOnly bool
+	// IgnoreSources are the ignore files of the root directory whose ignored files are excluded, see ignoreSources.
+	IgnoreSources []string
+	// DetectShebang matches extensionless scripts by the language of their shebang line.
+	DetectShebang bool
+	// Pragmas honors the crev:ignore and crev:include comments near the top of files.
+	Pragmas bool
+	// RelativePaths shows the paths in the bundle relative to the root directory instead of the
+	// working directory, so bundles of the same project are identical on every machine.
+	RelativePaths bool
+	// Anonymizer, if not nil, replaces the machine specific prefixes of the paths in the bundle, see anonymize.go.
+	Anonymizer *pathAnonymizer
+	// Select is an expression of file predicates that the selected files must match, see files.SelectExpr.
+	Select string
+	// IncludeContaining is a regular expression that the content of the bundled files must match.
+	IncludeContaining string
+	// WarnUnmatchedExcludes enables warnings for exclude patterns that match nothing. It is only
+	// set for patterns given on the command line, as config files often list generic patterns.
+	WarnUnmatchedExcludes bool
+	// Symbols appends the symbol index of the files to the bundle, see formatting.GenerateSymbolIndex.
+	Symbols bool
+	// Imports appends the import graph of the files to the bundle, see formatting.GenerateImportGraph.
+	Imports bool
+	// Format is the format of the bundle, "text" (default), "json", "xml", "html" or "ndjson", see formatExtensions.
+	Format string
+	// Strict fails the run if any warning was logged, after the bundle is written.
+	Strict bool
+	// LogFormat is the format of the progress log, either "text" (default) or "json".
+	LogFormat string
+	// Quiet suppresses all logging and only prints the path of the output file to stdout.
+	Quiet   bool
+	Verbose bool
 }
 
+// defaultMaxFiles is the default limit of selected files, above which the bundle is most likely
+// too large to be useful and the patterns should be narrower.
+const defaultMaxFiles = 20000
+
 // DefaultBundleOptions returns a BundleOptions with default values
 func DefaultBundleOptions() BundleOptions {
 	return BundleOptions{
-		RootDir:        ".",
-		MaxConcurrency: 100,
+		RootDir:         ".",
+		MaxConcurrency:  100,
+		DefaultExcludes: builtinDefaultExcludes(),
+		MaxFiles:        defaultMaxFiles,
+		CaseCollisions:  caseCollisionsWarn,
 	}
 }
 
-// validateExplicitFiles checks if all explicitly specified files exist
-func validateExplicitFiles(files []string) error {
-	var missingFiles []string
-	for _, file := range files {
-		if _, err := os.Stat(file); os.IsNotExist(err) {
-			missingFiles = append(missingFiles, file)
-		}
-	}
-
-	if len(missingFiles) > 0 {
-		return fmt.Errorf("the following files specified via --files do not exist: %v", missingFiles)
-	}
-	return nil
-}
-
 // Bundle performs the main bundling operation
-func Bundle(opts BundleOptions) error {
+func Bundle(opts BundleOptions) (err error) {
 	start := time.Now()
 
-	// Only log start if verbose is true
-	if opts.Verbose {
-		log.Printf("Starting bundle operation in directory: %s", opts.RootDir)
+	logger, err := report.NewLogger(log.Writer(), opts.LogFormat, opts.Verbose)
+	if err != nil {
+		return err
+	}
+	if opts.Quiet {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	// In strict mode a run with warnings fails, once the bundle is written
+	logger, warnings := report.CountWarnings(logger)
+	if opts.Strict {
+		defer func() {
+			if n := warnings(); err == nil && n > 0 {
+				err = fmt.Errorf("%d warning(s) reported, failing because of strict mode (--ci)", n)
+			}
+		}()
 	}
+	// Bundle the project root of the working directory
+	if opts.AutoRoot {
+		if opts.RootDir, err = detectRootDir(logger); err != nil {
+			return err
+		}
+	}
+	logger.Debug(fmt.Sprintf("Starting bundle operation in directory: %s", opts.RootDir),
+		"phase", "start", "dir", opts.RootDir)
 
 	// Get absolute path for better error messaging
 	absRootDir, err := filepath.Abs(opts.RootDir)
@@ -70,90 +207,805 @@ func Bundle(opts BundleOptions) error {
 		return fmt.Errorf("error accessing directory %q: %w", absRootDir, err)
 	}
 
-	// Validate explicit files if any are specified
+	// Compile the redaction rules before doing any work
+	redactor, err := redact.New(opts.RedactRules)
+	if err != nil {
+		return err
+	}
+
+	// Compile the content filter
+	var contentPattern *regexp.Regexp
+	if opts.IncludeContaining != "" {
+		contentPattern, err = regexp.Compile(opts.IncludeContaining)
+		if err != nil {
+			return fmt.Errorf("invalid --include-containing pattern: %w", err)
+		}
+	}
+
+	// Validate explicit files if any are specified, relative to the root or the working directory
 	if len(opts.ExplicitFiles) > 0 {
-		if err := validateExplicitFiles(opts.ExplicitFiles); err != nil {
+		opts.ExplicitFiles = files.ResolveExplicitFiles(opts.RootDir, opts.ExplicitFiles)
+		if err := files.ValidateExplicitFiles(opts.ExplicitFiles); err != nil {
 			return err
 		}
 	}
 
 	// Add default exclude patterns
-	opts.ExcludePatterns = appendDefaultExcludes(opts.ExcludePatterns)
+	userExcludePatterns := opts.ExcludePatterns
+	opts.ExcludePatterns = excludePatternsWithDefaults(opts)
+
+	// Use the default allowlist in safe mode unless one is configured
+	if opts.SafeMode && len(opts.SafeExtensions) == 0 {
+		opts.SafeExtensions = safeModeExtensions
+	}
+
+	// Debug logs are only shown if verbose
+	logger.Debug(fmt.Sprintf("Files: %v", opts.ExplicitFiles), "phase", "config", "files", opts.ExplicitFiles)
+	logger.Debug(fmt.Sprintf("Includes: %v", opts.IncludePatterns), "phase", "config", "include", opts.IncludePatterns)
+	logger.Debug(fmt.Sprintf("Excludes: %v", opts.ExcludePatterns), "phase", "config", "exclude", opts.ExcludePatterns)
+	if opts.SafeMode {
+		logger.Debug(fmt.Sprintf("Safe mode enabled, allowed extensions: %v", opts.SafeExtensions),
+			"phase", "config", "extensions", opts.SafeExtensions)
+	}
+
+	// Add the recent history to the top of the bundle
+	if opts.History > 0 || opts.ChangelogLines > 0 {
+		history, err := historySections(opts)
+		if err != nil {
+			return err
+		}
+		sections := opts.Sections
+		if len(sections) == 0 {
+			sections = formatting.DefaultSections
+		}
+		opts.Sections = append(history, sections...)
+	}
+	var coverageReport coverage.Report
+	if opts.Coverage != "" {
+		if coverageReport, err = coverage.ReadFile(opts.Coverage); err != nil {
+			return err
+		}
+	}
+
+	// Create output sink target
+	compressionExt, err := files.CompressionExtension(opts.Compress)
+	if err != nil {
+		return err
+	}
+	if err := files.ValidateCompressionLevel(opts.Compress, opts.CompressLevel); err != nil {
+		return err
+	}
+	encryptionExt := ""
+	if len(opts.EncryptTo) > 0 {
+		if _, err := files.ParseRecipients(opts.EncryptTo); err != nil {
+			return err
+		}
+		encryptionExt = files.EncryptionExtension
+	}
+	formatExt, err := formatExtension(opts.Format)
+	if err != nil {
+		return err
+	}
+	if opts.Format == formatNDJSON && (opts.Index || opts.Gist || opts.MarkUntrusted) {
+		return fmt.Errorf("--format %s cannot be used with --index, --gist or --mark-untrusted", opts.Format)
+	}
+	if slices.Contains([]string{formatJSON, formatXML, formatHTML}, opts.Format) && opts.Index {
+		return fmt.Errorf("--format %s cannot be used with --index", opts.Format)
+	}
+	if slices.Contains([]string{formatJSON, formatHTML}, opts.Format) && opts.MarkUntrusted {
+		return fmt.Errorf("--format %s cannot be used with --mark-untrusted", opts.Format)
+	}
+	if opts.Format == formatNDJSON && opts.MaxTokens > 0 {
+		return fmt.Errorf("--max-tokens cannot be used with --format %s, whose files are written as they are read", opts.Format)
+	}
+	if opts.Stdout && opts.Output != "" && opts.Output != files.StdoutSink {
+		return fmt.Errorf("--output cannot be used with --stdout")
+	}
+	outputTarget, err := resolveOutput(opts.Output, opts.OutputDir, bundleBaseName+formatExt+compressionExt+encryptionExt)
+	if err != nil {
+		return err
+	}
+	if files.IsFileSink(outputTarget) && opts.Output != "" {
+		opts.OutputDir, opts.OutputName = outputFile(outputTarget)
+	} else if !files.IsFileSink(outputTarget) && !opts.Stdout {
+		if err := validateStreamOutput(opts); err != nil {
+			return err
+		}
+	}
+	if opts.Append {
+		if err := validateAppend(opts); err != nil {
+			return err
+		}
+	}
+	if opts.SplitTokens > 0 {
+		if err := validateSplit(opts); err != nil {
+			return err
+		}
+	}
+	if opts.Stdout {
+		if opts.Index {
+			return fmt.Errorf("--index cannot be used with --stdout")
+		}
+		if opts.Gitignore {
+			return fmt.Errorf("--gitignore cannot be used with --stdout")
+		}
+		if opts.Keep > 0 {
+			return fmt.Errorf("--keep cannot be used with --stdout")
+		}
+		outputTarget = files.StdoutSink
+	}
 
-	// Debug logs only if verbose
-	if opts.Verbose {
-		log.Printf("Files: %v", opts.ExplicitFiles)
-		log.Printf("Includes: %v", opts.IncludePatterns)
-		log.Printf("Excludes: %v", opts.ExcludePatterns)
+	if opts.OutputName != "" && !opts.DryRun {
+		if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+			return fmt.Errorf("error creating output directory: %w", err)
+		}
 	}
 
-	// Create output file path
-	outputFile := filepath.Join(opts.OutputDir, "crev-project.txt")
+	// Check that the bundle can be opened and published before doing any work
+	if opts.Open {
+		if err := validateOpen(opts); err != nil {
+			return err
+		}
+	}
+	var gistToken string
+	if opts.Gist && !opts.DryRun {
+		if gistToken, err = validateGist(opts); err != nil {
+			return err
+		}
+	}
 
 	// Fetch file paths
-	filePaths, err := files.GetAllFilePaths(opts.RootDir, opts.IncludePatterns, opts.ExcludePatterns, opts.ExplicitFiles)
+	selectStart := time.Now()
+	sel, err := newSelection(opts)
+	if err != nil {
+		return err
+	}
+	sel.Stats = &files.SelectionStats{}
+	entries, err := files.SelectEntries(opts.RootDir, sel)
+	if errors.Is(err, files.ErrTooManyFiles) {
+		return fmt.Errorf("more than %d files selected, narrow the selection with --include/--exclude "+
+			"or raise the limit with --max-files (0 disables it)", opts.MaxFiles)
+	}
 	if err != nil {
 		return fmt.Errorf("error getting file paths: %w", err)
 	}
+	logger.Debug(fmt.Sprintf("Selected %d paths, excluded %d", len(entries), sel.Stats.Excluded()),
+		"phase", "select", "paths", len(entries), "excluded", sel.Stats.Excluded(),
+		report.Duration(time.Since(selectStart)))
 
-	// Debug logging for collected file paths
-	if opts.Verbose {
-		log.Println(filePaths)
-	}
+	// Debug logging for pattern statistics
+	logPatternStats(logger, sel.Stats, opts.IncludePatterns, userExcludePatterns)
 
-	if len(filePaths) == 0 {
-		return fmt.Errorf("no files found to bundle. Please check your include/exclude patterns and the specified path")
+	// Warn about patterns that did not match anything, as they are most likely typos
+	var excludesToCheck []string
+	if opts.WarnUnmatchedExcludes {
+		excludesToCheck = userExcludePatterns
 	}
+	warnUnmatchedPatterns(logger, sel.Stats, opts.IncludePatterns, excludesToCheck)
 
-	// Generate and save the bundle
-	if err := generateBundle(filePaths, outputFile, opts.MaxConcurrency); err != nil {
+	// Paths that only differ by case can not be unbundled on case-insensitive file systems
+	if entries, err = checkCaseCollisions(logger, entries, opts.CaseCollisions); err != nil {
 		return err
 	}
 
-	// Log success
-	log.Printf("Project overview successfully saved to: %s", outputFile)
-	log.Printf("Execution time: %s", time.Since(start))
+	if len(entries) == 0 {
+		return fmt.Errorf("%w. Please check your include/exclude patterns and the specified path", files.ErrNoFilesSelected)
+	}
+
+	// Only list the selected files, without reading them or writing the bundle
+	if opts.DryRun {
+		return writeDryRun(os.Stdout, entries, opts.MaxFileSize)
+	}
+
+	// Add the coverage of the selected files to the top of the bundle
+	var fileCoverage map[string]coverage.File
+	if coverageReport != nil {
+		fileCoverage = selectedCoverage(coverageReport, entries, opts.RootDir)
+		logger.Debug(fmt.Sprintf("Found coverage for %d files in %s", len(fileCoverage), opts.Coverage),
+			"phase", "select", "coverage", len(fileCoverage))
+		sections := opts.Sections
+		if len(sections) == 0 {
+			sections = formatting.DefaultSections
+		}
+		selected := len(slices.DeleteFunc(slices.Clone(entries), func(entry files.Entry) bool { return entry.IsDir }))
+		opts.Sections = append([]formatting.Section{coverageSection(opts.Coverage, fileCoverage, selected)}, sections...)
+	}
+
+	// Generate and save the bundle, NDJSON bundles are streamed file by file
+	var bundle *formatting.Bundle
+	var fileSizes []report.FileSize
+	var written int
+	outputs := []string{outputTarget}
+	if opts.Format == formatNDJSON {
+		fileSizes, written, err = writeNDJSON(logger, entries, fileCoverage, outputTarget, redactor, contentPattern, opts)
+		if err != nil {
+			return err
+		}
+	} else {
+		bundle, outputs, written, err = generateBundle(logger, entries, outputTarget, redactor, contentPattern, opts)
+		if err != nil {
+			return err
+		}
+		for _, file := range bundle.Files {
+			fileSizes = append(fileSizes, report.FileSize{Path: file.Path, Size: file.Size})
+		}
+	}
+
+	// Write the sidecar index of the bundle
+	if opts.Index {
+		// The paths of the files are relative to the root directory or to the working directory
+		indexDir := absRootDir
+		if !opts.RelativePaths {
+			if indexDir, err = filepath.Abs("."); err != nil {
+				return err
+			}
+		}
+		if err := writeIndex(bundle, outputTarget, indexDir); err != nil {
+			return err
+		}
+	}
+
+	// Archive the bundle in the history of the output directory
+	if opts.Keep > 0 {
+		archived, err := archiveBundle(outputTarget, opts.Keep, start)
+		if err != nil {
+			return err
+		}
+		logger.Info(fmt.Sprintf("Archived the bundle to %s", archived), "phase", "write", "archived", archived)
+	}
+
+	// Keep the bundle files out of git
+	if opts.Gitignore {
+		names := []string{filepath.Base(outputTarget)}
+		if opts.SplitTokens > 0 {
+			stem, _ := splitBundleName(names[0])
+			names = []string{stem + partMarker + "*"}
+		}
+		if opts.Index {
+			names = append(names, indexFileName)
+		}
+		if opts.Keep > 0 {
+			names = append(names, historyDir+"/")
+		}
+		added, err := files.AddToGitignore(opts.OutputDir, names...)
+		if err != nil {
+			return err
+		}
+		if len(added) > 0 {
+			logger.Info(fmt.Sprintf("Added %v to .gitignore", added), "phase", "write", "gitignore", added)
+		}
+	}
+
+	// Publish the bundle as a gist, the URL is printed to stdout unless it contains the bundle
+	if opts.Gist {
+		url, err := publishGist(bundle, outputTarget, gistToken, opts)
+		if err != nil {
+			return err
+		}
+		if opts.Stdout {
+			fmt.Fprintln(os.Stderr, url)
+		} else {
+			fmt.Fprintln(os.Stdout, url)
+		}
+	}
+
+	// Let the user review the bundle in the editor
+	if opts.Open {
+		if err := openInEditor(outputTarget); err != nil {
+			return err
+		}
+	}
+
+	// Print the summary of the run
+	summary := report.Summary{
+		Files:        len(fileSizes),
+		Excluded:     sel.Stats.Excluded(),
+		TooLong:      sel.Stats.TooLong,
+		BytesWritten: written,
+		Elapsed:      time.Since(start),
+		LargestFiles: fileSizes,
+	}
+	if !opts.Stdout {
+		summary.Output = strings.Join(outputs, ", ")
+	}
+	if opts.Quiet {
+		if !opts.Stdout {
+			fmt.Fprintln(os.Stdout, strings.Join(outputs, "\n"))
+		}
+		return nil
+	}
+	if opts.LogFormat == report.LogFormatJSON {
+		summary.Log(logger)
+		return nil
+	}
+	if err := summary.Write(log.Writer(), report.ColorEnabled(log.Writer())); err != nil {
+		return fmt.Errorf("error writing summary: %w", err)
+	}
 
 	return nil
 }
 
-// appendDefaultExcludes adds the default exclude patterns to the provided patterns
-func appendDefaultExcludes(patterns []string) []string {
+// ignoreSources are the ignore files supported by --ignore-source, by name, with the function
+// reading them from the root directory. The functions return nil if the file does not exist.
+var ignoreSources = map[string]func(dir string) (*files.IgnoreFile, error){
+	"dockerignore": files.ReadDockerignore,
+}
+
+// newSelection creates the file selection for the bundle options, including the rules of the
+// config files in subdirectories of the root directory
+func newSelection(opts BundleOptions) (files.Selection, error) {
+	// Never select the bundles written to the output directory by earlier runs
+	outputExcludes, err := outputExcludePatterns(opts.RootDir, opts.OutputDir, opts.OutputName)
+	if err != nil {
+		return files.Selection{}, fmt.Errorf("failed to resolve output directory %q: %w", opts.OutputDir, err)
+	}
+	excludePatterns := append(slices.Clip(opts.ExcludePatterns), outputExcludes...)
+
+	sel := files.Selection{
+		IncludePatterns: opts.IncludePatterns,
+		ExcludePatterns: excludePatterns,
+		ExplicitFiles:   opts.ExplicitFiles,
+		DetectShebang:   opts.DetectShebang,
+		Pragmas:         opts.Pragmas,
+		MaxFiles:        opts.MaxFiles,
+		MaxLines:        opts.MaxLinesFilter,
+	}
+
+	// In safe mode only allowlisted text files are selected
+	if opts.SafeMode {
+		sel.Filters = append(sel.Filters, files.ExtensionAllowlistFilter(opts.SafeExtensions))
+	}
+
+	// Only select the files matching the select expression
+	if opts.Select != "" {
+		expr, err := files.ParseSelectExpr(opts.Select)
+		if err != nil {
+			return files.Selection{}, err
+		}
+		filter, err := expr.Filter(opts.RootDir)
+		if err != nil {
+			return files.Selection{}, err
+		}
+		sel.Filters = append(sel.Filters, filter)
+	}
+
+	// Only select the files tracked by git
+	if opts.GitTrackedOnly {
+		tracked, err := files.GitTrackedFiles(opts.RootDir)
+		if err != nil {
+			return files.Selection{}, err
+		}
+		sel.Filters = append(sel.Filters, files.GitTrackedFilter(tracked))
+	}
+
+	// Exclude the files ignored by the .crevignore file of the root directory
+	crevignore, err := files.ReadCrevignore(opts.RootDir)
+	if err != nil {
+		return files.Selection{}, err
+	}
+	if crevignore != nil {
+		sel.Filters = append(sel.Filters, crevignore.Filter())
+	}
+
+	// Exclude the files ignored by the ignore files of the root directory
+	for _, source := range opts.IgnoreSources {
+		read, ok := ignoreSources[source]
+		if !ok {
+			return files.Selection{}, fmt.Errorf("unsupported ignore source %q (supported: %s)", source, strings.Join(slices.Sorted(maps.Keys(ignoreSources)), ", "))
+		}
+		ignore, err := read(opts.RootDir)
+		if err != nil {
+			return files.Selection{}, err
+		}
+		if ignore != nil {
+			sel.Filters = append(sel.Filters, ignore.Filter())
+		}
+	}
+
+	nested, err := loadNestedConfigs(opts.RootDir, excludePatterns)
+	if err != nil {
+		return files.Selection{}, err
+	}
+	if len(nested) > 0 {
+		sel.Filters = append(sel.Filters, files.DirRulesFilter(nested))
+	}
+
+	return sel, nil
+}
+
+// logPatternStats logs how many paths each include and user exclude pattern matched as debug events.
+// The built-in default exclude patterns are reported as a single total.
+func logPatternStats(logger *slog.Logger, stats *files.SelectionStats, includePatterns, userExcludePatterns []string) {
+	for _, pattern := range includePatterns {
+		logger.Debug(fmt.Sprintf("Include pattern %q matched %d files", pattern, stats.Include[pattern]),
+			"phase", "select", "include", pattern, "matches", stats.Include[pattern])
+	}
+
+	userPatterns := make(map[string]bool, len(userExcludePatterns))
+	for _, pattern := range userExcludePatterns {
+		if pattern == "" || userPatterns[pattern] {
+			continue
+		}
+		userPatterns[pattern] = true
+		logger.Debug(fmt.Sprintf("Exclude pattern %q matched %d paths", pattern, stats.Exclude[pattern]),
+			"phase", "select", "exclude", pattern, "matches", stats.Exclude[pattern])
+	}
+
+	defaultMatches := 0
+	for pattern, count := range stats.Exclude {
+		if !userPatterns[pattern] {
+			defaultMatches += count
+		}
+	}
+	logger.Debug(fmt.Sprintf("Built-in default exclude patterns matched %d paths", defaultMatches),
+		"phase", "select", "exclude", "defaults", "matches", defaultMatches)
+}
+
+// warnUnmatchedPatterns logs a warning for each of the given patterns that matched no paths
+func warnUnmatchedPatterns(logger *slog.Logger, stats *files.SelectionStats, includePatterns, excludePatterns []string) {
+	for _, pattern := range includePatterns {
+		if stats.Include[pattern] == 0 {
+			logger.Warn(fmt.Sprintf("include pattern %q did not match any files", pattern),
+				"phase", "select", "include", pattern)
+		}
+	}
+	for _, pattern := range excludePatterns {
+		if pattern != "" && stats.Exclude[pattern] == 0 {
+			logger.Warn(fmt.Sprintf("exclude pattern %q did not match any files", pattern),
+				"phase", "select", "exclude", pattern)
+		}
+	}
+}
+
+// excludePatternsWithDefaults returns the exclude patterns of opts with the patterns of the ignore lists added
+func excludePatternsWithDefaults(opts BundleOptions) []string {
+	if opts.NoDefaultExcludes {
+		return opts.ExcludePatterns
+	}
+	defaults := opts.DefaultExcludes
+	if opts.IncludeManifests {
+		defaults.Files = slices.DeleteFunc(slices.Clone(defaults.Files), func(file string) bool {
+			return slices.Contains(manifestFiles, file)
+		})
+	}
+	return appendDefaultExcludes(opts.ExcludePatterns, defaults)
+}
+
+// appendDefaultExcludes adds the exclude patterns of the ignore lists to the provided patterns
+func appendDefaultExcludes(patterns []string, defaults DefaultExcludes) []string {
 	// Add excludes for prefixes
-	for _, prefix := range specificPrefixesToIgnore {
+	for _, prefix := range defaults.Prefixes {
 		patterns = append(patterns, "**/"+prefix+"*", prefix+"*")
 	}
 
 	// Convert extensions to exclude patterns
-	for _, ext := range specificExtensionsToIgnore {
+	for _, ext := range defaults.Extensions {
 		patterns = append(patterns, "**/*"+ext)
 	}
 
 	// Add specific filenames to exclude patterns
-	for _, file := range specificFilesToIgnore {
+	for _, file := range defaults.Files {
 		patterns = append(patterns, "**/"+file)
 	}
 
 	return patterns
 }
 
-// generateBundle creates the bundle file from the given file paths
-func generateBundle(filePaths []string, outputFile string, maxConcurrency int) error {
-	// Generate the project tree (structure)
-	projectTree := formatting.GeneratePathTree(filePaths)
-
+// generateBundle creates the bundle from the selected entries and writes it to the output sink,
+// or to its parts with opts.SplitTokens. It returns the bundle, the written files and the number
+// of (uncompressed) bytes written.
+// Files whose content does not match contentPattern, if set, are left out of the bundle.
+func generateBundle(logger *slog.Logger, entries []files.Entry, outputTarget string, redactor *redact.Redactor, contentPattern *regexp.Regexp, opts BundleOptions) (bundle *formatting.Bundle, outputs []string, written int, err error) {
 	// Retrieve file contents
-	fileContentMap, err := files.GetContentMapOfFiles(filePaths, maxConcurrency)
+	readStart := time.Now()
+	filePaths := files.EntryPaths(entries)
+	readEntries, treeNotes := omitLargeFiles(logger, entries, opts.MaxFileSize)
+	fileContentMap, err := files.GetContentMapOfEntriesFS(files.HostFS(opts.RootDir), readEntries, opts.MaxConcurrency)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error getting file contents: %w", err)
+	}
+	logger.Debug(fmt.Sprintf("Read %d files", len(fileContentMap)),
+		"phase", "read", "files", len(fileContentMap), report.Duration(time.Since(readStart)))
+
+	// Filter and transform the contents
+	var explicitPaths []string
+	if contentPattern != nil {
+		if explicitPaths, err = explicitSelectionPaths(opts.RootDir, opts.ExplicitFiles); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	// The index records the checksums of the files as read, so "crev verify" can detect changes
+	var checksums map[string]string
+	if opts.Index {
+		checksums = make(map[string]string, len(fileContentMap))
+		for filePath, content := range fileContentMap {
+			checksums[filePath] = files.Checksum(content)
+		}
+	}
+	filePaths = processContents(logger, filePaths, fileContentMap, redactor, contentPattern, explicitPaths, opts)
+	if contentPattern != nil && len(fileContentMap) == 0 {
+		return nil, nil, 0, fmt.Errorf("no files found with content matching %q", contentPattern)
+	}
+
+	// Show the paths as seen from the working directory unless they should be relative to the root
+	if !opts.RelativePaths {
+		filePaths, fileContentMap = joinRootDir(opts.RootDir, filePaths, fileContentMap)
+		_, checksums = joinRootDir(opts.RootDir, nil, checksums)
+		_, treeNotes = joinRootDir(opts.RootDir, nil, treeNotes)
+	}
+	if opts.Anonymizer != nil {
+		filePaths, fileContentMap = opts.Anonymizer.Paths(filePaths, fileContentMap)
+		_, checksums = opts.Anonymizer.Paths(nil, checksums)
+		_, treeNotes = opts.Anonymizer.Paths(nil, treeNotes)
+	}
+
+	// Add the files of the bundle written by earlier runs
+	var existing *formatting.Bundle
+	if opts.Append {
+		if existing, err = readAppendedBundle(outputTarget); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	if existing != nil {
+		if filePaths, err = appendBundleFiles(existing, outputTarget, filePaths, fileContentMap); err != nil {
+			return nil, nil, 0, err
+		}
+		if opts.Index {
+			if err := appendIndexChecksums(outputTarget, checksums); err != nil {
+				return nil, nil, 0, err
+			}
+		}
+		logger.Info(fmt.Sprintf("Appending to the %d files of %s", len(existing.Files), outputTarget),
+			"phase", "read", "appended_to", len(existing.Files))
+	}
+
+	// Build the bundle model
+	build := func(fileContentMap, treeNotes map[string]string) (*formatting.Bundle, error) {
+		return buildBundle(filePaths, fileContentMap, checksums, treeNotes, existing, opts)
+	}
+	if bundle, err = build(fileContentMap, treeNotes); err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Drop or truncate the least important files until the bundle fits in the token budget
+	if opts.MaxTokens > 0 {
+		pinned, err := pinnedPaths(opts)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if bundle, err = fitTokenBudget(logger, bundle, fileContentMap, treeNotes, pinned, opts, build); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+
+	// Write the bundle in parts that each fit in the token budget of a part
+	if opts.SplitTokens > 0 {
+		outputs, written, err := writeParts(logger, bundle, outputTarget, opts)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		return bundle, outputs, written, nil
+	}
+
+	// Render the bundle to the output sink
+	sink, err := openOutput(outputTarget, opts)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer func() {
+		if closeErr := sink.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing output: %w", closeErr)
+		}
+	}()
+
+	writeStart := time.Now()
+	counter := &countingWriter{w: sink}
+	if err := bundleFormatter(opts.Format).Write(counter, bundle); err != nil {
+		return nil, nil, 0, fmt.Errorf("error saving file: %w", err)
+	}
+	logger.Debug(fmt.Sprintf("Wrote %d bytes", counter.n),
+		"phase", "write", "bytes", counter.n, report.Duration(time.Since(writeStart)))
+
+	return bundle, []string{outputTarget}, counter.n, nil
+}
+
+// buildBundle creates the bundle model of the files in fileContentMap, with the project tree of
+// filePaths annotated with treeNotes, the appendices and the sections of opts and of the existing
+// bundle, if any, appended to.
+func buildBundle(filePaths []string, fileContentMap, checksums, treeNotes map[string]string, existing *formatting.Bundle, opts BundleOptions) (*formatting.Bundle, error) {
+	bundle := formatting.NewBundle(filePaths, fileContentMap)
+	for i := range bundle.Files {
+		bundle.Files[i].SHA256 = checksums[bundle.Files[i].Path]
+	}
+	bundle.Sections = opts.Sections
+	if existing != nil {
+		bundle.Sections = appendSections(existing.Sections, opts.Sections)
+	}
+	bundle.Fence = opts.Fence
+	if opts.MarkUntrusted {
+		var err error
+		if bundle.Sentinel, err = formatting.NewSentinel(bundle); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Symbols {
+		bundle.Symbols = formatting.GenerateSymbolIndex(bundle.Files)
+	}
+	if opts.Imports {
+		// Go imports are resolved with the module path of the root directory, if it is a Go module
+		goMod, _ := os.ReadFile(filepath.Join(opts.RootDir, "go.mod"))
+		bundle.Imports = formatting.GenerateImportGraph(bundle.Files, symbols.GoModulePath(string(goMod)))
+	}
+	switch {
+	case opts.NoTree:
+		bundle.Tree = ""
+	case opts.TreeDepth > 0 || opts.TreeLabel != "" || len(treeNotes) > 0:
+		bundle.Tree = formatting.GenerateAnnotatedPathTree(filePaths, opts.TreeDepth, opts.TreeLabel, treeNotes)
+	}
+	return bundle, nil
+}
+
+// processContents applies the content filter, the minified asset placeholders, the redaction
+// rules and the line limit to the read files in fileContentMap, in that order. It returns the
+// paths of the files that are kept, files whose content does not match contentPattern are
+// removed from the map unless they are in explicitPaths.
+func processContents(logger *slog.Logger, filePaths []string, fileContentMap map[string]string, redactor *redact.Redactor, contentPattern *regexp.Regexp, explicitPaths []string, opts BundleOptions) []string {
+	// Only keep the files whose content matches, before any further processing of the content
+	if contentPattern != nil {
+		read := len(fileContentMap)
+		filePaths = files.FilterByContent(filePaths, fileContentMap, contentPattern, explicitPaths)
+		logger.Debug(fmt.Sprintf("Skipped %d files not matching %q", read-len(fileContentMap), contentPattern),
+			"phase", "read", "include_containing", contentPattern.String(), "skipped", read-len(fileContentMap))
+	}
+
+	// Replace minified assets with a placeholder
+	if !opts.IncludeMinified {
+		replaced := files.ReplaceMinified(fileContentMap)
+		if len(replaced) > 0 {
+			logger.Debug(fmt.Sprintf("Skipped minified files: %v", replaced),
+				"phase", "read", "minified", replaced)
+		}
+	}
+
+	// Scrub the file contents with the redaction rules
+	redactor.ApplyAll(fileContentMap)
+
+	// Truncate long files after redaction, so secrets spanning the cut are still redacted
+	maxLines := lineLimit(opts)
+	truncated := files.TruncateLines(fileContentMap, maxLines)
+	if len(truncated) > 0 {
+		logger.Debug(fmt.Sprintf("Truncated files longer than %d lines: %v", maxLines, truncated),
+			"phase", "read", "truncated", truncated)
+	}
+	return filePaths
+}
+
+// lineLimit returns the number of lines files are truncated after, the smaller of
+// opts.MaxLinesPerFile and opts.HeadLines if both are set, 0 if none is.
+func lineLimit(opts BundleOptions) int {
+	if opts.MaxLinesPerFile > 0 && opts.HeadLines > 0 {
+		return min(opts.MaxLinesPerFile, opts.HeadLines)
+	}
+	return max(opts.MaxLinesPerFile, opts.HeadLines)
+}
+
+// omitLargeFiles returns the entries to read, without the files larger than maxSize bytes, and
+// the notes showing the size of the left out files in the project tree. A maxSize of 0 keeps all files.
+func omitLargeFiles(logger *slog.Logger, entries []files.Entry, maxSize int64) ([]files.Entry, map[string]string) {
+	if maxSize <= 0 {
+		return entries, nil
+	}
+	var kept []files.Entry
+	notes := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir && entry.Size > maxSize {
+			notes[entry.Path] = report.FormatBytes(int(entry.Size)) + ", omitted"
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if len(notes) > 0 {
+		omitted := slices.Sorted(maps.Keys(notes))
+		logger.Debug(fmt.Sprintf("Omitted the content of files larger than %s: %v", report.FormatBytes(int(maxSize)), omitted),
+			"phase", "read", "max_file_size", maxSize, "omitted", omitted)
+	}
+	return kept, notes
+}
+
+// openOutput opens the sink of outputTarget, encrypting and compressing what is written to it
+// according to opts.
+func openOutput(outputTarget string, opts BundleOptions) (io.WriteCloser, error) {
+	sink, err := files.OpenSink(outputTarget)
 	if err != nil {
-		return fmt.Errorf("error getting file contents: %w", err)
+		return nil, fmt.Errorf("error opening output: %w", err)
 	}
+	if len(opts.EncryptTo) > 0 {
+		recipients, err := files.ParseRecipients(opts.EncryptTo)
+		if err != nil {
+			sink.Close()
+			return nil, err
+		}
+		encrypted, err := files.NewEncryptedSink(sink, recipients)
+		if err != nil {
+			sink.Close()
+			return nil, fmt.Errorf("error encrypting output: %w", err)
+		}
+		sink = encrypted
+	}
+	compressed, err := files.NewCompressedSink(sink, opts.Compress, opts.CompressLevel)
+	if err != nil {
+		sink.Close()
+		return nil, err
+	}
+	return compressed, nil
+}
 
-	// Create and save the project string
-	projectString := formatting.CreateProjectString(projectTree, fileContentMap)
-	if err := files.SaveStringToFile(projectString, outputFile); err != nil {
-		return fmt.Errorf("error saving file: %w", err)
+// joinRootDir returns the paths relative to the root directory, and the keys of the content map,
+// joined with the root directory.
+func joinRootDir(rootDir string, filePaths []string, fileContentMap map[string]string) ([]string, map[string]string) {
+	joinedPaths := make([]string, len(filePaths))
+	for i, p := range filePaths {
+		joinedPaths[i] = filepath.ToSlash(filepath.Join(rootDir, p))
 	}
+	joinedContents := make(map[string]string, len(fileContentMap))
+	for p, content := range fileContentMap {
+		joinedContents[filepath.ToSlash(filepath.Join(rootDir, p))] = content
+	}
+	return joinedPaths, joinedContents
+}
 
-	log.Printf("Estimated token count: %d - %d tokens", len(projectString)/4, len(projectString)/3)
+// explicitSelectionPaths returns the explicit files as the slash-separated paths relative to root
+// that files.Select returns for them
+func explicitSelectionPaths(root string, explicitFiles []string) ([]string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(explicitFiles))
+	for _, file := range explicitFiles {
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		relPath, err := filepath.Rel(absRoot, absPath)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, filepath.ToSlash(relPath))
+	}
+	return paths, nil
+}
+
+// writeIndex writes the index of the bundle next to the bundle saved at outputTarget, with the
+// directory its relative file paths are resolved against. The offsets refer to the uncompressed,
+// unencrypted plain text bundle.
+func writeIndex(bundle *formatting.Bundle, outputTarget, dir string) (err error) {
+	sink, err := files.OpenSink(filepath.Join(filepath.Dir(outputTarget), indexFileName))
+	if err != nil {
+		return fmt.Errorf("error opening index: %w", err)
+	}
+	defer func() {
+		if closeErr := sink.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing index: %w", closeErr)
+		}
+	}()
+
+	index := formatting.NewIndex(filepath.Base(outputTarget), bundle)
+	index.Dir = dir
+	if err := index.WriteJSON(sink); err != nil {
+		return fmt.Errorf("error writing index: %w", err)
+	}
 	return nil
 }
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
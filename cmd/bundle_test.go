@@ -267,3 +267,132 @@ func TestBundleCommandHandlesNonExistentPath(t *testing.T) {
 	err := env.executeBundleCmd(nonExistentDir)
 	env.assertErrorContains(err, "does not exist")
 }
+
+// TestBundleCommandWithRedactRules tests that redact rules from the config are applied to all file content.
+func TestBundleCommandWithRedactRules(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"main.go":        `const api = "https://billing.internal.acme.com/v1" // JIRA-1234`,
+		"config/app.yml": "host: auth.internal.acme.com",
+	}
+	env.createProjectStructure(files)
+
+	configContent := `
+include:
+  - "**/*"
+redact:
+  - pattern: "([a-z]+)\\.internal\\.acme\\.com"
+    replacement: "${1}.example.com"
+  - pattern: "JIRA-[0-9]+"
+    replacement: "TICKET"
+`
+	env.setupConfig(configContent)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err, "Bundle command execution failed")
+
+	expectedContent := []string{
+		`const api = "https://billing.example.com/v1" // TICKET`,
+		"host: auth.example.com",
+	}
+	unexpectedContent := []string{"internal.acme.com", "JIRA-1234"}
+	env.assertFileContents("crev-project.txt", expectedContent, unexpectedContent)
+}
+
+// TestBundleCommandWithInvalidRedactRule tests that an invalid redact pattern is reported.
+func TestBundleCommandWithInvalidRedactRule(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	configContent := `
+redact:
+  - pattern: "([a-z"
+    replacement: "x"
+`
+	env.setupConfig(configContent)
+
+	err := env.executeBundleCmd(".")
+	env.assertErrorContains(err, `invalid redact pattern "([a-z"`)
+}
+
+// TestBundleCommandWithRelativePaths tests bundling another directory with paths relative to the
+// working directory or, with --relative-paths, to the bundled directory.
+func TestBundleCommandWithRelativePaths(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"project/src/main.go": "package main",
+		"notes.txt":           "notes",
+	})
+
+	err := env.executeBundleCmd("project")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt", []string{"File: \nproject/src/main.go\nContent: \npackage main"}, nil)
+
+	err = env.executeBundleCmd("project", "--relative-paths", "--files", "notes.txt", "--include", "**/*")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt",
+		[]string{"File: \nsrc/main.go\nContent: \npackage main", "File: \n../notes.txt\nContent: \nnotes"},
+		[]string{"project/src"})
+}
+
+// TestBundleCommandWithAutoRoot tests bundling the project root found above the working directory
+func TestBundleCommandWithAutoRoot(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"go.mod":                "module example.com/app",
+		".crev-config.yaml":     "exclude:\n  - 'docs/**'\n",
+		"main.go":               "package main",
+		"docs/guide.md":         "# Guide",
+		"internal/deep/util.go": "package deep",
+	})
+	require.NoError(t, os.Chdir("internal/deep"))
+
+	err := env.executeBundleCmd("--root", "auto", "--relative-paths", "--include-manifests")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt",
+		[]string{"File: \nmain.go\n", "File: \ngo.mod\n", "File: \ninternal/deep/util.go\n"},
+		[]string{"docs/guide.md"})
+	env.assertLogContains("Bundling the project root ../..")
+
+	err = env.executeBundleCmd(".", "--root", "auto")
+	env.assertErrorContains(err, "--root cannot be used with a directory argument")
+}
+
+// TestBundleCommandWithAnonymizedPaths tests replacing the machine specific prefixes of the paths
+// of a bundle of an absolute directory.
+func TestBundleCommandWithAnonymizedPaths(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"project/src/main.go": "package main"})
+	projectDir := filepath.Join(env.TempDir, "project")
+
+	err := env.executeBundleCmd(projectDir, "--anonymize-paths")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt", []string{"File: \n$TMPDIR/"}, []string{env.TempDir})
+
+	err = env.executeBundleCmd(projectDir, "--anonymize-paths", "--path-alias", env.TempDir+"=work")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt",
+		[]string{"└── work (1 file)\n    └── project (1 file)\n", "File: \nwork/project/src/main.go\nContent: \npackage main"}, []string{env.TempDir})
+
+	err = env.executeBundleCmd(projectDir, "--path-alias", projectDir+"=")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt", []string{"File: \nsrc/main.go\nContent: \npackage main"}, nil)
+
+	err = env.executeBundleCmd(projectDir, "--path-alias", "work")
+	env.assertErrorContains(err, `invalid path alias "work": expected PREFIX=ALIAS`)
+}
+
+// TestPathAnonymizer tests the replacement of prefixes and user names in paths.
+func TestPathAnonymizer(t *testing.T) {
+	a := &pathAnonymizer{
+		aliases:  []pathAlias{{prefix: "/home/alice/.cache/tmp", alias: "$TMPDIR"}, {prefix: "/home/alice", alias: "~"}},
+		username: "alice",
+	}
+	require.Equal(t, "~/src/main.go", a.Path("/home/alice/src/main.go"))
+	require.Equal(t, "$TMPDIR/build/out.go", a.Path("/home/alice/.cache/tmp/build/out.go"))
+	require.Equal(t, "/home/alicex/main.go", a.Path("/home/alicex/main.go"))
+	require.Equal(t, "../../user/shared/util.go", a.Path("../../alice/shared/util.go"))
+
+	var none *pathAnonymizer
+	require.Equal(t, "/home/alice/main.go", none.Path("/home/alice/main.go"))
+}
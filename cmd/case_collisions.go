@@ -0,0 +1,66 @@
+// Description: This file contains the detection of selected paths that only differ by case.
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+)
+
+const (
+	// caseCollisionsWarn logs a warning for each group of paths that only differ by case.
+	caseCollisionsWarn = "warn"
+	// caseCollisionsError fails the bundle if paths only differ by case.
+	caseCollisionsError = "error"
+	// caseCollisionsDedupe keeps the first path of each group in sort order and leaves out the others.
+	caseCollisionsDedupe = "dedupe"
+)
+
+// caseCollisionPolicies are the supported values of --case-collisions.
+var caseCollisionPolicies = []string{caseCollisionsWarn, caseCollisionsError, caseCollisionsDedupe}
+
+// validateCaseCollisions checks that policy is a supported case collision policy.
+func validateCaseCollisions(policy string) error {
+	if !slices.Contains(caseCollisionPolicies, policy) {
+		return fmt.Errorf("unsupported case-collisions policy %q (supported: %s)", policy, strings.Join(caseCollisionPolicies, ", "))
+	}
+	return nil
+}
+
+// checkCaseCollisions applies policy to the selected files whose paths only differ by case, as
+// they can not be extracted side by side on case-insensitive file systems. It returns the entries
+// left after deduplication.
+func checkCaseCollisions(logger *slog.Logger, entries []files.Entry, policy string) ([]files.Entry, error) {
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir {
+			paths = append(paths, entry.Path)
+		}
+	}
+	collisions := files.CaseCollisions(paths)
+	if len(collisions) == 0 {
+		return entries, nil
+	}
+
+	dropped := make(map[string]bool)
+	for _, group := range collisions {
+		switch policy {
+		case caseCollisionsError:
+			return nil, fmt.Errorf("paths only differ by case, which breaks unbundling on case-insensitive file systems: %s (use --case-collisions dedupe to keep one of them)",
+				strings.Join(group, ", "))
+		case caseCollisionsDedupe:
+			logger.Info(fmt.Sprintf("Keeping %s and leaving out %s, which only differ by case", group[0], strings.Join(group[1:], ", ")),
+				"phase", "select", "kept", group[0], "dropped", group[1:])
+			for _, p := range group[1:] {
+				dropped[p] = true
+			}
+		default:
+			logger.Warn(fmt.Sprintf("paths %s only differ by case and can not be unbundled on case-insensitive file systems", strings.Join(group, ", ")),
+				"phase", "select", "paths", group)
+		}
+	}
+	return slices.DeleteFunc(entries, func(entry files.Entry) bool { return dropped[entry.Path] }), nil
+}
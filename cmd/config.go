@@ -0,0 +1,407 @@
+// Description: This file implements the "config" command, which inspects and edits the layered configuration.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and edit the crev configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration and where each value came from",
+	Long: `Print the fully merged effective configuration of "crev bundle", annotating each value with
+the layer it came from. Later layers override earlier ones:
+
+  default  the built-in default of the flag
+  extends  a base config extended by the global or project config file
+  global   the global config file (e.g. ~/.config/crev/config.yaml)
+  project  the project config file in the current directory (.crev-config.yaml, .toml or .json)
+  profile  the profile of the 'profiles' key selected with --env, or ci on CI services
+  env      an environment variable named like the upper-cased key (e.g. EXCLUDE)
+  flag     a bundle flag passed to this command
+
+Example usage:
+  # Show the effective configuration
+  crev config show
+
+  # Show how a flag combines with the config files
+  crev config show --exclude 'dist/**'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showConfig(cmd.OutOrStdout(), cmd.Flags())
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a config key",
+	Long: `Print the effective value of a config key, after merging the global and project config files
+and the environment. Lists are printed one item per line.
+
+Example usage:
+  crev config get exclude`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return getConfig(cmd.OutOrStdout(), args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Set a config key in the project config file",
+	Long: `Set a config key in the project config file (.crev-config.yaml), creating the file if needed.
+The rest of the file, including comments, is left untouched.
+
+For list keys such as include and exclude, a value starting with "+" is appended to the list and
+a value starting with "-" is removed from it. Other values replace the whole list, and several
+comma separated items can be given.
+
+Example usage:
+  # Add a pattern to the exclude list
+  crev config set exclude '+dist/**'
+
+  # Remove a pattern from the exclude list
+  crev config set exclude '-**/*.md'
+
+  # Replace the include list
+  crev config set include 'src/**,lib/**'
+
+  # Set a value in the global config file
+  crev config set --global compress zstd`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := projectConfigFile(".")
+		if file == "" {
+			file = ".crev-config.yaml"
+		}
+		if global, _ := cmd.Flags().GetBool("global"); global {
+			file = globalConfigFile()
+			if file == "" {
+				return fmt.Errorf("unable to determine the location of the global config file")
+			}
+		}
+		if err := setConfig(file, args[0], args[1:]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Updated %s in %s\n", args[0], file)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	addConfigShowFlags(configShowCmd)
+	addConfigSetFlags(configSetCmd)
+}
+
+// addConfigSetFlags adds the config set flags to cmd.
+func addConfigSetFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("global", false, "Write to the global config file instead of the project config file")
+	// Stop parsing flags after the key, so values like "-**/*.md" are not taken for flags
+	cmd.Flags().SetInterspersed(false)
+}
+
+// configListKeys contains the config keys that are lists but not flags
+var configListKeys = []string{"safe-extensions"}
+
+// configScalarKeys maps the config keys that are single values but not bundle flags to their type
+var configScalarKeys = map[string]string{"editor": "string", "extends": "string", "offline": "bool"}
+
+// configKeyType returns the type of a config key, using the type of the bundle flag of the same
+// name. ok is false for unknown keys and for flagOnlyFlags, which cannot be set in a config.
+func configKeyType(key string) (keyType string, ok bool) {
+	if slices.Contains(configListKeys, key) {
+		return "stringSlice", true
+	}
+	if keyType, ok := configScalarKeys[key]; ok {
+		return keyType, true
+	}
+	if slices.Contains(flagOnlyFlags, key) {
+		return "", false
+	}
+	flags := &cobra.Command{}
+	defineBundleFlags(flags)
+	if flag := flags.Flags().Lookup(key); flag != nil {
+		return flag.Value.Type(), true
+	}
+	return "", false
+}
+
+// getConfig writes the effective value of key to w
+func getConfig(w io.Writer, key string) error {
+	flags := &cobra.Command{}
+	defineBundleFlags(flags)
+	values, err := resolveConfig(flags.Flags())
+	if err != nil {
+		return err
+	}
+	value, ok := values[key]
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+
+	switch v := value.value.(type) {
+	case []string:
+		for _, item := range v {
+			fmt.Fprintln(w, item)
+		}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				fmt.Fprintln(w, s)
+				continue
+			}
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				return fmt.Errorf("error encoding %s: %w", key, err)
+			}
+			fmt.Fprintln(w, string(encoded))
+		}
+	default:
+		fmt.Fprintln(w, v)
+	}
+	return nil
+}
+
+// setConfig sets key to values in the config file, see configSetCmd for the syntax of the values
+func setConfig(file, key string, values []string) error {
+	if ext := filepath.Ext(file); ext != ".yaml" && ext != ".yml" {
+		return fmt.Errorf("only YAML config files can be edited, edit %s directly", file)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read config file: %w", err)
+	}
+	doc, err := parseConfigDocument(content)
+	if err != nil {
+		return err
+	}
+
+	keyType, ok := configKeyType(key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	if keyType != "stringSlice" && len(values) > 1 {
+		return fmt.Errorf("%q takes a single value", key)
+	}
+	switch keyType {
+	case "stringSlice":
+		var replacement []string
+		replace := false
+		for _, value := range values {
+			switch {
+			case strings.HasPrefix(value, "+"):
+				item := strings.TrimPrefix(value, "+")
+				if !slices.Contains(doc.listItems(key), item) {
+					if err := doc.appendToList(key, "", item); err != nil {
+						return err
+					}
+				}
+			case strings.HasPrefix(value, "-"):
+				if _, err := doc.removeFromList(key, strings.TrimPrefix(value, "-")); err != nil {
+					return err
+				}
+			default:
+				replace = true
+				if value != "" {
+					replacement = append(replacement, strings.Split(value, ",")...)
+				}
+			}
+		}
+		if replace {
+			if replacement == nil {
+				replacement = []string{}
+			}
+			if err := doc.setValue(key, replacement); err != nil {
+				return err
+			}
+		}
+	case "bool":
+		value, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s, expected true or false", values[0], key)
+		}
+		if err := doc.setValue(key, value); err != nil {
+			return err
+		}
+	case "int":
+		value, err := strconv.Atoi(values[0])
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s, expected a number", values[0], key)
+		}
+		if err := doc.setValue(key, value); err != nil {
+			return err
+		}
+	default:
+		if err := doc.setValue(key, values[0]); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("unable to create config directory: %w", err)
+	}
+	if err := os.WriteFile(file, doc.bytes(), 0644); err != nil {
+		return fmt.Errorf("unable to write config file: %w", err)
+	}
+	return nil
+}
+
+// addConfigShowFlags adds the bundle flags to cmd, without binding them to viper.
+func addConfigShowFlags(cmd *cobra.Command) {
+	defineBundleFlags(cmd)
+}
+
+// configValue is the effective value of a config key and the layer it came from
+type configValue struct {
+	value  any
+	source string
+}
+
+// resolveConfig returns the effective value of every known config key, applying the
+// layers in the same order of precedence as viper.
+func resolveConfig(flags *pflag.FlagSet) (map[string]configValue, error) {
+	values := make(map[string]configValue)
+
+	// Defaults of the bundle flags
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == "help" {
+			return
+		}
+		values[flag.Name] = configValue{value: flagValue(flags, flag), source: "default"}
+	})
+
+	// Global and project config files, on top of the configs they extend
+	var err error
+	layers := []struct {
+		name string
+		file string
+	}{
+		{"global", globalConfigFile()},
+		{"project", viper.ConfigFileUsed()},
+	}
+	for _, layer := range layers {
+		if layer.file == "" {
+			continue
+		}
+		if _, err := os.Stat(layer.file); err != nil {
+			continue
+		}
+		v := viper.New()
+		v.SetConfigFile(layer.file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading %s config %s: %w", layer.name, layer.file, err)
+		}
+		var base map[string]any
+		if ref := v.GetString("extends"); ref != "" {
+			base, err = loadExtends(ref, layer.file, nil)
+			if err != nil {
+				return nil, fmt.Errorf("error reading the config extended by %s config %s: %w", layer.name, layer.file, err)
+			}
+			for key, value := range base {
+				values[key] = configValue{value: value, source: "extends (" + ref + ")"}
+			}
+		}
+		for _, key := range v.AllKeys() {
+			value := v.Get(key)
+			if key == "exclude" && base != nil {
+				// The exclude patterns of the extended config are kept
+				value = appendUnique(toStringSlice(base[key]), toStringSlice(value)...)
+			}
+			values[key] = configValue{value: value, source: layer.name + " (" + layer.file + ")"}
+		}
+	}
+
+	// Profile selected with --env or by the CI environment variable
+	if name, _ := selectedProfile(); name != "" {
+		if profile := profileSettings(name); profile != nil {
+			for _, key := range profile.AllKeys() {
+				values[key] = configValue{value: profile.Get(key), source: "profile (" + name + ")"}
+			}
+		}
+	}
+
+	// Environment variables
+	for key := range values {
+		if value, ok := os.LookupEnv(envName(key)); ok {
+			values[key] = configValue{value: value, source: "env (" + envName(key) + ")"}
+		}
+	}
+
+	// Flags
+	flags.Visit(func(flag *pflag.Flag) {
+		values[flag.Name] = configValue{value: flagValue(flags, flag), source: "flag (--" + flag.Name + ")"}
+	})
+
+	// The flags that are not read from the config are no config keys
+	for _, key := range flagOnlyFlags {
+		delete(values, key)
+	}
+
+	return values, nil
+}
+
+// flagValue returns the typed value of a flag
+func flagValue(flags *pflag.FlagSet, flag *pflag.Flag) any {
+	switch flag.Value.Type() {
+	case "stringSlice":
+		value, _ := flags.GetStringSlice(flag.Name)
+		if value == nil {
+			value = []string{}
+		}
+		return value
+	case "bool":
+		value, _ := flags.GetBool(flag.Name)
+		return value
+	case "int":
+		value, _ := flags.GetInt(flag.Name)
+		return value
+	default:
+		return flag.Value.String()
+	}
+}
+
+// showConfig writes the effective configuration to w, one key per line with its source as a comment.
+// The values are written as JSON, which is also valid YAML.
+func showConfig(w io.Writer, flags *pflag.FlagSet) error {
+	values, err := resolveConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		encoded, err := json.Marshal(values[key].value)
+		if err != nil {
+			return fmt.Errorf("error encoding %s: %w", key, err)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s  # %s\n", key, encoded, values[key].source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// Description: This file contains the editing of config files as text, preserving the formatting and comments of the user.
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configDocument is a config file that is edited line by line. The YAML node tree is only used
+// to locate keys and list items, so everything that is not edited stays exactly as it was.
+type configDocument struct {
+	lines []string
+	root  *yaml.Node
+}
+
+// parseConfigDocument parses the content of a config file
+func parseConfigDocument(content []byte) (*configDocument, error) {
+	d := &configDocument{lines: strings.Split(string(content), "\n")}
+	if err := d.parse(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// parse updates the node tree after the lines were edited
+func (d *configDocument) parse() error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(d.lines, "\n")), &doc); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+	d.root = nil
+	if len(doc.Content) > 0 {
+		d.root = doc.Content[0]
+		if d.root.Kind != yaml.MappingNode {
+			return fmt.Errorf("invalid config file: expected a mapping at the top level")
+		}
+	}
+	return nil
+}
+
+// bytes returns the content of the edited config file
+func (d *configDocument) bytes() []byte {
+	content := strings.Join(d.lines, "\n")
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return []byte(content)
+}
+
+// lookup returns the key and value nodes of a top level key, or nil if it does not exist
+func (d *configDocument) lookup(key string) (*yaml.Node, *yaml.Node) {
+	if d.root == nil {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(d.root.Content); i += 2 {
+		if d.root.Content[i].Value == key {
+			return d.root.Content[i], d.root.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// listItems returns the items of a top level list
+func (d *configDocument) listItems(key string) []string {
+	_, value := d.lookup(key)
+	if value == nil || value.Kind != yaml.SequenceNode {
+		return nil
+	}
+	items := make([]string, 0, len(value.Content))
+	for _, item := range value.Content {
+		items = append(items, item.Value)
+	}
+	return items
+}
+
+// isBlockList reports whether value is a non-empty list written as "- item" lines
+func isBlockList(value *yaml.Node) bool {
+	return value.Kind == yaml.SequenceNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0
+}
+
+// isNull reports whether value is empty, as in "key:" without a value
+func isNull(value *yaml.Node) bool {
+	return value.Kind == yaml.ScalarNode && value.Tag == "!!null"
+}
+
+// appendToList appends items to a top level list, preceded by comment if it is not empty.
+// The list is created at the end of the file if the key does not exist.
+func (d *configDocument) appendToList(key, comment string, items ...string) error {
+	keyNode, value := d.lookup(key)
+
+	var insertAfter int // index of the line after which the items are inserted
+	var indent string
+	switch {
+	case keyNode == nil:
+		insertAfter = d.appendLines(key + ":")
+		indent = "  "
+	case isBlockList(value):
+		last := value.Content[len(value.Content)-1]
+		insertAfter = last.Line - 1
+		// The item of a block list starts after "- "
+		indent = strings.Repeat(" ", max(last.Column-3, 0))
+	case isNull(value):
+		insertAfter = keyNode.Line - 1
+		indent = strings.Repeat(" ", keyNode.Column-1) + "  "
+	default:
+		return fmt.Errorf("cannot edit %q in the config file, please use a block list (\"- item\" lines)", key)
+	}
+
+	var insert []string
+	if comment != "" {
+		insert = append(insert, indent+"# "+comment)
+	}
+	for _, item := range items {
+		insert = append(insert, fmt.Sprintf("%s- %q", indent, item))
+	}
+	d.insertLines(insertAfter+1, insert...)
+	return d.parse()
+}
+
+// removeFromList removes all occurrences of item from a top level list and reports whether it was found
+func (d *configDocument) removeFromList(key, item string) (bool, error) {
+	_, value := d.lookup(key)
+	if value == nil || value.Kind != yaml.SequenceNode {
+		return false, nil
+	}
+	if value.Style&yaml.FlowStyle != 0 {
+		return false, fmt.Errorf("cannot edit %q in the config file, please use a block list (\"- item\" lines)", key)
+	}
+
+	// Remove the lines from the bottom up, so the line numbers of the remaining items stay valid
+	found := false
+	for i := len(value.Content) - 1; i >= 0; i-- {
+		if value.Content[i].Value == item {
+			line := value.Content[i].Line - 1
+			d.lines = append(d.lines[:line], d.lines[line+1:]...)
+			found = true
+		}
+	}
+	return found, d.parse()
+}
+
+// setValue replaces the value of a top level key, or adds the key to the end of the file.
+// Lists are written as block lists, other values on the line of the key.
+func (d *configDocument) setValue(key string, value any) error {
+	var lines []string
+	if items, ok := value.([]string); ok {
+		lines = append(lines, key+":")
+		for _, item := range items {
+			lines = append(lines, fmt.Sprintf("  - %q", item))
+		}
+		if len(items) == 0 {
+			lines[0] = key + ": []"
+		}
+	} else {
+		lines = append(lines, key+": "+formatScalar(value))
+	}
+
+	keyNode, valueNode := d.lookup(key)
+	if keyNode == nil {
+		d.appendLines(lines...)
+		return d.parse()
+	}
+
+	// Replace the lines from the key to the last line of its value
+	first, last := keyNode.Line-1, keyNode.Line-1
+	if isBlockList(valueNode) {
+		last = valueNode.Content[len(valueNode.Content)-1].Line - 1
+	} else if valueNode.Line != keyNode.Line {
+		return fmt.Errorf("cannot edit %q in the config file, its value spans multiple lines", key)
+	}
+	// Keep the comment at the end of a single line value
+	comment := keyNode.LineComment
+	if comment == "" {
+		comment = valueNode.LineComment
+	}
+	if comment != "" && len(lines) == 1 {
+		lines[0] += " " + comment
+	}
+	d.lines = append(d.lines[:first], append(lines, d.lines[last+1:]...)...)
+	return d.parse()
+}
+
+// formatScalar formats a scalar config value as YAML
+func formatScalar(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// appendLines adds lines to the end of the file, separated from the existing content by an empty line.
+// It returns the index of the last added line.
+func (d *configDocument) appendLines(lines ...string) int {
+	for len(d.lines) > 0 && strings.TrimSpace(d.lines[len(d.lines)-1]) == "" {
+		d.lines = d.lines[:len(d.lines)-1]
+	}
+	if len(d.lines) > 0 {
+		d.lines = append(d.lines, "")
+	}
+	d.lines = append(d.lines, lines...)
+	last := len(d.lines) - 1
+	d.lines = append(d.lines, "")
+	return last
+}
+
+// insertLines inserts lines before the line with index i
+func (d *configDocument) insertLines(i int, lines ...string) {
+	d.lines = append(d.lines[:i], append(lines, d.lines[i:]...)...)
+}
@@ -0,0 +1,51 @@
+// Description: This file contains the environment profiles of the config, such as "ci" and "local".
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+// ciProfile is the profile applied on CI services, which set the CI environment variable
+const ciProfile = "ci"
+
+// selectedProfile returns the name of the profile given with --env, or ciProfile if the CI
+// environment variable is set to a true value. explicit reports whether the profile was given with --env.
+func selectedProfile() (name string, explicit bool) {
+	if name, _ := rootCmd.PersistentFlags().GetString("env"); name != "" {
+		return name, true
+	}
+	if ci, err := strconv.ParseBool(os.Getenv("CI")); err == nil && ci {
+		return ciProfile, false
+	}
+	return "", false
+}
+
+// profileSettings returns the settings of the profile name from the "profiles" key of the config
+// files, or nil if the profile is not defined.
+func profileSettings(name string) *viper.Viper {
+	return viper.Sub("profiles." + name)
+}
+
+// applyProfile merges the settings of the selected profile on top of the config files. Its values
+// replace those of the config files and are overridden by the environment and flags as usual.
+// A profile selected with --env that is not defined is reported with a warning.
+func applyProfile() {
+	name, explicit := selectedProfile()
+	if name == "" {
+		return
+	}
+	profile := profileSettings(name)
+	if profile == nil {
+		if explicit {
+			configEvent(slog.LevelWarn, fmt.Sprintf("profile %q is not defined in the 'profiles' config key", name), "profile", name)
+		}
+		return
+	}
+	viper.MergeConfigMap(profile.AllSettings())
+	configEvent(slog.LevelInfo, "Using config profile: "+name, "profile", name)
+}
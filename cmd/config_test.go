@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// executeConfigCmd runs a config subcommand with fresh flags and returns its output
+func (env *testEnv) executeConfigCmd(args ...string) (string, error) {
+	configShowCmd.ResetFlags()
+	addConfigShowFlags(configShowCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	env.t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs(append([]string{"config"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// TestConfigShow tests that each value is annotated with the layer it came from
+func TestConfigShow(t *testing.T) {
+	env := newTestEnv(t)
+
+	globalDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "crev")
+	require.NoError(t, os.MkdirAll(globalDir, 0755))
+	globalConfig := filepath.Join(globalDir, "config.yaml")
+	require.NoError(t, os.WriteFile(globalConfig, []byte("compress: gzip\nsafe-mode: true\n"), 0644))
+
+	env.setupConfig(`
+include:
+  - "src/**"
+safe-mode: false
+`)
+	t.Setenv("CREV_COMPRESS_LEVEL", "3")
+	t.Setenv("CREV_VERBOSE", "true")
+	// Variables without the prefix are set by CI services and are not settings
+	t.Setenv("CI", "true")
+	t.Setenv("OUTPUT", "elsewhere.txt")
+
+	out, err := env.executeConfigCmd("show", "--exclude", "dist/**")
+	require.NoError(t, err)
+
+	projectConfig := filepath.Join(env.TempDir, ".crev-config.yaml")
+	require.Contains(t, out, `compress: "gzip"  # global (`+globalConfig+")\n")
+	require.Contains(t, out, `include: ["src/**"]  # project (`+projectConfig+")\n")
+	require.Contains(t, out, `safe-mode: false  # project (`+projectConfig+")\n")
+	require.Contains(t, out, `verbose: "true"  # env (CREV_VERBOSE)`+"\n")
+	require.Contains(t, out, `compress-level: "3"  # env (CREV_COMPRESS_LEVEL)`+"\n")
+	require.Contains(t, out, `ci: false  # default`+"\n")
+	require.Contains(t, out, `output: ""  # default`+"\n")
+	require.Contains(t, out, `exclude: ["dist/**"]  # flag (--exclude)`+"\n")
+	require.Contains(t, out, `log-format: "text"  # default`+"\n")
+	require.NotContains(t, out, "help:")
+	require.NotContains(t, out, "open:")
+	require.NotContains(t, out, "gist:")
+}
+
+// TestGlobalConfig tests that the global config file applies to bundles and is overridden by the project config
+func TestGlobalConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":    "package main",
+		"docs/readme.md": "# Readme",
+	})
+
+	globalDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "crev")
+	require.NoError(t, os.MkdirAll(globalDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "config.yaml"), []byte("exclude:\n  - \"docs/**\"\n"), 0644))
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"src/main.go"}, []string{"docs/readme.md"})
+
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte("exclude:\n  - \"src/**\"\n"), 0644))
+	err = env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"docs/readme.md"}, []string{"src/main.go"})
+}
+
+// TestConfigMessages tests that the config files in use are logged, and not with --quiet
+func TestConfigMessages(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":           "package main",
+		"base.yaml":         "exclude:\n  - \"docs/**\"\n",
+		".crev-config.yaml": "extends: base.yaml\n",
+	})
+	globalDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "crev")
+	require.NoError(t, os.MkdirAll(globalDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "config.yaml"), []byte("exclude: []\n"), 0644))
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertLogContains("Using global config file: ", "Using config file: ", "Using extended config: ")
+
+	env.LogBuffer.Reset()
+	err = env.executeBundleCmd(".", "--quiet")
+	require.NoError(t, err)
+	require.NotContains(t, env.LogBuffer.String(), "Using")
+}
+
+// TestConfigMessagesJSON tests that every line logged with --log-format json, including the
+// messages of loading the config, is a JSON event
+func TestConfigMessagesJSON(t *testing.T) {
+	t.Setenv("CI", "true")
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":           "package main",
+		"base.yaml":         "exclude:\n  - \"docs/**\"\n",
+		".crev-config.yaml": "extends: base.yaml\nprofiles:\n  ci:\n    tree-label: ci\n",
+	})
+	globalDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "crev")
+	require.NoError(t, os.MkdirAll(globalDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "config.yaml"), []byte("extends: missing.yaml\n"), 0644))
+
+	err := env.executeBundleCmd(".", "--log-format", "json")
+	require.NoError(t, err)
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSuffix(env.LogBuffer.String(), "\n"), "\n") {
+		// Lines logged by the test helpers
+		if strings.Contains(line, "Test executing") {
+			continue
+		}
+		var event struct {
+			Msg   string `json:"msg"`
+			Phase string `json:"phase"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &event), "Log line should be JSON: %s", line)
+		if event.Phase == "config" {
+			messages = append(messages, event.Msg)
+		}
+	}
+	// The config files are reported first, followed by the config of the bundle run
+	require.Greater(t, len(messages), 5)
+	require.Contains(t, messages[0], "unable to load the config extended by ")
+	require.Contains(t, messages[1], "Using global config file: ")
+	require.Contains(t, messages[2], "Using config file: ")
+	require.Contains(t, messages[3], "Using extended config: ")
+	require.Equal(t, "Using config profile: ci", messages[4])
+}
+
+// TestConfigFormats tests that TOML and JSON config files are detected by their extension
+func TestConfigFormats(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":           "package main",
+		"src/gen/types.go":      "package gen",
+		"src/.crev-config.toml": "exclude = [\"gen/**\"]\n",
+		"docs/readme.md":        "# Readme",
+		".crev-config.toml":     "exclude = [\"docs/**\"]\ntree-label = \"toml-project\"\n",
+	})
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"src/main.go", "toml-project"}, []string{"docs/readme.md", "src/gen/types.go"})
+
+	_, err = env.executeConfigSetCmd("compress", "zstd")
+	env.assertErrorContains(err, "only YAML config files can be edited, edit .crev-config.toml directly")
+
+	env = newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"app.go":     "package app",
+		"notes.md":   "# Notes",
+		".crev.json": `{"exclude": ["**/*.md"], "tree-label": "json-project"}`,
+	})
+
+	err = env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"app.go", "json-project"}, []string{"notes.md"})
+}
+
+// executeConfigSetCmd runs the config set command with fresh flags and returns its output
+func (env *testEnv) executeConfigSetCmd(args ...string) (string, error) {
+	configSetCmd.ResetFlags()
+	addConfigSetFlags(configSetCmd)
+	return env.executeConfigCmd(append([]string{"set"}, args...)...)
+}
+
+// TestConfigSet tests editing list and scalar keys while preserving the rest of the config file
+func TestConfigSet(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte(`# Project config
+include:
+  - "**/*"
+
+exclude:
+  # Docs
+  - "**/*.md"
+  - "vendor/**"
+
+compress: gzip # smaller bundles
+`), 0644))
+
+	_, err := env.executeConfigSetCmd("exclude", "+dist/**", "-**/*.md", "+vendor/**")
+	require.NoError(t, err)
+	_, err = env.executeConfigSetCmd("compress", "zstd")
+	require.NoError(t, err)
+	_, err = env.executeConfigSetCmd("safe-mode", "true")
+	require.NoError(t, err)
+	out, err := env.executeConfigSetCmd("include", "src/**,lib/**")
+	require.NoError(t, err)
+	require.Contains(t, out, "Updated include in .crev-config.yaml")
+
+	content, err := os.ReadFile(".crev-config.yaml")
+	require.NoError(t, err)
+	require.Equal(t, `# Project config
+include:
+  - "src/**"
+  - "lib/**"
+
+exclude:
+  # Docs
+  - "vendor/**"
+  - "dist/**"
+
+compress: "zstd" # smaller bundles
+
+safe-mode: true
+`, string(content))
+}
+
+// TestConfigSetInvalidValue tests that values are validated against the type of the key
+func TestConfigSetInvalidValue(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.executeConfigSetCmd("compress-level", "high")
+	env.assertErrorContains(err, `invalid value "high" for compress-level, expected a number`)
+	require.NoFileExists(t, ".crev-config.yaml")
+
+	// Typos and the flags that are not read from the config are rejected
+	for _, key := range []string{"exlude", "open", "gist"} {
+		_, err = env.executeConfigSetCmd(key, "true")
+		env.assertErrorContains(err, `unknown config key "`+key+`"`)
+	}
+	require.NoFileExists(t, ".crev-config.yaml")
+
+	_, err = env.executeConfigSetCmd("editor", "vim")
+	require.NoError(t, err)
+}
+
+// TestConfigGet tests printing the effective value of a key
+func TestConfigGet(t *testing.T) {
+	env := newTestEnv(t)
+	env.setupConfig(`
+exclude:
+  - "dist/**"
+  - "**/*.md"
+compress: gzip
+`)
+
+	out, err := env.executeConfigCmd("get", "exclude")
+	require.NoError(t, err)
+	require.Equal(t, "dist/**\n**/*.md\n", out)
+
+	out, err = env.executeConfigCmd("get", "compress")
+	require.NoError(t, err)
+	require.Equal(t, "gzip\n", out)
+
+	_, err = env.executeConfigCmd("get", "colour")
+	env.assertErrorContains(err, `unknown config key "colour"`)
+}
+
+// TestConfigProfiles tests layering the profile selected with --env or by the CI variable on top of the config
+func TestConfigProfiles(t *testing.T) {
+	t.Cleanup(func() { rootCmd.PersistentFlags().Set("env", "") })
+	t.Setenv("CI", "")
+	config := `
+tree-label: base
+profiles:
+  ci:
+    tree-label: ci-label
+    exclude:
+      - "**/*.log"
+  local:
+    tree-label: local-label
+`
+	files := map[string]string{"main.go": "package main", "debug.log": "log"}
+
+	env := newTestEnv(t)
+	env.createProjectStructure(files)
+	env.setupConfig(config)
+	require.NoError(t, env.executeBundleCmd("."))
+	env.assertFileContents("crev-project.txt", []string{"base", "debug.log"}, []string{"local-label", "ci-label"})
+
+	env = newTestEnv(t)
+	env.createProjectStructure(files)
+	env.setupConfig(config)
+	require.NoError(t, env.executeBundleCmd(".", "--env", "local"))
+	env.assertFileContents("crev-project.txt", []string{"local-label", "debug.log"}, []string{"ci-label"})
+
+	out, err := env.executeConfigCmd("show")
+	require.NoError(t, err)
+	require.Contains(t, out, `tree-label: "local-label"  # profile (local)`+"\n")
+
+	rootCmd.PersistentFlags().Set("env", "")
+	t.Setenv("CI", "true")
+	env = newTestEnv(t)
+	env.createProjectStructure(files)
+	env.setupConfig(config)
+	require.NoError(t, env.executeBundleCmd("."))
+	env.assertFileContents("crev-project.txt", []string{"ci-label", "main.go"}, []string{"debug.log"})
+}
@@ -0,0 +1,65 @@
+// Description: This file contains the test coverage section added to the top of the bundle.
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/coverage"
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+)
+
+// selectedCoverage returns the coverage of the selected files in the report, keyed by their path
+// relative to the root directory.
+func selectedCoverage(report coverage.Report, entries []files.Entry, rootDir string) map[string]coverage.File {
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir {
+			paths = append(paths, filepath.ToSlash(entry.Path))
+		}
+	}
+	return report.Match(rootDir, paths)
+}
+
+// coveragePercent returns the coverage percentage of a file rounded to one decimal.
+func coveragePercent(f coverage.File) float64 {
+	return math.Round(f.Percent()*10) / 10
+}
+
+// coverageSection returns the custom section listing the coverage of the selected files, least
+// covered first, so reviews can focus on poorly tested code.
+func coverageSection(name string, fileCoverage map[string]coverage.File, selected int) formatting.Section {
+	paths := make([]string, 0, len(fileCoverage))
+	var total coverage.File
+	for path, f := range fileCoverage {
+		paths = append(paths, path)
+		total.Covered += f.Covered
+		total.Total += f.Total
+	}
+	slices.SortFunc(paths, func(a, b string) int {
+		if c := cmp.Compare(fileCoverage[a].Percent(), fileCoverage[b].Percent()); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "Total: %.1f%% (%d/%d)\n", coveragePercent(total), total.Covered, total.Total)
+	for _, path := range paths {
+		f := fileCoverage[path]
+		fmt.Fprintf(&content, "%6.1f%%  %s (%d/%d)\n", coveragePercent(f), path, f.Covered, f.Total)
+	}
+	if missing := selected - len(paths); missing > 0 {
+		fmt.Fprintf(&content, "Files without coverage data: %d\n", missing)
+	}
+	return formatting.Section{
+		Kind:    formatting.CustomSection,
+		Title:   "Test coverage (" + filepath.Base(name) + ")",
+		Content: strings.TrimRight(content.String(), "\n"),
+	}
+}
@@ -1,5 +1,67 @@
 package cmd
 
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultExcludes are the built-in ignore lists that are added to the exclude patterns
+type DefaultExcludes struct {
+	Prefixes   []string
+	Extensions []string
+	Files      []string
+}
+
+// builtinDefaultExcludes returns the built-in ignore lists
+func builtinDefaultExcludes() DefaultExcludes {
+	return DefaultExcludes{
+		Prefixes:   slices.Clone(specificPrefixesToIgnore),
+		Extensions: slices.Clone(specificExtensionsToIgnore),
+		Files:      slices.Clone(specificFilesToIgnore),
+	}
+}
+
+// configuredDefaultExcludes returns the built-in ignore lists as changed by the
+// defaults.prefixes, defaults.extensions and defaults.files config keys, see configureList.
+func configuredDefaultExcludes() DefaultExcludes {
+	return DefaultExcludes{
+		Prefixes:   configureList(specificPrefixesToIgnore, viper.GetStringSlice("defaults.prefixes")),
+		Extensions: configureList(specificExtensionsToIgnore, viper.GetStringSlice("defaults.extensions")),
+		Files:      configureList(specificFilesToIgnore, viper.GetStringSlice("defaults.files")),
+	}
+}
+
+// configureList applies the configured entries to a built-in list. Entries starting with "+" are
+// added to the list and entries starting with "-" are removed from it. If there are other entries,
+// they replace the built-in list.
+func configureList(builtin, configured []string) []string {
+	list := slices.Clone(builtin)
+	var replacement []string
+	for _, entry := range configured {
+		if !strings.HasPrefix(entry, "+") && !strings.HasPrefix(entry, "-") {
+			replacement = append(replacement, entry)
+		}
+	}
+	if replacement != nil {
+		list = replacement
+	}
+
+	for _, entry := range configured {
+		switch {
+		case strings.HasPrefix(entry, "+"):
+			if item := strings.TrimPrefix(entry, "+"); !slices.Contains(list, item) {
+				list = append(list, item)
+			}
+		case strings.HasPrefix(entry, "-"):
+			item := strings.TrimPrefix(entry, "-")
+			list = slices.DeleteFunc(list, func(existing string) bool { return existing == item })
+		}
+	}
+	return list
+}
+
 // specificPrefixesToIgnore contains file/directory prefixes that should be ignored by default
 var specificPrefixesToIgnore = []string{
 	// Version control and IDE directories
@@ -35,3 +97,51 @@ var specificFilesToIgnore = []string{
 	"go.mod",      // Go module file
 	"go.sum",      // Go module checksum file
 }
+
+// manifestFiles contains the dependency manifests of specificFilesToIgnore, bundled with --include-manifests
+var manifestFiles = []string{
+	"poetry.lock",
+	"go.mod",
+	"go.sum",
+}
+
+// safeModeExtensions contains the text file extensions (and exact file names) that are bundled in safe mode.
+// Everything else is excluded, unless it is specified via --files.
+var safeModeExtensions = []string{
+	// Source code
+	".go",
+	".py",
+	".js",
+	".jsx",
+	".ts",
+	".tsx",
+	".java",
+	".kt",
+	".rb",
+	".rs",
+	".c",
+	".h",
+	".cpp",
+	".hpp",
+	".cs",
+	".swift",
+	".sh",
+	".sql",
+	".proto",
+
+	// Markup and styles
+	".html",
+	".css",
+	".scss",
+	".md",
+	".txt",
+
+	// Configuration
+	".yaml",
+	".yml",
+	".toml",
+
+	// Well-known files without an extension
+	"Makefile",
+	"Dockerfile",
+}
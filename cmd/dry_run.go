@@ -0,0 +1,45 @@
+// Description: This file contains the --dry-run listing of the files that would be bundled.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/budget"
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/report"
+)
+
+// writeDryRun writes the selected files to w, one per line with their size and token estimate
+// (see budget.Tokens), followed by the totals. The files are not read, so the estimates are
+// based on the size on disk, before redaction and truncation. Files larger than maxFileSize,
+// if not 0, are marked as omitted and not counted.
+func writeDryRun(w io.Writer, entries []files.Entry, maxFileSize int64) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%9s  %7s  %s\n", "SIZE", "TOKENS", "PATH")
+	count, omitted, total := 0, 0, 0
+	for _, entry := range entries {
+		// Directories are selected for the project tree, but only files are listed
+		if entry.IsDir {
+			continue
+		}
+		size := int(entry.Size)
+		if maxFileSize > 0 && entry.Size > maxFileSize {
+			fmt.Fprintf(&sb, "%9s  %7s  %s (omitted, larger than --max-file-size)\n", report.FormatBytes(size), "-", entry.Path)
+			omitted++
+			continue
+		}
+		fmt.Fprintf(&sb, "%9s  %7d  %s\n", report.FormatBytes(size), budget.Tokens(size), entry.Path)
+		count++
+		total += size
+	}
+	fmt.Fprintf(&sb, "%d files, %s, about %d tokens", count, report.FormatBytes(total), budget.Tokens(total))
+	if omitted > 0 {
+		fmt.Fprintf(&sb, " (%d omitted)", omitted)
+	}
+	sb.WriteString("\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
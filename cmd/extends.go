@@ -0,0 +1,245 @@
+// Description: This file contains the loading of the base configs that config files extend with the "extends" key.
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// extendsCacheTTL is how long a fetched remote config is used before it is fetched again
+	extendsCacheTTL = 24 * time.Hour
+	// maxExtendsDepth limits the length of a chain of configs extending each other
+	maxExtendsDepth = 10
+)
+
+// extendsHTTPClient fetches the remote configs
+var extendsHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// applyExtends returns settings merged on top of the base config that ref points to.
+// configFile is the location of the config declaring ref, relative references are resolved against it.
+// Errors are printed as a warning and the settings are returned without a base.
+func applyExtends(settings map[string]any, ref, configFile string) map[string]any {
+	base, err := loadExtends(ref, configFile, nil)
+	if err != nil {
+		configEvent(slog.LevelWarn, fmt.Sprintf("unable to load the config extended by %s: %v", configFile, err),
+			"config", configFile, "error", err.Error())
+		delete(settings, "extends")
+		return settings
+	}
+	return mergeExtendedSettings(base, settings)
+}
+
+// loadExtends returns the settings of the config that ref points to, including the configs it extends.
+// ref is an http(s) URL or a file path, relative to the location of the config declaring it.
+func loadExtends(ref, configFile string, chain []string) (map[string]any, error) {
+	location := resolveExtendsRef(ref, configFile)
+	if slices.Contains(chain, location) {
+		return nil, fmt.Errorf("config %s extends itself", location)
+	}
+	if len(chain) >= maxExtendsDepth {
+		return nil, fmt.Errorf("more than %d configs extend each other", maxExtendsDepth)
+	}
+	chain = append(chain, location)
+
+	content, err := readExtendedConfig(location)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseExtendedConfig(location, content)
+	if err != nil {
+		return nil, err
+	}
+	configEvent(slog.LevelInfo, "Using extended config: "+location, "extends", location)
+
+	settings := v.AllSettings()
+	if parent := v.GetString("extends"); parent != "" {
+		base, err := loadExtends(parent, location, chain)
+		if err != nil {
+			return nil, err
+		}
+		settings = mergeExtendedSettings(base, settings)
+	}
+	return settings, nil
+}
+
+// mergeExtendedSettings returns the settings of a config merged on top of the settings of its base.
+// The exclude patterns of both are kept, so a base can maintain a shared set of excludes,
+// the other settings of the config replace those of the base.
+func mergeExtendedSettings(base, settings map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(settings))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range settings {
+		if key == "exclude" {
+			value = appendUnique(toStringSlice(base[key]), toStringSlice(value)...)
+		}
+		merged[key] = value
+	}
+	delete(merged, "extends")
+	return merged
+}
+
+// toStringSlice converts a list read from a config file to a slice of strings
+func toStringSlice(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		return items
+	default:
+		return nil
+	}
+}
+
+// appendUnique appends the items that are not in list yet
+func appendUnique(list []string, items ...string) []string {
+	list = slices.Clone(list)
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// isRemoteConfig reports whether ref is the URL of a remote config
+func isRemoteConfig(ref string) bool {
+	return strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://")
+}
+
+// resolveExtendsRef resolves ref against the location of the config declaring it
+func resolveExtendsRef(ref, configFile string) string {
+	if isRemoteConfig(ref) || filepath.IsAbs(ref) {
+		return ref
+	}
+	if isRemoteConfig(configFile) {
+		base, err := url.Parse(configFile)
+		if err != nil {
+			return ref
+		}
+		relative, err := url.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return base.ResolveReference(relative).String()
+	}
+	return filepath.Join(filepath.Dir(configFile), ref)
+}
+
+// parseConfig parses an extended config, see configType
+func parseExtendedConfig(location string, content []byte) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType(configType(location))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", location, err)
+	}
+	return v, nil
+}
+
+// configType returns the format of a config file, YAML unless the location has another config extension
+func configType(location string) string {
+	if ext := strings.TrimPrefix(filepath.Ext(location), "."); slices.Contains(viper.SupportedExts, ext) {
+		return ext
+	}
+	return "yaml"
+}
+
+// readExtendedConfig returns the content of the config at location. Remote configs are cached
+// for extendsCacheTTL, and a stale cached copy is used if the config cannot be fetched or in
+// offline mode.
+func readExtendedConfig(location string) ([]byte, error) {
+	if !isRemoteConfig(location) {
+		content, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config: %w", err)
+		}
+		return content, nil
+	}
+
+	cacheFile := extendsCacheFile(location)
+	if offlineMode() {
+		if cacheFile != "" {
+			if content, err := os.ReadFile(cacheFile); err == nil {
+				return content, nil
+			}
+		}
+		return nil, fmt.Errorf("remote config %s cannot be fetched in offline mode", location)
+	}
+	if cacheFile != "" {
+		if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < extendsCacheTTL {
+			if content, err := os.ReadFile(cacheFile); err == nil {
+				return content, nil
+			}
+		}
+	}
+
+	content, err := fetchRemoteConfig(location)
+	if err != nil {
+		if cacheFile == "" {
+			return nil, err
+		}
+		cached, cacheErr := os.ReadFile(cacheFile)
+		if cacheErr != nil {
+			return nil, err
+		}
+		configEvent(slog.LevelWarn, fmt.Sprintf("%v, using the cached copy", err), "extends", location, "cache", cacheFile)
+		return cached, nil
+	}
+	if _, err := parseExtendedConfig(location, content); err != nil {
+		return nil, err
+	}
+
+	if cacheFile != "" {
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err == nil {
+			_ = os.WriteFile(cacheFile, content, 0644)
+		}
+	}
+	return content, nil
+}
+
+// fetchRemoteConfig downloads the config at url
+func fetchRemoteConfig(url string) ([]byte, error) {
+	resp, err := extendsHTTPClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch config %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch config %s: %s", url, resp.Status)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch config %s: %w", url, err)
+	}
+	return content, nil
+}
+
+// extendsCacheFile returns the path of the cached copy of a remote config, or an empty
+// string if the user cache directory is unknown. On Linux this is in $XDG_CACHE_HOME/crev/extends.
+func extendsCacheFile(url string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, "crev", "extends", hex.EncodeToString(sum[:])+filepath.Ext(url))
+}
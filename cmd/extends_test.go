@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// extendsTestFiles is a project with files excluded by a base config and by the project config
+var extendsTestFiles = map[string]string{
+	"main.go":     "package main",
+	"debug.log":   "log",
+	"backup.tmp":  "tmp",
+	"dist/app.js": "bundle",
+}
+
+// TestBundleCommandWithRemoteExtends tests that the excludes of a remote base config are added
+// to those of the project, and that the base config is cached
+func TestBundleCommandWithRemoteExtends(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("exclude:\n  - \"**/*.log\"\n  - \"dist/**\"\n"))
+	}))
+	defer server.Close()
+
+	env := newTestEnv(t)
+	env.createProjectStructure(extendsTestFiles)
+	env.setupConfig(`
+extends: ` + server.URL + `/crev-base.yaml
+include:
+  - "**/*"
+exclude:
+  - "**/*.tmp"
+`)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt", []string{"main.go"}, []string{"debug.log", "backup.tmp", "dist/app.js"})
+
+	// The second run uses the cached copy
+	require.NoError(t, os.Remove("crev-project.txt"))
+	env.setupConfig(`
+extends: ` + server.URL + `/crev-base.yaml
+include:
+  - "**/*"
+`)
+	err = env.executeBundleCmd(".")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt", []string{"main.go", "backup.tmp"}, []string{"debug.log", "dist/app.js"})
+	require.Equal(t, 1, requests, "The remote config should be fetched once")
+}
+
+// TestBundleCommandWithLocalExtends tests extending a config file relative to the project config
+func TestBundleCommandWithLocalExtends(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(extendsTestFiles)
+	base := filepath.Join(t.TempDir(), "shared")
+	require.NoError(t, os.MkdirAll(base, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "base.yaml"), []byte("extends: root.yaml\nexclude:\n  - \"**/*.log\"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "root.yaml"), []byte("exclude:\n  - \"dist/**\"\n"), 0644))
+	env.setupConfig(`
+extends: ` + filepath.Join(base, "base.yaml") + `
+include:
+  - "**/*"
+`)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt", []string{"main.go", "backup.tmp"}, []string{"debug.log", "dist/app.js"})
+}
+
+// TestLoadExtendsCycle tests that configs extending each other are reported
+func TestLoadExtendsCycle(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("extends: b.yaml\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("extends: a.yaml\n"), 0644))
+
+	_, err := loadExtends("a.yaml", filepath.Join(dir, ".crev-config.yaml"), nil)
+	require.ErrorContains(t, err, "extends itself")
+}
+
+// TestOfflineBlocksRemoteExtends tests that remote configs are never fetched in offline mode
+func TestOfflineBlocksRemoteExtends(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("exclude:\n  - \"**/*.log\"\n"))
+	}))
+	defer server.Close()
+	t.Cleanup(func() { rootCmd.PersistentFlags().Set("offline", "false") })
+
+	env := newTestEnv(t)
+	env.createProjectStructure(extendsTestFiles)
+	env.setupConfig(`
+extends: ` + server.URL + `/crev-base.yaml
+`)
+
+	// Without a cached copy the base config is skipped
+	err := env.executeBundleCmd(".", "--offline")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt", []string{"main.go", "debug.log"}, nil)
+	require.Equal(t, 0, requests)
+
+	// Offline mode can also be enabled in the config, and a cached copy is used
+	require.NoError(t, os.Remove("crev-project.txt"))
+	rootCmd.PersistentFlags().Set("offline", "false")
+	require.NoError(t, env.executeBundleCmd("."))
+	require.Equal(t, 1, requests)
+
+	require.NoError(t, os.Remove("crev-project.txt"))
+	env.setupConfig(`
+offline: true
+extends: ` + server.URL + `/crev-base.yaml
+`)
+	err = env.executeBundleCmd(".")
+	require.NoError(t, err, "Bundle command execution failed")
+	env.assertFileContents("crev-project.txt", []string{"main.go"}, []string{"debug.log"})
+	require.Equal(t, 1, requests)
+}
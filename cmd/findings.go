@@ -0,0 +1,92 @@
+// Description: This file implements the "findings" command, which turns the output of a model review into a report of findings.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/devinbarry/crev/internal/findings"
+	"github.com/spf13/cobra"
+)
+
+var findingsCmd = &cobra.Command{
+	Use:   "findings [review]",
+	Short: "Print the findings of a review grouped by file",
+	Long: `Parse the findings of a review, e.g. the answer of a model to a bundle, and print them as a
+report grouped by file and ordered by line. The review is read from the given file, from
+crev-review.md by default, or from stdin if the file is "-".
+
+The findings can be a JSON array (optionally in a fenced json code block) of objects with
+"file", "line", "end_line", "severity" and "message" keys, or lines of the form:
+
+  path/to/file.go:12-15: [warning] message
+
+Line numbers refer to the lines of the file content in the bundle, starting at 1.
+
+Example usage:
+  # Print the findings of crev-review.md
+  crev findings
+
+  # Convert the findings of a model answer to JSON, e.g. for "crev annotate"
+  crev findings answer.md --json > findings.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source := "crev-review.md"
+		if len(args) > 0 {
+			source = args[0]
+		}
+		asJSON, _ := cmd.Flags().GetBool("json")
+		return Findings(cmd.OutOrStdout(), cmd.InOrStdin(), source, asJSON)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(findingsCmd)
+	addFindingsFlags(findingsCmd)
+}
+
+// addFindingsFlags adds the findings flags to cmd.
+func addFindingsFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("json", false, "Print the findings as a JSON array instead of a report")
+}
+
+// readFindings parses the findings in source, a file or "-" for in
+func readFindings(in io.Reader, source string) ([]findings.Finding, error) {
+	var content []byte
+	var err error
+	if source == "-" {
+		content, err = io.ReadAll(in)
+	} else {
+		content, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read review: %w", err)
+	}
+
+	result, err := findings.Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid findings in %s: %w", source, err)
+	}
+	return result, nil
+}
+
+// Findings writes the findings in source to w, as a report or as JSON.
+func Findings(w io.Writer, in io.Reader, source string, asJSON bool) error {
+	result, err := readFindings(in, source)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		findings.Sort(result)
+		if result == nil {
+			result = []findings.Finding{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return findings.WriteReport(w, result)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// executeFindingsCmd executes the findings command with given arguments and stdin and returns its output
+func (env *testEnv) executeFindingsCmd(stdin string, args ...string) (string, error) {
+	findingsCmd.ResetFlags()
+	addFindingsFlags(findingsCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetIn(strings.NewReader(stdin))
+	env.t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetIn(nil)
+	})
+
+	rootCmd.SetArgs(append([]string{"findings"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// TestFindingsCommand tests printing the findings of crev-review.md
+func TestFindingsCommand(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, os.WriteFile("crev-review.md", []byte("main.go:7: [info] Consider a constant\nmain.go:2: Rename x\n"), 0644))
+
+	out, err := env.executeFindingsCmd("")
+	require.NoError(t, err)
+	require.Equal(t, "main.go (2 findings)\n  2        Rename x\n  7  info  Consider a constant\n", out)
+}
+
+// TestFindingsCommandJSON tests converting findings read from stdin to JSON
+func TestFindingsCommandJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	out, err := env.executeFindingsCmd("b.go:3-4: [warning] Duplicate code\n", "-", "--json")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"file": "b.go", "line": 3, "end_line": 4, "severity": "warning", "message": "Duplicate code"}]`, out)
+}
+
+// TestFindingsCommandMissingReview tests the error for a missing review file
+func TestFindingsCommandMissingReview(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.executeFindingsCmd("", "missing.md")
+	env.assertErrorContains(err, "unable to read review")
+}
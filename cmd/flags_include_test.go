@@ -178,3 +178,99 @@ func TestIncludeNoMatches(t *testing.T) {
 	// No files are included in this bundle so an error is raised saying so.
 	env.assertErrorContains(err, "no files found to bundle.")
 }
+
+// TestSafeModeFlag tests that safe mode only includes allowlisted text files
+func TestSafeModeFlag(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"src/main.go":        "package main",
+		"src/Makefile":       "build:",
+		"data/users.csv":     "id,name",
+		"data/dump.sqlite":   "SQLITE",
+		"scripts/deploy.SH":  "#!/bin/sh",
+		"notes/customers.db": "DB",
+	}
+	env.createProjectStructure(files)
+
+	err := env.executeBundleCmd(".", "--safe-mode", "--include", "**/*", "--files", "data/users.csv")
+	require.NoError(t, err)
+
+	expectedFiles := []string{"src/main.go", "src/Makefile", "scripts/deploy.SH", "data/users.csv"}
+	unexpectedFiles := []string{"data/dump.sqlite", "notes/customers.db"}
+	env.assertFileContents("crev-project.txt", expectedFiles, unexpectedFiles)
+}
+
+// TestSafeModeConfigExtensions tests that the safe mode allowlist can be configured
+func TestSafeModeConfigExtensions(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"src/main.go": "package main",
+		"src/app.py":  "print('hi')",
+	}
+	env.createProjectStructure(files)
+
+	env.setupConfig(`
+safe-mode: true
+safe-extensions:
+  - ".py"
+`)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+
+	env.assertFileContents("crev-project.txt", []string{"src/app.py"}, []string{"src/main.go"})
+}
+
+// TestUnmatchedPatternWarnings tests that include and exclude patterns matching nothing are reported
+func TestUnmatchedPatternWarnings(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"src/main.go": "package main",
+	}
+	env.createProjectStructure(files)
+
+	err := env.executeBundleCmd(".", "--include", "scr/**", "--exclude", "vendr/**")
+	env.assertErrorContains(err, "no files found to bundle")
+	env.assertLogContains(
+		`Warning: include pattern "scr/**" did not match any files`,
+		`Warning: exclude pattern "vendr/**" did not match any files`,
+	)
+}
+
+// TestUnmatchedConfigExcludesNotReported tests that generic exclude patterns from the config are not reported
+func TestUnmatchedConfigExcludesNotReported(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"src/main.go": "package main"})
+	env.setupConfig(fullConfig)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	require.NotContains(t, env.LogBuffer.String(), "Warning:")
+}
+
+// TestExplicitDirectory tests that --files accepts a directory and bundles all its files
+func TestExplicitDirectory(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"internal/auth/auth.go":   "package auth",
+		"internal/auth/debug.log": "auth log",
+		"internal/db/db.go":       "package db",
+	})
+
+	err := env.executeBundleCmd(".", "--files", "internal/auth", "--exclude", "**/*.log")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"internal/auth/auth.go", "auth log"}, []string{"internal/db"})
+}
+
+// TestExplicitFilesRelativeToRoot tests that explicit files are found relative to the bundled directory.
+func TestExplicitFilesRelativeToRoot(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"project/cmd/main.go": "package main",
+		"project/util.go":     "package project",
+	})
+
+	err := env.executeBundleCmd("project", "--files", "cmd/main.go")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"cmd/main.go", "package main"}, []string{"util.go"})
+}
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/devinbarry/crev/internal/formatting"
 	"github.com/stretchr/testify/require"
 )
 
@@ -207,3 +212,495 @@ func TestComplexPatternCombination(t *testing.T) {
 	}
 	env.assertFileContents("crev-project.txt", expectedFiles, unexpectedFiles)
 }
+
+// TestNoDefaultExcludes tests disabling the built-in ignore lists
+func TestNoDefaultExcludes(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"src/main.go":    "package main",
+		".github/ci.yml": "on: push",
+		"go.mod":         "module example",
+		"logo.png":       "PNGDATA",
+	}
+	env.createProjectStructure(files)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"src/main.go"}, []string{".github/ci.yml", "go.mod", "logo.png"})
+
+	err = env.executeBundleCmd(".", "--no-default-excludes", "--exclude", "crev-project.txt")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"src/main.go", ".github/ci.yml", "go.mod", "logo.png"}, nil)
+}
+
+// TestConfiguredDefaultExcludes tests extending and trimming the built-in ignore lists via the config
+func TestConfiguredDefaultExcludes(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"src/main.go":   "package main",
+		"go.mod":        "module example",
+		"go.sum":        "checksums",
+		"logo.png":      "PNGDATA",
+		"data.parquet":  "PAR1",
+		"generated.txt": "generated",
+	}
+	env.createProjectStructure(files)
+	env.setupConfig(`
+defaults:
+  extensions:
+    - "+.parquet"
+    - "-.png"
+  files:
+    - "generated.txt"
+`)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt",
+		[]string{"src/main.go", "go.mod", "go.sum", "logo.png"},
+		[]string{"data.parquet", "generated.txt"})
+}
+
+// TestConfigureList tests applying configured entries to a built-in list
+func TestConfigureList(t *testing.T) {
+	builtin := []string{"a", "b"}
+	require.Equal(t, []string{"a", "b"}, configureList(builtin, nil))
+	require.Equal(t, []string{"b", "c"}, configureList(builtin, []string{"+c", "-a", "+b"}))
+	require.Equal(t, []string{"x", "y"}, configureList(builtin, []string{"x", "+y", "-a"}))
+	require.Equal(t, []string{"a", "b"}, builtin)
+}
+
+// TestIncludeManifests tests bundling the dependency manifests ignored by default
+func TestIncludeManifests(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"main.go":   "package main",
+		"go.mod":    "module example",
+		"go.sum":    "checksums",
+		"Thumbs.db": "cache",
+	}
+	env.createProjectStructure(files)
+
+	err := env.executeBundleCmd(".", "--include-manifests")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"main.go", "go.mod", "go.sum"}, []string{"Thumbs.db"})
+}
+
+// TestIncludeContaining tests restricting the bundle to files whose content matches a regex
+func TestIncludeContaining(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"billing/payment.go": "type PaymentService struct{}",
+		"billing/invoice.go": "type Invoice struct{}",
+		"api/handler.go":     "var svc *billing.PaymentService",
+		"docs/readme.md":     "Billing documentation",
+	}
+	env.createProjectStructure(files)
+
+	err := env.executeBundleCmd(".", "--include-containing", `Payment\w+`,
+		"--include", "**/*", "--files", "docs/readme.md")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt",
+		[]string{"billing/payment.go", "api/handler.go", "docs/readme.md"},
+		[]string{"billing/invoice.go"})
+
+	env = newTestEnv(t)
+	env.createProjectStructure(files)
+	err = env.executeBundleCmd(".", "--include-containing", "OrderService")
+	env.assertErrorContains(err, `no files found with content matching "OrderService"`)
+
+	err = env.executeBundleCmd(".", "--include-containing", "(")
+	env.assertErrorContains(err, "invalid --include-containing pattern")
+}
+
+// TestGitTrackedOnly tests restricting the bundle to the files tracked by git
+func TestGitTrackedOnly(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		".gitignore":     "build/\n",
+		"main.go":        "package main",
+		"util/str.go":    "package util",
+		"build/out.go":   "package build",
+		"scratch/try.go": "package scratch",
+	})
+	for _, args := range [][]string{{"init", "-q"}, {"add", ".gitignore", "main.go", "util/str.go"}} {
+		require.NoError(t, exec.Command("git", args...).Run())
+	}
+
+	err := env.executeBundleCmd(".", "--git-tracked-only")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt",
+		[]string{"main.go", "util/str.go"},
+		[]string{"build/out.go", "scratch/try.go", "scratch"})
+}
+
+// TestIgnoreSourceDockerignore tests excluding the files ignored by .dockerignore
+func TestIgnoreSourceDockerignore(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		".dockerignore":     "tests\n*.md\n!README.md\n",
+		"main.go":           "package main",
+		"README.md":         "# Service",
+		"NOTES.md":          "notes",
+		"tests/e2e_test.go": "package tests",
+	})
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"main.go", "NOTES.md", "tests/e2e_test.go"}, nil)
+
+	err = env.executeBundleCmd(".", "--ignore-source", "dockerignore")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt",
+		[]string{"main.go", "README.md"},
+		[]string{"NOTES.md", "tests/e2e_test.go"})
+
+	err = env.executeBundleCmd(".", "--ignore-source", "npmignore")
+	env.assertErrorContains(err, `unsupported ignore source "npmignore" (supported: dockerignore)`)
+}
+
+// TestCrevignore tests that the files ignored by the .crevignore file of the root directory are excluded
+func TestCrevignore(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		".crevignore":         "fixtures/\n*.md\n!README.md\n",
+		"main.go":             "package main",
+		"README.md":           "# Service",
+		"NOTES.md":            "notes",
+		"api/fixtures/a.json": "{}",
+	})
+
+	err := env.executeBundleCmd(".", "--exclude", "main.go")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"README.md"}, []string{"NOTES.md", "api/fixtures/a.json", "File: \nmain.go"})
+
+	// ls and tree select the same files
+	out, err := env.executeLsCmd()
+	require.NoError(t, err)
+	require.NotContains(t, out, "NOTES.md")
+	require.Contains(t, out, "README.md")
+
+	// Explicit files are bundled even if they are ignored
+	err = env.executeBundleCmd(".", "--files", "NOTES.md")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"File: \nNOTES.md"}, nil)
+}
+
+// TestTreeLabel tests starting the project tree of the bundle at a labeled root node
+func TestTreeLabel(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go": "package main",
+	})
+
+	err := env.executeBundleCmd(".", "--tree-label", "my-project")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt",
+		[]string{"Project Directory Structure:\nmy-project (1 file)\n└── src (1 file)\n    └── main.go\n"}, nil)
+}
+
+// TestNoTree tests omitting the project tree section, and that the bundle can still be extracted
+func TestNoTree(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go": "package main",
+	})
+
+	err := env.executeBundleCmd(".", "--no-tree")
+	require.NoError(t, err)
+	content, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	require.Equal(t, "File: \nsrc/main.go\nContent: \npackage main\n\n", string(content))
+
+	bundle, err := formatting.ParseText(string(content))
+	require.NoError(t, err)
+	require.Empty(t, bundle.Tree)
+	require.Equal(t, "src/main.go", bundle.Files[0].Path)
+}
+
+// TestMaxLinesPerFile tests truncating long files with a marker
+func TestMaxLinesPerFile(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"long.txt":  "one\ntwo\nthree\nfour\n",
+		"short.txt": "one\n",
+	})
+
+	err := env.executeBundleCmd(".", "--max-lines-per-file", "2")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{
+		"long.txt\nContent: \none\ntwo\n… truncated (2 more lines)\n\n",
+		"short.txt\nContent: \none\n\n",
+	}, []string{"three"})
+
+	err = newTestEnv(t).executeBundleCmd(".", "--max-lines-per-file", "-1")
+	require.ErrorContains(t, err, "invalid max-lines-per-file -1")
+}
+
+// TestMaxLinesFilter tests leaving files with too many lines out of the bundle and its tree
+func TestMaxLinesFilter(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":           "package main\n",
+		"fixtures/rows.csv": "1\n2\n3\n",
+	})
+
+	err := env.executeBundleCmd(".", "--max-lines-filter", "2")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"main.go"}, []string{"rows.csv", "fixtures"})
+	env.assertLogContains("Files too long:   1")
+
+	err = env.executeBundleCmd(".", "--max-lines-filter", "-1")
+	require.ErrorContains(t, err, "invalid max-lines-filter -1")
+}
+
+// TestHeadLines tests previewing the first lines of every file, with the smaller line limit applying
+func TestHeadLines(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"long.txt":  "one\ntwo\nthree\nfour\n",
+		"short.txt": "one\n",
+	})
+
+	err := env.executeBundleCmd(".", "--head-lines", "1")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{
+		"long.txt\nContent: \none\n… truncated (3 more lines)\n\n",
+		"short.txt\nContent: \none\n\n",
+	}, []string{"two"})
+
+	err = env.executeBundleCmd(".", "--head-lines", "3", "--max-lines-per-file", "2")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"long.txt\nContent: \none\ntwo\n… truncated (2 more lines)\n\n"}, nil)
+
+	err = env.executeBundleCmd(".", "--head-lines", "-1")
+	require.ErrorContains(t, err, "invalid head-lines -1")
+}
+
+// TestMaxFiles tests stopping with a hint when more files than the limit are selected
+func TestMaxFiles(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"a.go": "package a",
+		"b.go": "package b",
+	})
+
+	err := env.executeBundleCmd(".", "--max-files", "1")
+	env.assertErrorContains(err, "more than 1 files selected, narrow the selection")
+
+	env = newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"a.go": "package a",
+		"b.go": "package b",
+	})
+	err = env.executeBundleCmd(".", "--max-files", "0")
+	require.NoError(t, err)
+}
+
+// TestOutputExcluded tests that earlier bundles are never bundled and that --gitignore lists the bundle once
+func TestOutputExcluded(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":                 "package main",
+		"crev-project.txt.gz":     "OLD COMPRESSED BUNDLE",
+		"crev-project.index.json": "OLD INDEX",
+		".gitignore":              "/crev-project.txt.gz\nbin/",
+	})
+
+	err := env.executeBundleCmd(".", "--no-default-excludes", "--gitignore")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"main.go", ".gitignore"}, []string{"OLD COMPRESSED BUNDLE", "OLD INDEX"})
+
+	err = env.executeBundleCmd(".", "--no-default-excludes", "--gitignore")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"main.go"}, []string{"File: \ncrev-project.txt\n"})
+	gitignore, err := os.ReadFile(filepath.Join(env.TempDir, ".gitignore"))
+	require.NoError(t, err)
+	require.Equal(t, "/crev-project.txt.gz\nbin/\ncrev-project.txt\n", string(gitignore))
+
+	err = newTestEnv(t).executeBundleCmd(".", "--stdout", "--gitignore")
+	require.ErrorContains(t, err, "--gitignore cannot be used with --stdout")
+}
+
+// TestOutputFlag tests writing the bundle to a chosen file or directory, which is never bundled itself
+func TestOutputFlag(t *testing.T) {
+	env := newTestEnv(t)
+	t.Cleanup(func() { rootCmd.PersistentFlags().Set("offline", "false") })
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "-o", "reviews/api.txt", "--no-default-excludes")
+	require.NoError(t, err)
+	require.NoFileExists(t, "crev-project.txt")
+	env.assertFileContents("reviews/api.txt", []string{"File: \nmain.go\n"}, nil)
+
+	err = env.executeBundleCmd(".", "-o", "reviews/api.txt", "--no-default-excludes", "--split-tokens", "1000")
+	require.NoError(t, err)
+	env.assertFileContents("reviews/api.part1.txt", []string{"File: \nmain.go\n"}, []string{"api.txt"})
+
+	require.NoError(t, os.RemoveAll("reviews"))
+	for range 2 {
+		err = env.executeBundleCmd(".", "-o", "out/", "--no-default-excludes", "--split-tokens", "0")
+		require.NoError(t, err)
+	}
+	env.assertFileContents("out/crev-project.txt", []string{"File: \nmain.go\n"}, []string{"out/crev-project.txt"})
+
+	err = env.executeBundleCmd(".", "-o", "|cat", "--index")
+	env.assertErrorContains(err, "--output |cat is not a file and cannot be used with --index")
+
+	err = env.executeBundleCmd(".", "-o", "tcp://127.0.0.1:9", "--offline")
+	env.assertErrorContains(err, "--output tcp://127.0.0.1:9 cannot be used in offline mode")
+}
+
+// TestOutputFromConfig tests that commands and sockets are only accepted as output from the flag
+func TestOutputFromConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":           "package main",
+		".crev-config.yaml": "output: '|touch pwned'\n",
+	})
+
+	err := env.executeBundleCmd(".")
+	env.assertErrorContains(err, `output "|touch pwned" from the config is not a file`)
+	require.NoFileExists(t, "pwned")
+
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte("output: reviews/api.txt\n"), 0644))
+	err = env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertFileContents("reviews/api.txt", []string{"File: \nmain.go\n"}, nil)
+}
+
+// TestCaseCollisions tests the policies for selected paths that only differ by case
+func TestCaseCollisions(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/util.go": "package src // lower",
+		"src/Util.go": "package src // upper",
+		"main.go":     "package main",
+	})
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	env.assertLogContains("paths src/Util.go, src/util.go only differ by case")
+
+	err = env.executeBundleCmd(".", "--case-collisions", "dedupe")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"package src // upper", "package main"}, []string{"package src // lower"})
+
+	err = env.executeBundleCmd(".", "--case-collisions", "error")
+	env.assertErrorContains(err, "paths only differ by case, which breaks unbundling on case-insensitive file systems: src/Util.go, src/util.go")
+
+	err = env.executeBundleCmd(".", "--case-collisions", "rename")
+	env.assertErrorContains(err, `unsupported case-collisions policy "rename" (supported: warn, error, dedupe)`)
+}
+
+// TestMaxFileSize tests omitting the content of large files, which are listed in the tree with their size
+func TestMaxFileSize(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":           "package main",
+		"data/fixture.json": strings.Repeat("x", 2048),
+	})
+
+	err := env.executeBundleCmd(".", "--max-file-size", "1kb")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt",
+		[]string{"├── data (1 file)\n│   └── fixture.json (2.0 KB, omitted)\n", "File: \nmain.go\n"},
+		[]string{"File: \ndata/fixture.json", "xxxx"})
+
+	err = env.executeBundleCmd(".", "--max-file-size", "1 tb")
+	env.assertErrorContains(err, `invalid max-file-size: unsupported size unit "tb" in "1 tb"`)
+}
+
+// TestMaxTokens tests trimming the least important files until the bundle fits in the token budget
+func TestMaxTokens(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":             "package main\n\nfunc main() {}\n",
+		"testdata/users.json": strings.Repeat("{\"name\": \"user\"}\n", 200),
+		"notes.md":            strings.Repeat("note\n", 100),
+	})
+
+	err := env.executeBundleCmd(".", "--max-tokens", "300", "--files", "notes.md", "--include", "**/*")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt",
+		[]string{"users.json (omitted to fit --max-tokens)", "File: \nmain.go\n", "File: \nnotes.md\n"},
+		[]string{"File: \ntestdata/users.json"})
+	env.assertLogContains("Dropped 1 files to fit in 300 tokens: testdata/users.json")
+	content, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(content), 900)
+
+	err = env.executeBundleCmd(".", "--max-tokens", "500", "--trim", "truncate")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"File: \ntestdata/users.json", "… truncated ("}, nil)
+	content, err = os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(content), 1500)
+
+	err = env.executeBundleCmd(".", "--max-tokens", "10")
+	env.assertErrorContains(err, "the bundle does not fit in 10 tokens")
+
+	err = env.executeBundleCmd(".", "--trim", "shrink")
+	env.assertErrorContains(err, `unsupported trim strategy "shrink" (supported: drop, truncate)`)
+}
+
+// TestModel tests that --model sets the token budget and format of the model, unless given explicitly
+func TestModel(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":             "package main\n\nfunc main() {}\n",
+		"testdata/users.json": strings.Repeat("{\"name\": \"user\"}\n", 20000),
+	})
+
+	err := env.executeBundleCmd(".", "--model", "gpt-5")
+	env.assertErrorContains(err, `unsupported model "gpt-5" (supported: claude-sonnet, gemini-1.5-pro, gpt-4o)`)
+
+	// The 340 KB of users.json fit in the budget of claude-sonnet, but not in that of gpt-4o
+	err = env.executeBundleCmd(".", "--model", "claude-sonnet")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.xml", []string{`path="main.go"`, `path="testdata/users.json"`}, nil)
+
+	err = env.executeBundleCmd(".", "--model", "gpt-4o", "--format", "json")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.json", []string{`"path": "main.go"`}, []string{`"path": "testdata/users.json"`})
+	env.assertLogContains("Dropped 1 files to fit in 100000 tokens: testdata/users.json")
+}
+
+// TestSplitTokens tests writing the bundle in parts that each fit in the token budget
+func TestSplitTokens(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"a.go": "package main\n" + strings.Repeat("// a\n", 100),
+		"b.go": "package main\n" + strings.Repeat("// b\n", 100),
+		"c.go": "package main\n" + strings.Repeat("// c\n", 100),
+	})
+
+	err := env.executeBundleCmd(".", "--split-tokens", "250")
+	require.NoError(t, err)
+	require.NoFileExists(t, "crev-project.txt")
+	for part, file := range map[string]string{"crev-project.part1.txt": "a.go", "crev-project.part2.txt": "b.go", "crev-project.part3.txt": "c.go"} {
+		content, err := os.ReadFile(part)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(content), 750)
+		require.Contains(t, string(content), "Project Directory Structure:\n├── a.go\n├── b.go\n└── c.go\n")
+		require.Contains(t, string(content), "File: \n"+file+"\n")
+		require.Equal(t, 1, strings.Count(string(content), "File: \n"), part)
+	}
+	env.assertLogContains("Split the bundle into 3 parts of at most 250 tokens")
+
+	// Earlier parts are not bundled again, and parts left over by a larger bundle are removed
+	err = env.executeBundleCmd(".", "--split-tokens", "500")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.part1.txt", []string{"File: \na.go\n", "File: \nb.go\n"}, []string{"part"})
+	env.assertFileContents("crev-project.part2.txt", []string{"File: \nc.go\n"}, nil)
+	require.NoFileExists(t, "crev-project.part3.txt")
+
+	err = env.executeBundleCmd(".", "--split-tokens", "100")
+	env.assertErrorContains(err, "file a.go does not fit in a part of 100 tokens")
+
+	err = env.executeBundleCmd(".", "--split-tokens", "500", "--stdout")
+	env.assertErrorContains(err, "--split-tokens cannot be used with --stdout")
+}
@@ -0,0 +1,69 @@
+// Description: This file contains the publishing of bundles as GitHub gists with --gist.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/devinbarry/crev/internal/gist"
+)
+
+// gistURL is the endpoint creating gists, replaced by tests
+var gistURL = gist.DefaultURL
+
+// githubToken returns the GitHub token from the GITHUB_TOKEN or GH_TOKEN environment variables,
+// or from the GitHub CLI if it is logged in.
+func githubToken() (string, error) {
+	for _, key := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(key); token != "" {
+			return token, nil
+		}
+	}
+	if out, err := exec.Command("gh", "auth", "token").Output(); err == nil {
+		if token := strings.TrimSpace(string(out)); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("--gist needs a GitHub token with the gist scope in GITHUB_TOKEN or GH_TOKEN, or a logged in gh CLI")
+}
+
+// validateGist checks that the bundle of opts can be published as a gist and returns the GitHub token.
+func validateGist(opts BundleOptions) (string, error) {
+	if offlineMode() {
+		return "", fmt.Errorf("--gist cannot be used in offline mode")
+	}
+	if opts.Compress != "" || len(opts.EncryptTo) > 0 {
+		return "", fmt.Errorf("--gist cannot be used with --compress or --encrypt-to, gists only contain text")
+	}
+	return githubToken()
+}
+
+// publishGist uploads the bundle as a gist named after the output file and returns its URL.
+func publishGist(bundle *formatting.Bundle, outputTarget, token string, opts BundleOptions) (string, error) {
+	formatExt, err := formatExtension(opts.Format)
+	if err != nil {
+		return "", err
+	}
+	name := bundleBaseName + formatExt
+	if outputTarget != files.StdoutSink {
+		name = filepath.Base(outputTarget)
+	}
+	description := "crev bundle"
+	if absRootDir, err := filepath.Abs(opts.RootDir); err == nil {
+		description = "crev bundle of " + filepath.Base(absRootDir)
+	}
+	var content strings.Builder
+	if err := bundleFormatter(opts.Format).Write(&content, bundle); err != nil {
+		return "", err
+	}
+	return gist.Create(gistURL, token, gist.Gist{
+		Description: description,
+		Public:      opts.GistPublic,
+		Files:       map[string]string{name: content.String()},
+	})
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/gist"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGist tests uploading the bundle as a secret gist
+func TestGist(t *testing.T) {
+	var request struct {
+		Description string `json:"description"`
+		Public      bool   `json:"public"`
+		Files       map[string]struct {
+			Content string `json:"content"`
+		} `json:"files"`
+	}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"html_url": "https://gist.github.com/abc123"}`))
+	}))
+	defer server.Close()
+	gistURL = server.URL
+	t.Cleanup(func() { gistURL = gist.DefaultURL })
+	t.Setenv("GITHUB_TOKEN", "secret-token")
+
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--gist")
+	require.NoError(t, err)
+	require.Equal(t, "Bearer secret-token", authorization)
+	require.False(t, request.Public)
+	require.Contains(t, request.Description, "crev bundle of ")
+	require.Contains(t, request.Files["crev-project.txt"].Content, "File: \nmain.go\nContent: \npackage main\n\n")
+}
+
+// TestGistErrors tests the errors of the gist API and the options that cannot be combined with --gist
+func TestGistErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	gistURL = server.URL
+	t.Cleanup(func() { gistURL = gist.DefaultURL })
+	t.Setenv("GITHUB_TOKEN", "expired-token")
+
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+	err := env.executeBundleCmd(".", "--gist-public")
+	env.assertErrorContains(err, "unable to create gist: invalid GitHub token")
+
+	err = newTestEnv(t).executeBundleCmd(".", "--gist", "--compress", "gzip")
+	env.assertErrorContains(err, "--gist cannot be used with --compress or --encrypt-to")
+}
+
+// TestGistFromConfig tests that a config file cannot upload the bundle
+func TestGistFromConfig(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	gistURL = server.URL
+	t.Cleanup(func() { gistURL = gist.DefaultURL })
+	t.Setenv("GITHUB_TOKEN", "secret-token")
+
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":           "package main",
+		".crev-config.yaml": "gist: true\ngist-public: true\n",
+	})
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	require.Zero(t, requests)
+	require.FileExists(t, "crev-project.txt")
+}
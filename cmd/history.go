@@ -0,0 +1,56 @@
+// Description: This file contains the recent history sections added to the top of the bundle.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+)
+
+// changelogFiles are the names of the changelog of a project, in order of precedence.
+var changelogFiles = []string{"CHANGELOG.md", "CHANGELOG", "CHANGELOG.txt", "CHANGES.md", "HISTORY.md"}
+
+// historySections returns the custom sections with the recent history of the root directory:
+// the messages of the last opts.History commits and the first opts.ChangelogLines lines of its
+// changelog, if enabled. Without a changelog there is no changelog section.
+func historySections(opts BundleOptions) ([]formatting.Section, error) {
+	var sections []formatting.Section
+	if opts.History > 0 {
+		log, err := files.GitLog(opts.RootDir, opts.History)
+		if err != nil {
+			return nil, err
+		}
+		if log != "" {
+			sections = append(sections, formatting.Section{
+				Kind:    formatting.CustomSection,
+				Title:   fmt.Sprintf("Recent commits (last %d)", opts.History),
+				Content: log,
+			})
+		}
+	}
+
+	if opts.ChangelogLines > 0 {
+		for _, name := range changelogFiles {
+			content, err := os.ReadFile(filepath.Join(opts.RootDir, name))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", name, err)
+			}
+			head := map[string]string{name: string(content)}
+			files.TruncateLines(head, opts.ChangelogLines)
+			sections = append(sections, formatting.Section{
+				Kind:    formatting.CustomSection,
+				Title:   "Changelog (" + name + ")",
+				Content: strings.TrimRight(head[name], "\n"),
+			})
+			break
+		}
+	}
+	return sections, nil
+}
@@ -4,6 +4,8 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -56,7 +58,17 @@ exclude:
   # File types to exclude
   - "**/*.md"
   - "**/*.test.go"
-`)
+` + redactConfigExample)
+
+// redactConfigExample is the commented example of redaction rules appended to generated config files
+const redactConfigExample = `
+# Specify regex replacement rules applied to the content of all files
+# redact:
+#   - pattern: "[a-z0-9-]+\\.internal\\.example\\.com"
+#     replacement: "internal-host"
+#   - pattern: "JIRA-[0-9]+"
+#     replacement: "TICKET"
+`
 
 var initCmd = &cobra.Command{
 	Use:   "init",
@@ -67,28 +79,113 @@ The configuration file includes:
 - Include and exclude patterns for files and directories when generating the project overview.
 
 You can modify this file as needed to suit your project's structure.
+
+With --interactive the project type (Go, Node, Python or a mix of them) is detected and a few
+questions are asked to generate a config tailored to the project.
+
+With --template a curated config for a stack is written instead. Available templates:
+django, go, node, python, react, rust.
+
+If a config file already exists, --merge adds the recommended exclude patterns it is missing,
+preserving your edits and comments, and --force overwrites it. Without --template or --interactive
+the patterns of the template the config was generated from are merged, and only language-agnostic
+patterns for a config written by hand. Only YAML configs can be merged, --force replaces a TOML
+or JSON config with .crev-config.yaml.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		configFileName := ".crev-config.yaml"
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// An existing config of any name is detected, as a new one could shadow it or be shadowed
+		configFileName := projectConfigFile(".")
+		if configFileName == "" {
+			configFileName = projectConfigFiles[0]
+		}
+
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		template, _ := cmd.Flags().GetString("template")
+		merge, _ := cmd.Flags().GetBool("merge")
+		force, _ := cmd.Flags().GetBool("force")
+		if interactive && template != "" {
+			return fmt.Errorf("--interactive and --template cannot be used together")
+		}
+		if merge && force {
+			return fmt.Errorf("--merge and --force cannot be used together")
+		}
 
 		// Check if the config file already exists
-		if _, err := os.Stat(configFileName); err == nil {
-			fmt.Println("Config file already exists at", configFileName)
-			os.Exit(1)
+		existing, readErr := os.ReadFile(configFileName)
+		exists := readErr == nil
+		if exists && !merge && !force {
+			return fmt.Errorf("config file already exists at %s (use --merge to add new recommended patterns or --force to overwrite it)", configFileName)
+		}
+		yamlConfig := filepath.Ext(configFileName) == ".yaml" || filepath.Ext(configFileName) == ".yml"
+		if exists && merge && !yamlConfig {
+			return fmt.Errorf("only YAML config files can be merged, edit %s directly or replace it with --force", configFileName)
 		}
 
-		// Write the default config
-		err := os.WriteFile(configFileName, defaultConfig, 0644)
+		config := defaultConfig
+		var err error
+		if interactive {
+			config, err = interactiveConfig(".", cmd.InOrStdin(), cmd.OutOrStdout())
+		} else if template != "" {
+			config, err = configTemplate(template)
+		} else if exists && merge {
+			config = recommendedConfig(existing)
+		}
 		if err != nil {
-			fmt.Println("Unable to write config file:", err)
-			os.Exit(1)
+			return err
+		}
+
+		// Merge the recommended patterns into the existing config
+		if exists && merge {
+			var added []string
+			config, added, err = mergeConfig(existing, config)
+			if err != nil {
+				return err
+			}
+			if len(added) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Config file is up to date:", configFileName)
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Added %d exclude patterns to %s: %s\n", len(added), configFileName, strings.Join(added, ", "))
+		}
+
+		// Write the config, the generated config is YAML so a config in another format is replaced
+		replaced := ""
+		if !yamlConfig {
+			replaced, configFileName = configFileName, projectConfigFiles[0]
+		}
+		if err := os.WriteFile(configFileName, config, 0644); err != nil {
+			return fmt.Errorf("unable to write config file: %w", err)
+		}
+		if exists && replaced != "" {
+			if err := os.Remove(replaced); err != nil {
+				return fmt.Errorf("unable to remove config file: %w", err)
+			}
 		}
 
 		// Inform the user
-		fmt.Println("Config file created at:", configFileName)
+		switch {
+		case exists && merge:
+			// The added patterns are already reported
+		case exists && replaced != "":
+			fmt.Fprintf(cmd.OutOrStdout(), "Config file %s replaced by: %s\n", replaced, configFileName)
+		case exists:
+			fmt.Fprintln(cmd.OutOrStdout(), "Config file overwritten at:", configFileName)
+		default:
+			fmt.Fprintln(cmd.OutOrStdout(), "Config file created at:", configFileName)
+		}
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(initCmd)
+	addInitFlags(initCmd)
+}
+
+// addInitFlags adds the init flags to cmd.
+func addInitFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("interactive", false, "Detect the project type and ask questions to generate a tailored config")
+	cmd.Flags().Bool("merge", false, "Add newly recommended exclude patterns to an existing config, preserving its edits and comments")
+	cmd.Flags().Bool("force", false, "Overwrite an existing config")
+	cmd.Flags().String("template", "", "Write a curated config for a stack ("+strings.Join(configTemplateNames(), ", ")+")")
 }
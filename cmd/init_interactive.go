@@ -0,0 +1,155 @@
+// Description: This file implements the project detection and questions of "init --interactive".
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectType describes how to detect an ecosystem and which of its files to exclude
+type projectType struct {
+	name string
+	// markers are files in the project directory identifying the project type
+	markers []string
+	// exclude contains the patterns for dependencies and build output
+	exclude []string
+	// tests contains the patterns for test files, excluded unless tests are included
+	tests []string
+}
+
+// projectTypes contains the project types detected by "init --interactive"
+var projectTypes = []projectType{
+	{
+		name:    "Go",
+		markers: []string{"go.mod"},
+		exclude: []string{"vendor/**", "bin/**"},
+		tests:   []string{"**/*_test.go", "**/testdata/**"},
+	},
+	{
+		name:    "Node",
+		markers: []string{"package.json"},
+		exclude: []string{"node_modules/**", "dist/**", "build/**", "coverage/**", "**/*.min.js", "**/package-lock.json", "**/yarn.lock", "**/pnpm-lock.yaml"},
+		tests:   []string{"**/*.test.js", "**/*.test.ts", "**/*.spec.js", "**/*.spec.ts", "**/__tests__/**"},
+	},
+	{
+		name:    "Python",
+		markers: []string{"pyproject.toml", "setup.py", "requirements.txt", "Pipfile"},
+		exclude: []string{"**/__pycache__/**", "**/*.pyc", ".venv/**", "venv/**", "**/*.egg-info/**", "dist/**", "build/**"},
+		tests:   []string{"tests/**", "**/test_*.py", "**/*_test.py", "**/conftest.py"},
+	},
+}
+
+// genericExcludes contains the patterns excluded for every project type
+var genericExcludes = []string{".git/**", ".idea/**", ".vscode/**", "logs/**", "**/*.log", "**/*.tmp", "**/*.bak", "**/*.swp"}
+
+// docsExcludes contains the patterns for documentation, excluded unless docs are included
+var docsExcludes = []string{"docs/**", "**/*.md", "**/*.rst"}
+
+// patternGroup is a commented group of patterns in a generated config file
+type patternGroup struct {
+	comment  string
+	patterns []string
+}
+
+// detectProjectTypes returns the project types whose marker files exist in dir
+func detectProjectTypes(dir string) []projectType {
+	var detected []projectType
+	for _, pt := range projectTypes {
+		for _, marker := range pt.markers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				detected = append(detected, pt)
+				break
+			}
+		}
+	}
+	return detected
+}
+
+// interactiveConfig detects the project type of dir, asks the questions on in and out,
+// and returns the generated config
+func interactiveConfig(dir string, in io.Reader, out io.Writer) ([]byte, error) {
+	detected := detectProjectTypes(dir)
+	names := make([]string, 0, len(detected))
+	for _, pt := range detected {
+		names = append(names, pt.name)
+	}
+	switch len(detected) {
+	case 0:
+		fmt.Fprintln(out, "No known project type detected, using generic patterns")
+	case 1:
+		fmt.Fprintln(out, "Detected project type:", names[0])
+	default:
+		fmt.Fprintln(out, "Detected mixed project:", strings.Join(names, ", "))
+	}
+
+	reader := bufio.NewReader(in)
+	includeTests, err := askYesNo(reader, out, "Include test files?")
+	if err != nil {
+		return nil, err
+	}
+	includeDocs, err := askYesNo(reader, out, "Include documentation?")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []patternGroup{{comment: "Generic exclude patterns", patterns: genericExcludes}}
+	for _, pt := range detected {
+		groups = append(groups, patternGroup{comment: pt.name + " dependencies and build output", patterns: pt.exclude})
+		if !includeTests {
+			groups = append(groups, patternGroup{comment: pt.name + " tests", patterns: pt.tests})
+		}
+	}
+	if !includeDocs {
+		groups = append(groups, patternGroup{comment: "Documentation", patterns: docsExcludes})
+	}
+
+	title := "generic project"
+	if len(names) > 0 {
+		title = strings.Join(names, ", ") + " project"
+	}
+	return renderConfig(title, groups), nil
+}
+
+// askYesNo asks a question on out and reads the answer from reader, defaulting to no
+func askYesNo(reader *bufio.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// renderConfig renders a config file including everything but the given exclude pattern groups.
+// Patterns appearing in more than one group are only listed in the first one.
+func renderConfig(title string, groups []patternGroup) []byte {
+	var sb strings.Builder
+	sb.WriteString("# Configuration for the crev tool (" + title + ")\n\n")
+	sb.WriteString("# Specify the glob patterns for files and directories to include (default is all files)\n")
+	sb.WriteString("include:\n  - \"**/*\"\n\n")
+	sb.WriteString("# Specify the glob patterns for files and directories to exclude\n")
+	sb.WriteString("exclude:\n")
+
+	seen := make(map[string]bool)
+	for i, group := range groups {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("  # " + group.comment + "\n")
+		for _, pattern := range group.patterns {
+			if seen[pattern] {
+				continue
+			}
+			seen[pattern] = true
+			sb.WriteString(fmt.Sprintf("  - %q\n", pattern))
+		}
+	}
+
+	sb.WriteString(redactConfigExample)
+	return []byte(sb.String())
+}
@@ -0,0 +1,74 @@
+// Description: This file implements "init --merge", which adds newly recommended patterns to an existing config file.
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configHeader is the first line of the generated config files. Generated configs add the
+// template or project type in parentheses, e.g. "(Go template)".
+const configHeader = "# Configuration for the crev tool"
+
+// recommendedConfig returns the config whose patterns --merge adds to existing when neither
+// --template nor --interactive is given: the template existing was generated from, according to
+// its first line, the default config if it was generated without a template, and only the
+// language-agnostic genericExcludes otherwise, e.g. for configs written by hand.
+func recommendedConfig(existing []byte) []byte {
+	header, _, _ := bytes.Cut(existing, []byte("\n"))
+	title, ok := strings.CutPrefix(string(header), configHeader)
+	switch {
+	case ok && title == "":
+		return defaultConfig
+	case ok && strings.HasSuffix(title, " template)"):
+		name := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(title, " ("), " template)"))
+		if slices.Contains(configTemplateNames(), name) {
+			// The template exists, so reading it cannot fail
+			template, _ := configTemplate(name)
+			return template
+		}
+	}
+	return renderConfig("generic project", []patternGroup{{comment: "Generic exclude patterns", patterns: genericExcludes}})
+}
+
+// mergeConfig adds the exclude patterns of the recommended config that are missing from the
+// existing config, and returns the merged config together with the added patterns.
+// The existing config is edited as text, so the formatting and comments of the user are preserved.
+// Include patterns are never merged, as adding the recommended "**/*" would undo a narrowed selection.
+func mergeConfig(existing, recommended []byte) ([]byte, []string, error) {
+	var rec struct {
+		Exclude []string `yaml:"exclude"`
+	}
+	if err := yaml.Unmarshal(recommended, &rec); err != nil {
+		return nil, nil, fmt.Errorf("invalid recommended config: %w", err)
+	}
+
+	doc, err := parseConfigDocument(existing)
+	if err != nil {
+		return nil, nil, err
+	}
+	present := make(map[string]bool)
+	for _, pattern := range doc.listItems("exclude") {
+		present[pattern] = true
+	}
+
+	var added []string
+	for _, pattern := range rec.Exclude {
+		if !present[pattern] {
+			present[pattern] = true
+			added = append(added, pattern)
+		}
+	}
+	if len(added) == 0 {
+		return existing, nil, nil
+	}
+
+	if err := doc.appendToList("exclude", "Added by crev init --merge", added...); err != nil {
+		return nil, nil, err
+	}
+	return doc.bytes(), added, nil
+}
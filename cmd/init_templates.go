@@ -0,0 +1,33 @@
+// Description: This file contains the config templates of "init --template", which are embedded in the binary.
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+//go:embed templates/*.yaml
+var configTemplates embed.FS
+
+// configTemplateNames returns the names of the embedded config templates
+func configTemplateNames() []string {
+	// The pattern is valid, so fs.Glob cannot fail
+	matches, _ := fs.Glob(configTemplates, "templates/*.yaml")
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(path.Base(match), ".yaml"))
+	}
+	return names
+}
+
+// configTemplate returns the config template with the given name
+func configTemplate(name string) ([]byte, error) {
+	content, err := configTemplates.ReadFile("templates/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(configTemplateNames(), ", "))
+	}
+	return append(content, redactConfigExample...), nil
+}
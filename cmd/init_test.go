@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// executeInitCmd runs the init command with fresh flags, the given input, and returns its output
+func (env *testEnv) executeInitCmd(input string, args ...string) (string, error) {
+	initCmd.ResetFlags()
+	addInitFlags(initCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetIn(strings.NewReader(input))
+	env.t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetIn(nil)
+	})
+	rootCmd.SetArgs(append([]string{"init"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// TestInitCmd tests that init writes the default config and refuses to overwrite it
+func TestInitCmd(t *testing.T) {
+	env := newTestEnv(t)
+
+	out, err := env.executeInitCmd("")
+	require.NoError(t, err)
+	require.Contains(t, out, "Config file created at: .crev-config.yaml")
+	content, err := os.ReadFile(".crev-config.yaml")
+	require.NoError(t, err)
+	require.Equal(t, string(defaultConfig), string(content))
+
+	_, err = env.executeInitCmd("")
+	env.assertErrorContains(err, "config file already exists")
+}
+
+// TestInitCmdInteractive tests that the project type is detected and the answers shape the config
+func TestInitCmdInteractive(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"go.mod":       "module example.com/app",
+		"package.json": "{}",
+	})
+
+	out, err := env.executeInitCmd("y\nn\n", "--interactive")
+	require.NoError(t, err)
+	require.Contains(t, out, "Detected mixed project: Go, Node")
+	require.Contains(t, out, "Include test files? [y/N]: ")
+
+	content, err := os.ReadFile(".crev-config.yaml")
+	require.NoError(t, err)
+	config := string(content)
+	require.Contains(t, config, `- "node_modules/**"`)
+	require.Contains(t, config, `- "docs/**"`)
+	require.NotContains(t, config, `- "**/*_test.go"`, "tests were included")
+	require.NotContains(t, config, `- "**/__pycache__/**"`, "not a Python project")
+
+	// The generated config must be valid YAML readable by viper
+	viper.SetConfigFile(".crev-config.yaml")
+	require.NoError(t, viper.ReadInConfig())
+	require.Equal(t, []string{"**/*"}, viper.GetStringSlice("include"))
+	require.Contains(t, viper.GetStringSlice("exclude"), "vendor/**")
+}
+
+// TestInitCmdInteractiveGeneric tests the defaults when no project type is detected
+func TestInitCmdInteractiveGeneric(t *testing.T) {
+	env := newTestEnv(t)
+
+	out, err := env.executeInitCmd("", "--interactive")
+	require.NoError(t, err)
+	require.Contains(t, out, "No known project type detected")
+
+	content, err := os.ReadFile(".crev-config.yaml")
+	require.NoError(t, err)
+	require.Contains(t, string(content), `- "**/*.md"`, "docs are excluded by default")
+}
+
+// TestInitCmdTemplate tests that every embedded template is valid YAML and written by --template
+func TestInitCmdTemplate(t *testing.T) {
+	require.Equal(t, []string{"django", "go", "node", "python", "react", "rust"}, configTemplateNames())
+
+	for _, name := range configTemplateNames() {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+
+			_, err := env.executeInitCmd("", "--template", name)
+			require.NoError(t, err)
+
+			viper.SetConfigFile(".crev-config.yaml")
+			require.NoError(t, viper.ReadInConfig())
+			require.Equal(t, []string{"**/*"}, viper.GetStringSlice("include"))
+			require.NotEmpty(t, viper.GetStringSlice("exclude"))
+		})
+	}
+}
+
+// TestInitCmdUnknownTemplate tests that an unknown template is rejected with the available ones
+func TestInitCmdUnknownTemplate(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.executeInitCmd("", "--template", "cobol")
+	env.assertErrorContains(err, `unknown template "cobol" (available: django, go, node, python, react, rust)`)
+	require.NoFileExists(t, ".crev-config.yaml")
+}
+
+// TestInitCmdMerge tests that --merge adds the missing recommended excludes and preserves the existing config
+func TestInitCmdMerge(t *testing.T) {
+	env := newTestEnv(t)
+	existing := `# My project config
+
+include:
+  - "src/**" # only the sources
+
+exclude:
+  # Keep the generated code out
+  - "src/gen/**"
+  - "vendor/**"
+
+stdout: true
+`
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte(existing), 0644))
+
+	out, err := env.executeInitCmd("", "--merge", "--template", "go")
+	require.NoError(t, err)
+	require.Contains(t, out, "Added 11 exclude patterns to .crev-config.yaml: bin/**, dist/**")
+
+	content, err := os.ReadFile(".crev-config.yaml")
+	require.NoError(t, err)
+	merged := string(content)
+	require.True(t, strings.HasPrefix(merged, `# My project config
+
+include:
+  - "src/**" # only the sources
+
+exclude:
+  # Keep the generated code out
+  - "src/gen/**"
+  - "vendor/**"
+  # Added by crev init --merge
+  - "bin/**"
+`), merged)
+	require.True(t, strings.HasSuffix(merged, "  - \"**/*.md\"\n\nstdout: true\n"), merged)
+
+	// The merged config is valid and a second merge has nothing to add
+	viper.SetConfigFile(".crev-config.yaml")
+	require.NoError(t, viper.ReadInConfig())
+	require.Equal(t, []string{"src/**"}, viper.GetStringSlice("include"))
+	require.Contains(t, viper.GetStringSlice("exclude"), "**/*_test.go")
+
+	out, err = env.executeInitCmd("", "--merge", "--template", "go")
+	require.NoError(t, err)
+	require.Contains(t, out, "Config file is up to date")
+}
+
+// TestInitCmdMergeRecommended tests that --merge without --template only adds the patterns of the
+// template the config was generated from, or the language-agnostic ones for other configs
+func TestInitCmdMergeRecommended(t *testing.T) {
+	env := newTestEnv(t)
+	goConfig, err := configTemplate("go")
+	require.NoError(t, err)
+	edited := strings.Replace(string(goConfig), "  - \"bin/**\"\n", "", 1)
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte(edited), 0644))
+
+	out, err := env.executeInitCmd("", "--merge")
+	require.NoError(t, err)
+	require.Contains(t, out, "Added 1 exclude patterns to .crev-config.yaml: bin/**")
+
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte("# My config\nexclude:\n  - \"src/gen/**\"\n"), 0644))
+	_, err = env.executeInitCmd("", "--merge")
+	require.NoError(t, err)
+
+	viper.SetConfigFile(".crev-config.yaml")
+	require.NoError(t, viper.ReadInConfig())
+	require.Equal(t, append([]string{"src/gen/**"}, genericExcludes...), viper.GetStringSlice("exclude"))
+	require.NotContains(t, viper.GetStringSlice("exclude"), "**/*.php")
+	require.NotContains(t, viper.GetStringSlice("exclude"), "public/**")
+}
+
+// TestInitCmdMergeWithoutExcludes tests that --merge adds an exclude list to a config without one
+func TestInitCmdMergeWithoutExcludes(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte("include:\n  - \"src/**\"\n"), 0644))
+
+	_, err := env.executeInitCmd("", "--merge", "--template", "rust")
+	require.NoError(t, err)
+
+	viper.SetConfigFile(".crev-config.yaml")
+	require.NoError(t, viper.ReadInConfig())
+	require.Equal(t, []string{"src/**"}, viper.GetStringSlice("include"))
+	require.Equal(t, []string{"target/**", "**/Cargo.lock", "tests/**", "benches/**", "**/*.log", "**/*.md"}, viper.GetStringSlice("exclude"))
+}
+
+// TestInitCmdForce tests that --force overwrites an existing config
+func TestInitCmdForce(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte("include: []\n"), 0644))
+
+	out, err := env.executeInitCmd("", "--force")
+	require.NoError(t, err)
+	require.Contains(t, out, "Config file overwritten at: .crev-config.yaml")
+
+	content, err := os.ReadFile(".crev-config.yaml")
+	require.NoError(t, err)
+	require.Equal(t, string(defaultConfig), string(content))
+}
+
+// TestInitCmdOtherConfigNames tests that init detects configs of every name instead of shadowing them
+func TestInitCmdOtherConfigNames(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, os.WriteFile(".crev-config.toml", []byte("include = [\"src/**\"]\n"), 0644))
+
+	_, err := env.executeInitCmd("")
+	env.assertErrorContains(err, "config file already exists at .crev-config.toml")
+	_, err = env.executeInitCmd("", "--merge")
+	env.assertErrorContains(err, "only YAML config files can be merged, edit .crev-config.toml directly")
+	require.NoFileExists(t, ".crev-config.yaml")
+
+	out, err := env.executeInitCmd("", "--force")
+	require.NoError(t, err)
+	require.Contains(t, out, "Config file .crev-config.toml replaced by: .crev-config.yaml")
+	require.NoFileExists(t, ".crev-config.toml")
+	content, err := os.ReadFile(".crev-config.yaml")
+	require.NoError(t, err)
+	require.Equal(t, string(defaultConfig), string(content))
+
+	require.NoError(t, os.Remove(".crev-config.yaml"))
+	require.NoError(t, os.WriteFile(".crev-config.yml", []byte("include:\n  - \"src/**\"\n"), 0644))
+	out, err = env.executeInitCmd("", "--merge")
+	require.NoError(t, err)
+	require.Contains(t, out, "exclude patterns to .crev-config.yml")
+	require.NoFileExists(t, ".crev-config.yaml")
+}
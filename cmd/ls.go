@@ -0,0 +1,67 @@
+// Description: This file implements the "ls" command, which prints the paths of the files that would be bundled.
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls [path]",
+	Short: "Print the paths of the files that would be bundled",
+	Long: `Print the paths of the files that "crev bundle" would include, one per line, using the same
+file selection rules and the include/exclude patterns of the config file.
+
+Only files are printed, relative to the project directory, so the selection can be piped into
+other tools. Use --null to separate the paths with NUL characters for paths containing newlines.
+
+Example usage:
+  # List the files that would be bundled
+  crev ls
+
+  # Archive the selected files
+  crev ls --null | xargs -0 tar -czf project.tar.gz
+
+  # Count the lines of the selected Go files
+  crev ls --include='**/*.go' | xargs wc -l`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		null, _ := cmd.Flags().GetBool("null")
+		return List(cmd.OutOrStdout(), selectionOptions(cmd, args), null)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lsCmd)
+	addLsFlags(lsCmd)
+}
+
+// addLsFlags adds the ls flags to cmd.
+func addLsFlags(cmd *cobra.Command) {
+	addSelectionFlags(cmd)
+	cmd.Flags().BoolP("null", "0", false, "Separate the paths with NUL characters instead of newlines")
+}
+
+// List writes the paths of the files selected by opts to w, separated by newlines or NUL characters.
+func List(w io.Writer, opts BundleOptions, null bool) error {
+	entries, err := selectEntries(opts)
+	if err != nil {
+		return err
+	}
+
+	separator := "\n"
+	if null {
+		separator = "\x00"
+	}
+	for _, entry := range entries {
+		// Directories are selected for the project tree, but only files are listed
+		if entry.IsDir {
+			continue
+		}
+		if _, err := io.WriteString(w, entry.Path+separator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// executeLsCmd runs the ls command with fresh flags and returns its output
+func (env *testEnv) executeLsCmd(args ...string) (string, error) {
+	lsCmd.ResetFlags()
+	addLsFlags(lsCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	env.t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs(append([]string{"ls"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// TestLsCmd tests that the ls command prints the selected files, one per line
+func TestLsCmd(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":     "package main",
+		"src/util/str.go": "package util",
+		"docs/readme.md":  "# Readme",
+		"logo.png":        "png",
+		".git/config":     "[core]",
+	})
+
+	out, err := env.executeLsCmd(".", "--exclude", "docs")
+	require.NoError(t, err)
+	require.Equal(t, "src/main.go\nsrc/util/str.go\n", out)
+}
+
+// TestLsCmdNull tests NUL separated output and the config patterns
+func TestLsCmdNull(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go": "package main",
+		"src/app.py":  "print('hi')",
+		"lib/lib.go":  "package lib",
+	})
+	env.setupConfig(`
+include:
+  - "src/**"
+`)
+
+	out, err := env.executeLsCmd("-0")
+	require.NoError(t, err)
+	require.Equal(t, "src/app.py\x00src/main.go\x00", out)
+}
+
+// TestLsCmdSafeMode tests that the safe mode filter of the bundle command is applied
+func TestLsCmdSafeMode(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":  "package main",
+		"data.bin": "binary",
+	})
+
+	out, err := env.executeLsCmd("--safe-mode")
+	require.NoError(t, err)
+	require.Equal(t, "main.go\n", out)
+}
+
+// TestLsCommandNoDefaultExcludes tests that the config key disables the built-in ignore lists
+func TestLsCommandNoDefaultExcludes(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":     "package main",
+		".env.sample": "KEY=",
+	})
+	env.setupConfig("no-default-excludes: true\n")
+
+	out, err := env.executeLsCmd(".")
+	require.NoError(t, err)
+	require.Equal(t, ".crev-config.yaml\n.env.sample\nmain.go\n", out)
+}
+
+// TestLsCmdDetectShebang tests selecting extensionless scripts by the language of their shebang
+func TestLsCmdDetectShebang(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"app.py":     "print('app')",
+		"bin/deploy": "#!/usr/bin/env python3\nprint('deploy')",
+		"bin/setup":  "#!/bin/sh\necho setup",
+	})
+
+	out, err := env.executeLsCmd("--include", "**/*.py", "--detect-shebang")
+	require.NoError(t, err)
+	require.Equal(t, "app.py\nbin/deploy\n", out)
+}
+
+// TestLsCmdPragmas tests excluding and pinning files with in-file pragmas
+func TestLsCmdPragmas(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":      "package main",
+		"gen/types.go": "// Code generated. DO NOT EDIT.\n// crev:ignore\npackage gen",
+		"notes.md":     "<!-- crev:include -->\n# Notes",
+	})
+
+	out, err := env.executeLsCmd("--include", "**/*.go", "--pragmas")
+	require.NoError(t, err)
+	require.Equal(t, "main.go\nnotes.md\n", out)
+}
+
+// TestLsCmdSelect tests selecting files with a select expression
+func TestLsCmdSelect(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":      "package main",
+		"main_test.go": "package main",
+		"app.py":       "print('app')",
+	})
+
+	out, err := env.executeLsCmd("--select", "lang:go and not test")
+	require.NoError(t, err)
+	require.Equal(t, "main.go\n", out)
+
+	_, err = env.executeLsCmd("--select", "lang:go and")
+	env.assertErrorContains(err, `invalid select expression "lang:go and": unexpected end of expression`)
+}
+
+// TestLsMatchesDryRun tests that ls lists the same files as bundle --dry-run, which both leave out
+// the bundle files written by crev
+func TestLsMatchesDryRun(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":          "package main",
+		"crev-project.txt": "OLD BUNDLE",
+		"reviews/api.txt":  "OLD CUSTOM BUNDLE",
+	})
+
+	// dryRunPaths returns the paths listed by bundle --dry-run, without the header and the totals
+	dryRunPaths := func() string {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		stdout := os.Stdout
+		os.Stdout = w
+		defer func() { os.Stdout = stdout }()
+		err = env.executeBundleCmd(".", "--dry-run", "--no-default-excludes")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+		var paths strings.Builder
+		for _, line := range lines[1 : len(lines)-1] {
+			fields := strings.Fields(line)
+			paths.WriteString(fields[len(fields)-1] + "\n")
+		}
+		return paths.String()
+	}
+
+	out, err := env.executeLsCmd(".", "--no-default-excludes")
+	require.NoError(t, err)
+	require.Equal(t, "main.go\nreviews/api.txt\n", out)
+	require.Equal(t, out, dryRunPaths())
+
+	require.NoError(t, os.WriteFile(".crev-config.yaml", []byte("output: reviews/api.txt\n"), 0644))
+	out, err = env.executeLsCmd(".", "--no-default-excludes")
+	require.NoError(t, err)
+	require.Equal(t, ".crev-config.yaml\ncrev-project.txt\nmain.go\n", out)
+	require.Equal(t, out, dryRunPaths())
+}
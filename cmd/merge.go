@@ -0,0 +1,142 @@
+// Description: This file implements the "merge" command, which combines bundles of several projects into one.
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/spf13/cobra"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <bundle>...",
+	Short: "Combine the bundles of several projects into one",
+	Long: `Combine the bundles of several projects into one bundle for cross-repository reviews.
+
+The paths of the files of each bundle are prefixed with a namespace, so files with the same
+path in different projects stay apart, and the project tree is rebuilt from all files.
+The namespace of a bundle is the name of its file without extensions, or the name of its
+directory for bundles with the default name crev-project.txt. Use --prefix to name them.
+
+Compressed and age encrypted bundles are supported. Custom sections of the bundles are not
+carried over.
+
+Example usage:
+  # Merge the bundles of two repositories into crev-merged.txt, namespaced api/ and web/
+  crev merge ../api/crev-project.txt ../web/crev-project.txt
+
+  # Choose the namespaces and the output file
+  crev merge a.txt b.txt --prefix backend --prefix frontend -o combined.txt`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefixes, _ := cmd.Flags().GetStringSlice("prefix")
+		output, _ := cmd.Flags().GetString("output")
+		identityFile, _ := cmd.Flags().GetString("identity")
+		return Merge(args, prefixes, output, identityFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+	addMergeFlags(mergeCmd)
+}
+
+// addMergeFlags adds the merge flags to cmd.
+func addMergeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("prefix", nil, "Namespace of each bundle, in the order of the bundles")
+	cmd.Flags().StringP("output", "o", "crev-merged.txt", "File to write the merged bundle to, - for stdout")
+	cmd.Flags().StringP("identity", "i", "", "age identity file used to decrypt .age bundles")
+}
+
+// Merge combines the files of bundleFiles into one bundle written to output. The paths of each
+// bundle are prefixed with the matching entry of prefixes, or a namespace derived from its name.
+func Merge(bundleFiles, prefixes []string, output, identityFile string) error {
+	namespaces, err := mergeNamespaces(bundleFiles, prefixes)
+	if err != nil {
+		return err
+	}
+
+	var filePaths []string
+	fileContentMap := make(map[string]string)
+	for i, bundleFile := range bundleFiles {
+		bundle, err := readBundle(bundleFile, identityFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", bundleFile, err)
+		}
+		for _, file := range bundle.Files {
+			filePath := namespacedPath(namespaces[i], file.Path)
+			filePaths = append(filePaths, filePath)
+			fileContentMap[filePath] = file.Content
+		}
+	}
+
+	sink, err := files.OpenSink(output)
+	if err != nil {
+		return fmt.Errorf("error opening output: %w", err)
+	}
+	if err := formatting.WriteText(sink, formatting.NewBundle(filePaths, fileContentMap)); err != nil {
+		sink.Close()
+		return fmt.Errorf("error writing merged bundle: %w", err)
+	}
+	if err := sink.Close(); err != nil {
+		return fmt.Errorf("error writing merged bundle: %w", err)
+	}
+
+	if output != files.StdoutSink {
+		log.Printf("Merged %d files of %d bundles into: %s", len(filePaths), len(bundleFiles), output)
+	}
+	return nil
+}
+
+// mergeNamespaces returns the namespace of each bundle, which must be unique.
+func mergeNamespaces(bundleFiles, prefixes []string) ([]string, error) {
+	if len(prefixes) > 0 && len(prefixes) != len(bundleFiles) {
+		return nil, fmt.Errorf("got %d prefixes for %d bundles, specify one prefix per bundle", len(prefixes), len(bundleFiles))
+	}
+
+	namespaces := make([]string, len(bundleFiles))
+	seen := make(map[string]string)
+	for i, bundleFile := range bundleFiles {
+		namespace := defaultNamespace(bundleFile)
+		if len(prefixes) > 0 {
+			namespace = namespacedPath("", prefixes[i])
+		}
+		if namespace == "" {
+			return nil, fmt.Errorf("invalid namespace %q for bundle %s", namespace, bundleFile)
+		}
+		if other, ok := seen[namespace]; ok {
+			return nil, fmt.Errorf("bundles %s and %s have the same namespace %q, use --prefix to name them", other, bundleFile, namespace)
+		}
+		seen[namespace] = bundleFile
+		namespaces[i] = namespace
+	}
+	return namespaces, nil
+}
+
+// defaultNamespace returns the name of bundleFile without the bundle extensions, or the name of
+// its directory if the bundle has the default name.
+func defaultNamespace(bundleFile string) string {
+	name := filepath.Base(bundleFile)
+	for _, ext := range []string{files.EncryptionExtension, ".gz", ".zst", ".txt"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if name == "crev-project" {
+		abs, err := filepath.Abs(bundleFile)
+		if err == nil {
+			name = filepath.Base(filepath.Dir(abs))
+		}
+	}
+	return name
+}
+
+// namespacedPath joins namespace and the path of a file of a bundle. Leading "/" and "../"
+// elements of the path are dropped, so all files stay inside their namespace.
+func namespacedPath(namespace, filePath string) string {
+	cleaned := path.Clean("/" + filepath.ToSlash(filePath))
+	return path.Join(namespace, strings.TrimPrefix(cleaned, "/"))
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestBundle writes a bundle of fileContentMap to bundleFile
+func writeTestBundle(t *testing.T, bundleFile string, fileContentMap map[string]string) {
+	var paths []string
+	for p := range fileContentMap {
+		paths = append(paths, p)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(bundleFile), 0755))
+	content := formatting.RenderText(formatting.NewBundle(paths, fileContentMap))
+	require.NoError(t, os.WriteFile(bundleFile, []byte(content), 0644))
+}
+
+// TestMerge tests merging bundles with the same paths into namespaces derived from their names
+func TestMerge(t *testing.T) {
+	env := newTestEnv(t)
+	writeTestBundle(t, "api/crev-project.txt", map[string]string{"main.go": "package api", "../shared/x.go": "package x"})
+	writeTestBundle(t, "web.txt", map[string]string{"main.go": "package web"})
+
+	err := Merge([]string{"api/crev-project.txt", "web.txt"}, nil, "crev-merged.txt", "")
+	require.NoError(t, err)
+	env.assertFileContents("crev-merged.txt", []string{
+		"├── api (2 files)",
+		"File: \napi/main.go\nContent: \npackage api\n\n",
+		"File: \napi/shared/x.go\nContent: \npackage x\n\n",
+		"File: \nweb/main.go\nContent: \npackage web\n\n",
+	}, nil)
+	env.assertLogContains("Merged 3 files of 2 bundles into: crev-merged.txt")
+
+	// The merged bundle can be extracted
+	outputDir := t.TempDir()
+	require.NoError(t, Unbundle("crev-merged.txt", outputDir, "", false))
+	extracted, err := os.ReadFile(filepath.Join(outputDir, "web", "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package web", string(extracted))
+}
+
+// TestMergePrefixes tests naming the namespaces and rejecting ambiguous namespaces
+func TestMergePrefixes(t *testing.T) {
+	env := newTestEnv(t)
+	writeTestBundle(t, "a/crev-project.txt", map[string]string{"main.go": "package a"})
+	writeTestBundle(t, "b/a/crev-project.txt", map[string]string{"main.go": "package b"})
+
+	err := Merge([]string{"a/crev-project.txt", "b/a/crev-project.txt"}, nil, "crev-merged.txt", "")
+	env.assertErrorContains(err, `have the same namespace "a", use --prefix to name them`)
+
+	err = Merge([]string{"a/crev-project.txt", "b/a/crev-project.txt"}, []string{"one"}, "crev-merged.txt", "")
+	env.assertErrorContains(err, "got 1 prefixes for 2 bundles")
+
+	err = Merge([]string{"a/crev-project.txt", "b/a/crev-project.txt"}, []string{"one", "two/"}, "crev-merged.txt", "")
+	require.NoError(t, err)
+	env.assertFileContents("crev-merged.txt", []string{"one/main.go", "two/main.go"}, nil)
+}
@@ -0,0 +1,50 @@
+// Description: This file contains the --model presets, which set the token budget and format of a bundle for a model.
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// modelPreset is the token budget and the bundle format recommended for a model.
+type modelPreset struct {
+	// maxTokens leaves a fifth of the context window of the model for the prompt and the answer.
+	maxTokens int
+	format    string
+}
+
+// modelPresets maps the models supported by --model to their preset. The tokens are estimated
+// with budget.Tokens for all models, as the tokenizers of the models are not available offline.
+var modelPresets = map[string]modelPreset{
+	"gpt-4o":         {maxTokens: 100_000, format: formatText},
+	"claude-sonnet":  {maxTokens: 160_000, format: formatXML},
+	"gemini-1.5-pro": {maxTokens: 1_600_000, format: formatText},
+}
+
+// modelNames returns the sorted names of the models of modelPresets.
+func modelNames() []string {
+	names := make([]string, 0, len(modelPresets))
+	for name := range modelPresets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// applyModelPreset makes the preset of model the default of --max-tokens and --format, so the
+// flags, environment and config files still override them. An empty model applies no preset.
+func applyModelPreset(model string) error {
+	if model == "" {
+		return nil
+	}
+	preset, ok := modelPresets[model]
+	if !ok {
+		return fmt.Errorf("unsupported model %q (supported: %s)", model, strings.Join(modelNames(), ", "))
+	}
+	viper.SetDefault("max-tokens", preset.maxTokens)
+	viper.SetDefault("format", preset.format)
+	return nil
+}
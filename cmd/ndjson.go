@@ -0,0 +1,87 @@
+// Description: This file contains the streaming NDJSON output format of the bundle command.
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/devinbarry/crev/internal/coverage"
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/devinbarry/crev/internal/redact"
+	"github.com/devinbarry/crev/internal/report"
+)
+
+// writeNDJSON reads the selected files in batches of opts.MaxConcurrency files and writes one
+// NDJSON record per file to the output sink as soon as its batch is processed, so the bundle is
+// never held in memory as a whole. The files are written in the order of their paths, like in the
+// text format. The records of the files in fileCoverage include their coverage. It returns the sizes of the written files and the number of bytes written.
+func writeNDJSON(logger *slog.Logger, entries []files.Entry, fileCoverage map[string]coverage.File, outputTarget string, redactor *redact.Redactor, contentPattern *regexp.Regexp, opts BundleOptions) (fileSizes []report.FileSize, written int, err error) {
+	var explicitPaths []string
+	if contentPattern != nil {
+		if explicitPaths, err = explicitSelectionPaths(opts.RootDir, opts.ExplicitFiles); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	fileEntries := slices.DeleteFunc(slices.Clone(entries), func(entry files.Entry) bool { return entry.IsDir })
+	fileEntries, _ = omitLargeFiles(logger, fileEntries, opts.MaxFileSize)
+	slices.SortFunc(fileEntries, func(a, b files.Entry) int { return strings.Compare(a.Path, b.Path) })
+
+	sink, err := openOutput(outputTarget, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if closeErr := sink.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing output: %w", closeErr)
+		}
+	}()
+
+	writeStart := time.Now()
+	counter := &countingWriter{w: sink}
+	writer := formatting.NewNDJSONWriter(counter)
+	fsys := files.HostFS(opts.RootDir)
+	batchSize := max(opts.MaxConcurrency, 1)
+	for start := 0; start < len(fileEntries); start += batchSize {
+		batch := fileEntries[start:min(start+batchSize, len(fileEntries))]
+		fileContentMap, err := files.GetContentMapOfEntriesFS(fsys, batch, opts.MaxConcurrency)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error getting file contents: %w", err)
+		}
+		processContents(logger, files.EntryPaths(batch), fileContentMap, redactor, contentPattern, explicitPaths, opts)
+
+		for _, entry := range batch {
+			content, ok := fileContentMap[entry.Path]
+			if !ok {
+				continue
+			}
+			filePath := entry.Path
+			if !opts.RelativePaths {
+				filePath = filepath.ToSlash(filepath.Join(opts.RootDir, entry.Path))
+			}
+			filePath = opts.Anonymizer.Path(filePath)
+			record := formatting.NewNDJSONRecord(filePath, content, entry)
+			if f, ok := fileCoverage[filepath.ToSlash(entry.Path)]; ok {
+				percent := coveragePercent(f)
+				record.Coverage = &percent
+			}
+			if err := writer.Write(record); err != nil {
+				return nil, 0, fmt.Errorf("error saving file: %w", err)
+			}
+			fileSizes = append(fileSizes, report.FileSize{Path: filePath, Size: len(content)})
+		}
+	}
+	if contentPattern != nil && len(fileSizes) == 0 {
+		return nil, 0, fmt.Errorf("no files found with content matching %q", contentPattern)
+	}
+	logger.Debug(fmt.Sprintf("Wrote %d bytes", counter.n),
+		"phase", "write", "bytes", counter.n, report.Duration(time.Since(writeStart)))
+
+	return fileSizes, counter.n, nil
+}
@@ -0,0 +1,64 @@
+// Description: This file contains the loading of the config files in subdirectories of the project.
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/spf13/viper"
+)
+
+// nestedConfigPatterns match the config files in subdirectories of the root directory
+var nestedConfigPatterns = []string{"**/.crev-config.yaml", "**/.crev-config.yml", "**/.crev-config.toml", "**/.crev-config.json", "**/.crev.json"}
+
+// loadNestedConfigs reads the config files found in subdirectories of root, skipping the
+// directories matched by excludePatterns. Their include and exclude patterns only apply to
+// the subtree of the directory they are in, on top of the rules of the root config.
+func loadNestedConfigs(root string, excludePatterns []string) ([]files.DirRules, error) {
+	// The default exclusion of hidden files would also exclude the config files, so only the
+	// contents of hidden directories are skipped
+	patterns := make([]string, 0, len(excludePatterns))
+	for _, pattern := range excludePatterns {
+		switch pattern {
+		case ".*", "**/.*":
+			pattern += "/*"
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	configPaths, err := files.Select(root, files.Selection{
+		IncludePatterns: nestedConfigPatterns,
+		ExcludePatterns: patterns,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error looking for nested config files: %w", err)
+	}
+
+	var rules []files.DirRules
+	for _, configPath := range configPaths {
+		// The config file of the root directory is the project config
+		dir := path.Dir(configPath)
+		if dir == "." {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(configPath)))
+		if err != nil {
+			return nil, fmt.Errorf("error reading nested config file %s: %w", configPath, err)
+		}
+		config := viper.New()
+		config.SetConfigType(configType(configPath))
+		if err := config.ReadConfig(bytes.NewReader(content)); err != nil {
+			return nil, fmt.Errorf("invalid nested config file %s: %w", configPath, err)
+		}
+		rules = append(rules, files.DirRules{
+			Dir:             dir,
+			IncludePatterns: config.GetStringSlice("include"),
+			ExcludePatterns: config.GetStringSlice("exclude"),
+		})
+	}
+	return rules, nil
+}
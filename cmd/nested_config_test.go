@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBundleCommandWithNestedConfig tests that the config files of subdirectories apply to their subtree
+func TestBundleCommandWithNestedConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":                             "package main",
+		"fixtures/root.json":                  "{}",
+		"services/api/main.go":                "package api",
+		"services/api/fixtures/data.json":     "{}",
+		"services/api/docs/api.md":            "# API",
+		"services/api/.crev-config.yaml":      "exclude:\n  - \"fixtures\"\n  - \"**/*.md\"\n",
+		"services/web/src/app.ts":             "export {}",
+		"services/web/scripts/build.sh":       "make",
+		"services/web/.crev-config.yml":       "include:\n  - \"src/**\"\n",
+		"services/ignored/.crev-config.yaml":  "exclude:\n  - \"**/*\"\n",
+		"services/ignored/kept-by-parent.txt": "parent",
+	})
+	env.setupConfig(`
+include:
+  - "**/*"
+exclude:
+  - "services/ignored/**"
+`)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err, "Bundle command execution failed")
+
+	env.assertFileContents("crev-project.txt",
+		[]string{"File: \nmain.go", "File: \nfixtures/root.json", "File: \nservices/api/main.go", "File: \nservices/web/src/app.ts"},
+		[]string{"services/api/fixtures/data.json", "services/api/docs/api.md", "services/web/scripts/build.sh", "kept-by-parent.txt"})
+}
+
+// TestTreeCommandWithInvalidNestedConfig tests that an invalid nested config file is reported
+func TestTreeCommandWithInvalidNestedConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":               "package main",
+		"sub/.crev-config.yaml": "exclude: [",
+	})
+
+	_, err := env.executeTreeCmd(".")
+	env.assertErrorContains(err, "invalid nested config file sub/.crev-config.yaml")
+}
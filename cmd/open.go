@@ -0,0 +1,54 @@
+// Description: This file contains opening the generated bundle in an editor or pager with --open.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/viper"
+)
+
+// editorCommand returns the command opening the bundle: the "editor" key of the global config, or
+// the VISUAL, EDITOR or PAGER environment variables, falling back to less. The project config and
+// the configs it extends are ignored, as they can come from the repository under review.
+func editorCommand() string {
+	if file := globalConfigFile(); file != "" {
+		global := viper.New()
+		global.SetConfigFile(file)
+		if err := global.ReadInConfig(); err == nil && global.GetString("editor") != "" {
+			return global.GetString("editor")
+		}
+	}
+	for _, key := range []string{"VISUAL", "EDITOR", "PAGER"} {
+		if editor := os.Getenv(key); editor != "" {
+			return editor
+		}
+	}
+	return "less"
+}
+
+// validateOpen checks that the bundle of opts is a text file that can be opened.
+func validateOpen(opts BundleOptions) error {
+	if opts.Stdout {
+		return fmt.Errorf("--open cannot be used with --stdout")
+	}
+	if opts.Compress != "" || len(opts.EncryptTo) > 0 {
+		return fmt.Errorf("--open cannot be used with --compress or --encrypt-to")
+	}
+	return nil
+}
+
+// openInEditor runs the editor command on path through the shell, so the command can contain
+// arguments (e.g. "code --wait"), and waits for it to exit.
+func openInEditor(path string) error {
+	editor := editorCommand()
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("unable to open %s with %q: %w", path, editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/spf13/viper"
+)
+
+const (
+	// bundleBaseName is the name of the bundle file without the extension of its format.
+	bundleBaseName = "crev-project"
+	// bundleFileName is the name of a text bundle, before compression and encryption extensions.
+	bundleFileName = bundleBaseName + ".txt"
+	// indexFileName is the name of the sidecar index written next to the bundle with --index.
+	indexFileName = "crev-project.index.json"
+)
+
+// Bundle formats supported by --format.
+const (
+	formatText   = "text"
+	formatJSON   = "json"
+	formatXML    = "xml"
+	formatHTML   = "html"
+	formatNDJSON = "ndjson"
+)
+
+// bundleFormats are the supported bundle formats, in the order of formatExtensions.
+var bundleFormats = []string{formatText, formatJSON, formatXML, formatHTML, formatNDJSON}
+
+// formatExtensions maps the bundle formats to the extension of the bundle file.
+var formatExtensions = map[string]string{
+	formatText:   ".txt",
+	formatJSON:   ".json",
+	formatXML:    ".xml",
+	formatHTML:   ".html",
+	formatNDJSON: ".ndjson",
+}
+
+// bundleFormatters maps the bundle formats written from the complete bundle model to their
+// formatter. NDJSON bundles are streamed file by file instead, see writeNDJSON.
+var bundleFormatters = map[string]formatting.Formatter{
+	formatText: formatting.TextFormatter{},
+	formatJSON: formatting.JSONFormatter{},
+	formatXML:  formatting.XMLFormatter{},
+	formatHTML: formatting.HTMLFormatter{},
+}
+
+// bundleFormatter returns the formatter of format, the text format if empty.
+func bundleFormatter(format string) formatting.Formatter {
+	if formatter, ok := bundleFormatters[format]; ok {
+		return formatter
+	}
+	return formatting.TextFormatter{}
+}
+
+// formatExtension returns the extension of the bundle file of format, the text format if empty.
+func formatExtension(format string) (string, error) {
+	if format == "" {
+		format = formatText
+	}
+	ext, ok := formatExtensions[format]
+	if !ok {
+		return "", fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(bundleFormats, ", "))
+	}
+	return ext, nil
+}
+
+// resolveOutput returns the target the bundle named name is written to, see --output: output
+// itself if it is a file or a sink of files.OpenSink, the file named name in output if it is a
+// directory, or in outputDir if output is empty. Network sinks are refused in offline mode.
+func resolveOutput(output, outputDir, name string) (string, error) {
+	switch {
+	case output == "":
+		return filepath.Join(outputDir, name), nil
+	case files.IsNetworkSink(output) && offlineMode():
+		return "", fmt.Errorf("--output %s cannot be used in offline mode", output)
+	case !files.IsFileSink(output):
+		return output, nil
+	case strings.HasSuffix(output, "/") || strings.HasSuffix(output, string(filepath.Separator)):
+		return filepath.Join(output, name), nil
+	}
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		return filepath.Join(output, name), nil
+	}
+	return output, nil
+}
+
+// outputSetting returns the output of the bundle, see resolveOutput. Commands and sockets run or
+// connect to whatever the value names, so they are only accepted from the --output flag and not
+// from a config file, which can come from the repository or a remote base config.
+func outputSetting(fromFlag bool) (string, error) {
+	output := viper.GetString("output")
+	if !fromFlag && output != files.StdoutSink && !files.IsFileSink(output) {
+		return "", fmt.Errorf("output %q from the config is not a file, commands and sockets can only be given with --output", output)
+	}
+	return output, nil
+}
+
+// validateStreamOutput checks that the options can be used with an output that is not a file,
+// such as a command or a network connection.
+func validateStreamOutput(opts BundleOptions) error {
+	if opts.Index || opts.Gitignore || opts.Keep > 0 || opts.SplitTokens > 0 || opts.Append || opts.Open {
+		return fmt.Errorf("--output %s is not a file and cannot be used with --index, --gitignore, --keep, --split-tokens, --append or --open", opts.Output)
+	}
+	return nil
+}
+
+// splitBundleName splits the name of a bundle file at its first extension, e.g. into
+// "crev-project" and ".txt.gz", so parts and archived bundles can be named after it.
+func splitBundleName(name string) (stem, ext string) {
+	if i := strings.IndexByte(strings.TrimPrefix(name, "."), '.'); i >= 0 {
+		i += len(name) - len(strings.TrimPrefix(name, "."))
+		return name[:i], name[i:]
+	}
+	return name, ""
+}
+
+// outputFile returns the directory and the name of the bundle file target, see resolveOutput.
+func outputFile(target string) (dir, name string) {
+	dir, name = filepath.Split(target)
+	return filepath.Clean(dir), name
+}
+
+// outputExcludePatterns returns exclude patterns for the bundles and indexes that crev writes to
+// outputDir, if it is inside rootDir, so earlier bundles never end up in the next one. Bundles
+// of any format and with any compression or encryption extension are excluded, as well as the
+// parts written with --split-tokens and the bundles archived with --keep. A bundle file named by
+// --output, if not empty, and its parts are excluded too.
+func outputExcludePatterns(rootDir, outputDir, outputName string) ([]string, error) {
+	absRootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(absRootDir, absOutputDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, nil
+	}
+
+	prefix := ""
+	if rel != "." {
+		prefix = escapeGlob(filepath.ToSlash(rel)) + "/"
+	}
+	patterns := make([]string, 0, len(bundleFormats)+3)
+	for _, format := range bundleFormats {
+		patterns = append(patterns, prefix+bundleBaseName+formatExtensions[format]+"*")
+	}
+	patterns = append(patterns, prefix+bundleBaseName+partMarker+"*", prefix+indexFileName, prefix+historyDir+"/**")
+	if outputName != "" {
+		stem, _ := splitBundleName(outputName)
+		patterns = append(patterns, prefix+escapeGlob(outputName), prefix+escapeGlob(stem)+partMarker+"*")
+	}
+	return patterns, nil
+}
+
+// escapeGlob escapes the wildcards of a literal path, so it can be used as a glob pattern.
+func escapeGlob(literal string) string {
+	var sb strings.Builder
+	for _, r := range literal {
+		if strings.ContainsRune(`*?[]{}\`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
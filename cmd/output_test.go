@@ -0,0 +1,658 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompressGzipFlag tests that --compress gzip writes a gzip compressed bundle
+func TestCompressGzipFlag(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"main.go": "package main",
+	}
+	env.createProjectStructure(files)
+
+	err := env.executeBundleCmd(".", "--compress", "gzip")
+	require.NoError(t, err)
+	require.NoFileExists(t, "crev-project.txt")
+
+	f, err := os.Open("crev-project.txt.gz")
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "package main")
+
+	env.assertLogContains("Project overview successfully saved to:", "crev-project.txt.gz")
+}
+
+// TestCompressUnsupportedFlag tests that an unknown compression algorithm is rejected
+func TestCompressUnsupportedFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--compress", "rar")
+	env.assertErrorContains(err, `unsupported compression "rar"`)
+}
+
+// TestCompressZstdFlag tests that --compress zstd with a level writes a zstd compressed bundle
+func TestCompressZstdFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--compress", "zstd", "--compress-level", "19")
+	require.NoError(t, err)
+
+	f, err := os.Open("crev-project.txt.zst")
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	require.NoError(t, err)
+	defer zr.Close()
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "package main")
+}
+
+// TestCompressLevelInvalidFlag tests that an out of range compression level is rejected
+func TestCompressLevelInvalidFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--compress", "gzip", "--compress-level", "12")
+	env.assertErrorContains(err, "invalid gzip compression level 12")
+	require.NoFileExists(t, "crev-project.txt.gz")
+}
+
+// TestLogFormatJSON tests that --log-format json emits one structured event per line
+func TestLogFormatJSON(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"src/main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--log-format", "json", "--include", "src/**", "--include", "lib/**")
+	require.NoError(t, err)
+	require.FileExists(t, "crev-project.txt")
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(env.LogBuffer.String()), "\n") {
+		// Skip the plain log lines of the test helpers
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &event), "log line is not JSON: %s", line)
+		events = append(events, event)
+	}
+
+	phases := make(map[string]map[string]any)
+	for _, event := range events {
+		phases[event["phase"].(string)] = event
+	}
+	require.Contains(t, phases, "read")
+	require.Contains(t, phases["write"], "duration_ms")
+
+	warning := phases["select"]
+	for _, event := range events {
+		if event["level"] == "WARN" {
+			warning = event
+		}
+	}
+	require.Equal(t, "WARN", warning["level"])
+	require.Equal(t, "lib/**", warning["include"])
+
+	summary := events[len(events)-1]
+	require.Equal(t, "summary", summary["phase"])
+	require.Equal(t, filepath.Join(env.TempDir, "crev-project.txt"), summary["output"])
+	require.EqualValues(t, 1, summary["files"])
+	require.NotContains(t, env.LogBuffer.String(), "Project overview successfully saved")
+}
+
+// TestCIMode tests that --ci uses relative paths and JSON events, and fails on warnings
+func TestCIMode(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"project/src/main.go": "package main"})
+
+	err := env.executeBundleCmd("project", "--ci")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"File: \nsrc/main.go"}, []string{"project/src/main.go"})
+	require.Contains(t, env.LogBuffer.String(), `"phase":"summary"`)
+
+	env = newTestEnv(t)
+	env.createProjectStructure(map[string]string{"project/src/main.go": "package main"})
+	err = env.executeBundleCmd("project", "--ci", "--include", "src/**", "--include", "lib/**")
+	env.assertErrorContains(err, "1 warning(s) reported, failing because of strict mode (--ci)")
+	require.FileExists(t, "crev-project.txt")
+
+	// The CI variable of CI services does not enable CI mode
+	t.Setenv("CI", "true")
+	env = newTestEnv(t)
+	env.createProjectStructure(map[string]string{"project/src/main.go": "package main"})
+	err = env.executeBundleCmd("project", "--include", "src/**", "--include", "lib/**")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"project/src/main.go"}, nil)
+	require.NotContains(t, env.LogBuffer.String(), `"phase":"summary"`)
+}
+
+// TestLogFormatUnsupported tests that an unknown log format is rejected
+func TestLogFormatUnsupported(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--log-format", "xml")
+	env.assertErrorContains(err, `unsupported log format "xml"`)
+}
+
+// TestQuietFlag tests that --quiet suppresses logging and only prints the output path
+func TestQuietFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"src/main.go": "package main"})
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	err = env.executeBundleCmd(".", "--quiet", "--include", "src/**", "--include", "lib/**")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	require.Equal(t, filepath.Join(env.TempDir, "crev-project.txt")+"\n", string(out))
+	require.NotContains(t, env.LogBuffer.String(), "Warning:")
+	require.NotContains(t, env.LogBuffer.String(), "Project overview successfully saved")
+}
+
+// TestDryRun tests that --dry-run prints the selected files with their size and token estimate without writing the bundle
+func TestDryRun(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":     "package main\n",
+		"src/big.json":    strings.Repeat("x", 3000),
+		"docs/guide.md":   "# Guide\n",
+		"src/lib/util.go": strings.Repeat("// util\n", 200),
+	})
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	err = env.executeBundleCmd(".", "--dry-run", "--include", "src/**", "--max-file-size", "2kb", "-o", "out/")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	require.Equal(t, `     SIZE   TOKENS  PATH
+   2.9 KB        -  src/big.json (omitted, larger than --max-file-size)
+   1.6 KB      534  src/lib/util.go
+     13 B        5  src/main.go
+2 files, 1.6 KB, about 538 tokens (1 omitted)
+`, string(out))
+	require.NoFileExists(t, "crev-project.txt")
+	require.NoDirExists(t, "out")
+}
+
+// TestIndexFlag tests that --index writes a sidecar index with the offsets of each file
+func TestIndexFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go": "package main",
+		"src/util.go": "package util",
+	})
+
+	err := env.executeBundleCmd(".", "--index")
+	require.NoError(t, err)
+
+	bundle, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	data, err := os.ReadFile("crev-project.index.json")
+	require.NoError(t, err)
+
+	var index formatting.Index
+	require.NoError(t, json.Unmarshal(data, &index))
+	require.Equal(t, "crev-project.txt", index.Bundle)
+	require.Len(t, index.Files, 2)
+	for _, entry := range index.Files {
+		require.Equal(t, "package "+strings.TrimSuffix(filepath.Base(entry.Path), ".go"),
+			string(bundle[entry.Offset:entry.Offset+entry.Length]))
+	}
+}
+
+// TestIndexWithStdoutFlag tests that --index is rejected when writing to stdout
+func TestIndexWithStdoutFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--index", "--stdout")
+	env.assertErrorContains(err, "--index cannot be used with --stdout")
+}
+
+// TestSectionsConfig tests the configured order of the bundle sections and custom sections
+func TestSectionsConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":            "package main",
+		"docs/checklist.txt": "- errors are wrapped\n",
+	})
+	env.setupConfig(`
+sections:
+  - title: Review instructions
+    content: |
+      Focus on error handling.
+  - files
+  - title: Checklist
+    file: docs/checklist.txt
+exclude:
+  - docs
+`)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	content, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	require.Equal(t, "Section: \nReview instructions\nContent: \nFocus on error handling.\n\n"+
+		"File: \nmain.go\nContent: \npackage main\n\n"+
+		"Section: \nChecklist\nContent: \n- errors are wrapped\n\n", string(content))
+}
+
+// TestSectionsConfigInvalid tests that unknown sections are rejected
+func TestSectionsConfigInvalid(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+	env.setupConfig(`
+sections:
+  - metadata
+  - files
+`)
+
+	err := env.executeBundleCmd(".")
+	env.assertErrorContains(err, `invalid sections config: unknown section "metadata"`)
+}
+
+// TestFenceFlags tests wrapping the file contents in code fences
+func TestFenceFlags(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--fence", "--fence-length", "4")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"File: \nmain.go\nContent: \n````go\npackage main\n````\n\n"}, nil)
+
+	err = env.executeBundleCmd(".", "--fence", "--fence-char", "*")
+	env.assertErrorContains(err, "invalid fence character")
+}
+
+// TestMarkUntrusted tests enclosing the file contents in untrusted content markers with a random nonce.
+func TestMarkUntrusted(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--mark-untrusted")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"Section: \nUntrusted content\n", "Content: \n<<<UNTRUSTED-CONTENT "}, nil)
+
+	content, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	match := regexp.MustCompile(`<<<UNTRUSTED-CONTENT ([0-9a-f]{16})>>>\n`).FindStringSubmatch(string(content))
+	require.NotNil(t, match)
+	require.Contains(t, string(content), match[0]+"package main\n<<<END-UNTRUSTED-CONTENT "+match[1]+">>>\n\n")
+}
+
+// TestOpen tests opening the bundle with the editor command from the environment
+func TestOpen(t *testing.T) {
+	t.Setenv("VISUAL", "cat > opened.txt <")
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--open")
+	require.NoError(t, err)
+	env.assertFileContents("opened.txt", []string{"File: \nmain.go\nContent: \npackage main\n\n"}, nil)
+
+	err = newTestEnv(t).executeBundleCmd(".", "--open", "--stdout")
+	env.assertErrorContains(err, "--open cannot be used with --stdout")
+}
+
+// TestOpenFromConfig tests that a project config can neither enable --open nor choose the editor
+func TestOpenFromConfig(t *testing.T) {
+	t.Setenv("VISUAL", "cat > opened.txt <")
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":           "package main",
+		".crev-config.yaml": "open: true\neditor: 'touch pwned; true'\n",
+	})
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+	require.NoFileExists(t, "opened.txt")
+
+	err = env.executeBundleCmd(".", "--open")
+	require.NoError(t, err)
+	require.NoFileExists(t, "pwned")
+	env.assertFileContents("opened.txt", []string{"File: \nmain.go\n"}, nil)
+
+	// The editor of the global config is trusted
+	globalDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "crev")
+	require.NoError(t, os.MkdirAll(globalDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "config.yaml"), []byte("editor: 'cp -t global'\n"), 0644))
+	require.NoError(t, os.Mkdir("global", 0755))
+	err = env.executeBundleCmd(".", "--open")
+	require.NoError(t, err)
+	require.FileExists(t, "global/crev-project.txt")
+}
+
+// TestFormatNDJSON tests that --format ndjson writes one JSON object per file in path order
+func TestFormatNDJSON(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":     "package main\n",
+		"lib/util.py": "print('util')\n",
+	})
+
+	err := env.executeBundleCmd(".", "--format", "ndjson", "--relative-paths")
+	require.NoError(t, err)
+	require.NoFileExists(t, "crev-project.txt")
+
+	content, err := os.ReadFile("crev-project.ndjson")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var records []formatting.NDJSONRecord
+	for _, line := range lines {
+		var record formatting.NDJSONRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	require.Equal(t, "lib/util.py", records[0].Path)
+	require.Equal(t, "python", records[0].Language)
+	require.Equal(t, "main.go", records[1].Path)
+	require.Equal(t, "package main\n", records[1].Content)
+	require.Equal(t, 1, records[1].Lines)
+	require.NotEmpty(t, records[1].ModTime)
+
+	// The previous bundle is not bundled again
+	require.NoError(t, env.executeBundleCmd(".", "--format", "ndjson"))
+	content, err = os.ReadFile("crev-project.ndjson")
+	require.NoError(t, err)
+	require.Len(t, strings.Split(strings.TrimSuffix(string(content), "\n"), "\n"), 2)
+}
+
+// TestFormatJSON tests that --format json writes a single document with the tree and the files
+func TestFormatJSON(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":     "package main\n",
+		"lib/util.py": "print('util')\n",
+	})
+
+	err := env.executeBundleCmd(".", "--format", "json", "--relative-paths")
+	require.NoError(t, err)
+	require.NoFileExists(t, "crev-project.txt")
+
+	content, err := os.ReadFile("crev-project.json")
+	require.NoError(t, err)
+	var doc formatting.JSONDocument
+	require.NoError(t, json.Unmarshal(content, &doc))
+	require.Contains(t, doc.Tree, "util.py")
+	require.Len(t, doc.Files, 2)
+	require.Equal(t, "lib/util.py", doc.Files[0].Path)
+	require.Equal(t, formatting.JSONFile{Path: "main.go", Size: 13, Tokens: 5, Content: "package main\n"}, doc.Files[1])
+	require.Equal(t, formatting.JSONStats{Files: 2, Bytes: 27, Tokens: 9}, doc.Stats)
+
+	// The previous bundle is not bundled again
+	require.NoError(t, env.executeBundleCmd(".", "--format", "json"))
+	content, err = os.ReadFile("crev-project.json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(content, &doc))
+	require.Len(t, doc.Files, 2)
+
+	err = env.executeBundleCmd(".", "--format", "json", "--mark-untrusted")
+	env.assertErrorContains(err, "--format json cannot be used with --mark-untrusted")
+}
+
+// TestFormatXML tests that --format xml encloses each file in document tags
+func TestFormatXML(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":     "package main\n",
+		"lib/util.py": "print('util')\n",
+	})
+
+	err := env.executeBundleCmd(".", "--format", "xml", "--relative-paths")
+	require.NoError(t, err)
+	require.NoFileExists(t, "crev-project.txt")
+	env.assertFileContents("crev-project.xml", []string{
+		"<project_tree>\n",
+		"<documents>\n<document index=\"1\" path=\"lib/util.py\">\n<![CDATA[print('util')\n]]>\n</document>\n" +
+			"<document index=\"2\" path=\"main.go\">\n<![CDATA[package main\n]]>\n</document>\n</documents>\n",
+	}, []string{"File: "})
+
+	// The previous bundle is not bundled again
+	require.NoError(t, env.executeBundleCmd(".", "--format", "xml"))
+	env.assertFileContents("crev-project.xml", nil, []string{"crev-project.xml"})
+
+	err = env.executeBundleCmd(".", "--format", "xml", "--mark-untrusted")
+	require.NoError(t, err)
+	content, err := os.ReadFile("crev-project.xml")
+	require.NoError(t, err)
+	match := regexp.MustCompile(`<<<UNTRUSTED-CONTENT ([0-9a-f]{16})>>>\n`).FindStringSubmatch(string(content))
+	require.NotNil(t, match)
+	require.Contains(t, string(content), "<![CDATA["+match[0]+"package main\n<<<END-UNTRUSTED-CONTENT "+match[1]+">>>\n]]>\n")
+	require.Contains(t, string(content), `<section title="Untrusted content">`)
+
+	err = env.executeBundleCmd(".", "--format", "xml", "--index")
+	env.assertErrorContains(err, "--format xml cannot be used with --index")
+}
+
+// TestFormatHTML tests that --format html writes a self-contained page with the highlighted files
+func TestFormatHTML(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":     "package main\n",
+		"lib/util.py": "print('util')\n",
+	})
+
+	err := env.executeBundleCmd(".", "--format", "html", "--relative-paths")
+	require.NoError(t, err)
+	require.NoFileExists(t, "crev-project.txt")
+	env.assertFileContents("crev-project.html", []string{
+		"<!DOCTYPE html>",
+		"<summary>Project Directory Structure</summary>",
+		`<summary>lib/util.py<span class="meta">1 line</span></summary>` + "\n<pre><code>print(<span class=\"s\">&#39;util&#39;</span>)",
+		`<span class="k">package</span> main`,
+	}, nil)
+
+	// The previous bundle is not bundled again
+	require.NoError(t, env.executeBundleCmd(".", "--format", "html"))
+	env.assertFileContents("crev-project.html", nil, []string{"crev-project.html"})
+
+	err = env.executeBundleCmd(".", "--format", "html", "--mark-untrusted")
+	env.assertErrorContains(err, "--format html cannot be used with --mark-untrusted")
+}
+
+// TestFormatUnsupported tests that unknown formats and options of the text format are rejected
+func TestFormatUnsupported(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--format", "pdf")
+	env.assertErrorContains(err, `unsupported format "pdf"`)
+
+	err = env.executeBundleCmd(".", "--format", "ndjson", "--index")
+	env.assertErrorContains(err, "--format ndjson cannot be used with --index")
+}
+
+// TestSymbolsFlag tests appending the symbol index of the bundled files
+func TestSymbolsFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":    "package main\n\nfunc main() {}\n",
+		"lib/api.py": "def handler():\n    pass\n",
+	})
+
+	err := env.executeBundleCmd(".", "--symbols", "--relative-paths")
+	require.NoError(t, err)
+	content, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(string(content),
+		"Symbol Index:\nlib/api.py\n  func handler (line 1)\nmain.go\n  func main (line 3)\n\n"), string(content))
+}
+
+// TestImportsFlag tests appending the import graph, with Go imports resolved by the module path
+func TestImportsFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"go.mod":          "module example.com/app\n",
+		"main.go":         "package main\n\nimport \"example.com/app/store\"\n",
+		"store/store.go":  "package store\n",
+		"store/memory.go": "package store\n\nimport \"sync\"\n",
+		"tools/lint/x.go": "package lint\n",
+		"tools/lint/y.go": "package lint\n\nimport \"example.com/other/store\"\n",
+	})
+
+	err := env.executeBundleCmd(".", "--imports", "--relative-paths")
+	require.NoError(t, err)
+	content, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(string(content),
+		"Import Graph:\nmain.go\n  imports: store\nstore\n  imported by: main.go\n\n"), string(content))
+}
+
+// TestHistory tests adding the recent commits and the head of the changelog to the top of the bundle
+func TestHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"main.go":      "package main",
+		"CHANGELOG.md": "# Changelog\n\n## 1.1.0\n- Retry failed uploads\n\n## 1.0.0\n- First release\n",
+	})
+	for _, args := range [][]string{{"init", "-q"}, {"add", "main.go"}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "Retry failed uploads"}} {
+		require.NoError(t, exec.Command("git", args...).Run())
+	}
+
+	err := env.executeBundleCmd(".", "--history", "5", "--changelog-lines", "4")
+	require.NoError(t, err)
+	content, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	require.Regexp(t, "^Section: \nRecent commits \\(last 5\\)\nContent: \n\\w+ \\d{4}-\\d{2}-\\d{2}\n  Retry failed uploads\n\n"+
+		"Section: \nChangelog \\(CHANGELOG.md\\)\nContent: \n# Changelog\n\n## 1.1.0\n- Retry failed uploads\n… truncated \\(3 more lines\\)\n\n"+
+		"Project Directory Structure:\n", string(content))
+}
+
+// TestCoverage tests adding the coverage of the bundled files from a Go coverage profile to the top
+// of the bundle and to the NDJSON records
+func TestCoverage(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"go.mod":         "module example.com/app\n",
+		"main.go":        "package main",
+		"store/store.go": "package store",
+		"README.md":      "# App",
+		"coverage.out": "mode: set\nexample.com/app/main.go:3.13,5.2 3 1\nexample.com/app/main.go:7.13,8.2 1 0\n" +
+			"example.com/app/store/store.go:5.20,7.2 4 0\n",
+	})
+
+	err := env.executeBundleCmd(".", "--coverage", "coverage.out", "--exclude", "coverage.out")
+	require.NoError(t, err)
+	content, err := os.ReadFile("crev-project.txt")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(content), "Section: \nTest coverage (coverage.out)\nContent: \n"+
+		"Total: 37.5% (3/8)\n   0.0%  store/store.go (0/4)\n  75.0%  main.go (3/4)\n"+
+		"Files without coverage data: 1\n\nProject Directory Structure:\n"), string(content))
+
+	err = env.executeBundleCmd(".", "--coverage", "coverage.out", "--format", "ndjson", "--include", "main.go")
+	require.NoError(t, err)
+	content, err = os.ReadFile("crev-project.ndjson")
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"coverage":75,`)
+
+	err = env.executeBundleCmd(".", "--coverage", "missing.out")
+	require.ErrorContains(t, err, "error reading coverage report")
+}
+
+// TestAppend tests adding the files of several runs to one bundle, with duplicate paths reported
+func TestAppend(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"api/main.go": "package main",
+		"web/app.js":  "console.log('app')",
+	})
+
+	err := env.executeBundleCmd("api", "--index")
+	require.NoError(t, err)
+	err = env.executeBundleCmd("web", "--index", "--append")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{
+		"Project Directory Structure:\n├── api (1 file)\n│   └── main.go\n└── web (1 file)\n    └── app.js\n",
+		"File: \napi/main.go\nContent: \npackage main\n\n",
+		"File: \nweb/app.js\nContent: \nconsole.log('app')\n\n",
+	}, nil)
+
+	// The index keeps the checksums of the files of the earlier run
+	var out bytes.Buffer
+	require.NoError(t, Verify(&out, "crev-project.txt"))
+	require.Equal(t, "Bundle crev-project.txt is up to date (2 files)\n", out.String())
+
+	err = env.executeBundleCmd("web", "--append")
+	env.assertErrorContains(err, "crev-project.txt: 1 files are already in the bundle: web/app.js")
+
+	err = env.executeBundleCmd("web", "--append", "--stdout")
+	env.assertErrorContains(err, "--append cannot be used with --stdout")
+}
+
+// TestKeep tests archiving the bundles in the history and pruning the oldest ones
+func TestKeep(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--keep", "2")
+	require.NoError(t, err)
+	archived, err := filepath.Glob(".crev/history/crev-project-*.txt")
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	env.assertLogContains("Archived the bundle to " + filepath.Join(env.TempDir, archived[0]))
+
+	// Archived bundles are never bundled
+	err = env.executeBundleCmd(".", "--no-default-excludes")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"main.go"}, []string{".crev/history"})
+
+	// Only the newest bundles are kept
+	require.NoError(t, os.RemoveAll(".crev/history"))
+	base := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	for i := range 3 {
+		_, err := archiveBundle("crev-project.txt", 2, base.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+	}
+	entries, err := os.ReadDir(".crev/history")
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Equal(t, []string{"crev-project-20240102T160405Z.txt", "crev-project-20240102T170405Z.txt"}, names)
+
+	err = env.executeBundleCmd(".", "--keep", "2", "--stdout")
+	env.assertErrorContains(err, "--keep cannot be used with --stdout")
+}
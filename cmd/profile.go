@@ -0,0 +1,67 @@
+// Description: This file contains the hidden profiling flags used to diagnose performance problems in the field.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/spf13/cobra"
+)
+
+// cpuProfile is the file the running CPU profile is written to, nil if profiling is off
+var cpuProfile *os.File
+
+// addProfilingFlags adds the hidden --cpuprofile and --memprofile flags to cmd and its subcommands.
+func addProfilingFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("cpuprofile", "", "Write a CPU profile of the command to this file")
+	cmd.PersistentFlags().String("memprofile", "", "Write a heap profile to this file when the command finishes")
+	_ = cmd.PersistentFlags().MarkHidden("cpuprofile")
+	_ = cmd.PersistentFlags().MarkHidden("memprofile")
+}
+
+// startProfiling starts the CPU profile requested by --cpuprofile.
+func startProfiling(cmd *cobra.Command) error {
+	path, _ := cmd.Flags().GetString("cpuprofile")
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to start CPU profile: %w", err)
+	}
+	cpuProfile = f
+	return nil
+}
+
+// stopProfiling stops the CPU profile and writes the heap profile requested by --memprofile.
+// It is called after the command returned, also when it failed.
+func stopProfiling() error {
+	if cpuProfile != nil {
+		pprof.StopCPUProfile()
+		if err := cpuProfile.Close(); err != nil {
+			return fmt.Errorf("unable to write CPU profile: %w", err)
+		}
+		cpuProfile = nil
+	}
+
+	path, _ := rootCmd.PersistentFlags().GetString("memprofile")
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create heap profile: %w", err)
+	}
+	defer f.Close()
+	runtime.GC() // get up-to-date statistics of the live heap
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("unable to write heap profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Description: This file contains the detection of the project root for --root auto.
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// rootAuto is the value of --root detecting the project root from the working directory.
+const rootAuto = "auto"
+
+// projectRootMarkers are the files and directories marking the root of a project. .git is a
+// directory in a repository and a file in linked worktrees and submodules.
+var projectRootMarkers = append([]string{".git", "go.mod"}, projectConfigFiles...)
+
+// findProjectRoot returns the nearest of dir and its parent directories containing one of the
+// projectRootMarkers, or an empty string if there is none.
+func findProjectRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, marker := range projectRootMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// detectRootDir returns the project root of the working directory, relative to it, e.g. "../..",
+// so paths in the bundle are shown as for "crev bundle ../..". Without a project root the working
+// directory is bundled.
+func detectRootDir(logger *slog.Logger) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	root, err := findProjectRoot(cwd)
+	if err != nil {
+		return "", fmt.Errorf("error detecting the project root: %w", err)
+	}
+	if root == "" {
+		logger.Warn("no project root (.git, go.mod or crev config file) found above the working directory, bundling the working directory",
+			"phase", "config")
+		return ".", nil
+	}
+	rel, err := filepath.Rel(cwd, root)
+	if err != nil {
+		return "", err
+	}
+	logger.Info(fmt.Sprintf("Bundling the project root %s", rel), "phase", "config", "root", root)
+	return rel, nil
+}
@@ -2,9 +2,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/devinbarry/crev/internal/report"
+	"github.com/devinbarry/crev/internal/review"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -18,12 +27,20 @@ var rootCmd = &cobra.Command{
 	Short:   "Initialize",
 	Long: `Allows you to bundle your codebase and let it be reviewed by an AI. For more information see: https://crevcli.com/docs
 `,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		logConfigEvents()
+		return startProfiling(cmd)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
+	if profileErr := stopProfiling(); profileErr != nil {
+		fmt.Fprintln(os.Stderr, "Error:", profileErr)
+		err = profileErr
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -31,20 +48,172 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	review.Offline = offlineMode
+	rootCmd.PersistentFlags().Bool("offline", false,
+		"Block all network access, e.g. fetching remote configs (also 'offline: true' in config or OFFLINE=true)")
+	rootCmd.PersistentFlags().String("env", "",
+		"Apply the settings of this profile of the 'profiles' config key (default: ci if the CI variable is set)")
+	addProfilingFlags(rootCmd)
 	// otherwise the completion command will be available
 	rootCmd.Root().CompletionOptions.DisableDefaultCmd = true
 }
 
 // initConfig reads in config file and ENV variables if set.
+// The global config file is read first, the project config file is merged on top of it.
+// A config file can extend a base config with the "extends" key, see applyExtends, and the
+// settings of a profile are layered on top of them, see applyProfile.
 func initConfig() {
-	// Search the current directory for a config file
-	viper.SetConfigType("yaml")
-	viper.SetConfigName(".crev-config")
-	viper.AddConfigPath(".")
+	// Only CREV_ variables override config keys, e.g. CREV_COMPRESS_LEVEL, so that variables like CI
+	// or OUTPUT set by the environment are not taken for settings
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 
-	// If a config file is found, read it in
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	// Offline mode applies to the base configs as well, so it is resolved before they are loaded
+	global := viper.New()
+	globalConfig := globalConfigFile()
+	globalFound := false
+	if globalConfig != "" {
+		global.SetConfigFile(globalConfig)
+		globalFound = global.ReadInConfig() == nil
+	}
+	projectConfig := projectConfigFile(projectConfigDir(global))
+	if abs, err := filepath.Abs(projectConfig); err == nil && projectConfig != "" {
+		projectConfig = abs
+	}
+	project := viper.New()
+	projectFound := false
+	if projectConfig != "" {
+		project.SetConfigFile(projectConfig)
+		projectFound = project.ReadInConfig() == nil
+	}
+	resolveOffline(global.GetBool("offline"), project.GetBool("offline"))
+
+	// Read the global config file shared by all projects
+	if globalFound {
+		settings := global.AllSettings()
+		if ref := global.GetString("extends"); ref != "" {
+			settings = applyExtends(settings, ref, globalConfig)
+		}
+		viper.MergeConfigMap(settings)
+		configEvent(slog.LevelInfo, "Using global config file: "+globalConfig, "global_config", globalConfig)
+	}
+
+	// If a config file is found in the current directory, merge it in
+	if projectConfig != "" {
+		viper.SetConfigFile(projectConfig)
+		if err := viper.MergeInConfig(); err == nil {
+			configEvent(slog.LevelInfo, "Using config file: "+viper.ConfigFileUsed(), "config", viper.ConfigFileUsed())
+
+			// Merge the base config it extends between the global and the project config
+			if ref := project.GetString("extends"); projectFound && ref != "" {
+				viper.MergeConfigMap(applyExtends(project.AllSettings(), ref, viper.ConfigFileUsed()))
+			}
+		}
+	}
+
+	// Layer the settings of the selected profile on top of the config files
+	applyProfile()
+}
+
+// envPrefix is the prefix of the environment variables overriding config keys.
+const envPrefix = "CREV"
+
+// envName returns the environment variable overriding the config key, e.g. CREV_COMPRESS_LEVEL
+// for compress-level.
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// configEvents are the events of loading the config files. The config is loaded before the log
+// format and verbosity are known, so they are recorded and logged by logConfigEvents.
+var configEvents []slog.Record
+
+// configEvent records an event of loading the config files with the attributes args, see
+// configEvents. Like the events of a bundle run they have a "phase" attribute, here "config".
+func configEvent(level slog.Level, msg string, args ...any) {
+	event := slog.NewRecord(time.Now(), level, msg, 0)
+	event.Add(append([]any{"phase", "config"}, args...)...)
+	configEvents = append(configEvents, event)
+}
+
+// logConfigEvents logs the recorded configEvents to stderr with the log format and verbosity of
+// the config and the bundle flags, and drops them with --quiet.
+func logConfigEvents() {
+	events := configEvents
+	configEvents = nil
+	if viper.GetBool("quiet") {
+		return
+	}
+	logger, err := report.NewLogger(log.Writer(), viper.GetString("log-format"), viper.GetBool("verbose"))
+	if err != nil {
+		// The unsupported format is reported by the bundle command
+		logger, _ = report.NewLogger(log.Writer(), report.LogFormatText, false)
+	}
+	for _, event := range events {
+		if logger.Enabled(context.Background(), event.Level) {
+			logger.Handler().Handle(context.Background(), event)
+		}
+	}
+}
+
+// resolveOffline enables offline mode if the --offline flag, the OFFLINE environment variable or
+// one of the config files enables it. As a safety switch, no layer can disable it once another
+// layer enabled it.
+func resolveOffline(configs ...bool) {
+	offline, _ := rootCmd.PersistentFlags().GetBool("offline")
+	if env, err := strconv.ParseBool(os.Getenv("OFFLINE")); err == nil {
+		offline = offline || env
+	}
+	for _, config := range configs {
+		offline = offline || config
+	}
+	viper.Set("offline", offline)
+}
+
+// offlineMode reports whether network access is blocked, see resolveOffline.
+func offlineMode() bool {
+	return viper.GetBool("offline")
+}
+
+// projectConfigFiles are the names of the project config file, in order of precedence. The format
+// is detected by the extension.
+var projectConfigFiles = []string{".crev-config.yaml", ".crev-config.yml", ".crev-config.toml", ".crev-config.json", ".crev.json"}
+
+// projectConfigFile returns the path of the first of projectConfigFiles found in dir, or an
+// empty string if there is none.
+func projectConfigFile(dir string) string {
+	for _, name := range projectConfigFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// projectConfigDir returns the directory of the project config file: the project root if
+// --root auto is used (or 'root: auto' is set in the global config), and the current directory
+// otherwise.
+func projectConfigDir(global *viper.Viper) string {
+	root := viper.GetString("root")
+	if root == "" {
+		root = global.GetString("root")
+	}
+	if root != rootAuto {
+		return "."
+	}
+	if dir, err := findProjectRoot("."); err == nil && dir != "" {
+		return dir
+	}
+	return "."
+}
+
+// globalConfigFile returns the path of the global config file, or an empty string if the
+// user config directory is unknown. On Linux this is $XDG_CONFIG_HOME/crev/config.yaml.
+func globalConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
 	}
+	return filepath.Join(dir, "crev", "config.yaml")
 }
@@ -0,0 +1,81 @@
+// Description: This file contains the loading of the bundle section layout from the "sections" config key.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/spf13/viper"
+)
+
+// configuredSections returns the sections of the bundle in the order of the "sections" config key,
+// or nil if it is not set. An entry is the name of a built-in section ("tree" or "files"), or a
+// custom section with a title and its content or a file containing it, relative to the working directory.
+func configuredSections() ([]formatting.Section, error) {
+	if !viper.IsSet("sections") {
+		return nil, nil
+	}
+	entries, ok := viper.Get("sections").([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid sections config: expected a list")
+	}
+
+	sections := make([]formatting.Section, 0, len(entries))
+	for _, entry := range entries {
+		section, err := parseSection(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sections config: %w", err)
+		}
+		sections = append(sections, section)
+	}
+
+	if err := formatting.ValidateSections(sections); err != nil {
+		return nil, fmt.Errorf("invalid sections config: %w", err)
+	}
+	return sections, nil
+}
+
+// parseSection parses an entry of the "sections" config key
+func parseSection(entry any) (formatting.Section, error) {
+	if name, ok := entry.(string); ok {
+		return formatting.Section{Kind: formatting.SectionKind(name)}, nil
+	}
+	fields, ok := entry.(map[string]any)
+	if !ok {
+		return formatting.Section{}, fmt.Errorf("expected a section name or a custom section, got %v", entry)
+	}
+
+	section := formatting.Section{Kind: formatting.CustomSection}
+	var file string
+	for key, value := range fields {
+		text, ok := value.(string)
+		if !ok {
+			return formatting.Section{}, fmt.Errorf("%s of a custom section must be a string", key)
+		}
+		switch key {
+		case "title":
+			section.Title = text
+		case "content":
+			section.Content = text
+		case "file":
+			file = text
+		default:
+			return formatting.Section{}, fmt.Errorf("unknown key %q of a custom section (supported: title, content, file)", key)
+		}
+	}
+
+	if file != "" {
+		if section.Content != "" {
+			return formatting.Section{}, fmt.Errorf("section %q has both content and file", section.Title)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return formatting.Section{}, fmt.Errorf("unable to read section %q: %w", section.Title, err)
+		}
+		section.Content = string(content)
+	}
+	section.Content = strings.TrimRight(section.Content, "\n")
+	return section, nil
+}
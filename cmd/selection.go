@@ -0,0 +1,130 @@
+// Description: This file contains the file selection shared by the commands that inspect what would be bundled.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addSelectionFlags adds the file selection flags to a command inspecting the bundle selection.
+// Unlike the bundle flags they are not bound to viper, see stringSliceSetting.
+func addSelectionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceP("files", "f", nil, "Specify files to always include")
+	cmd.Flags().StringSliceP("include", "i", nil, "Include files matching these glob patterns")
+	cmd.Flags().StringSliceP("exclude", "e", nil, "Exclude files matching these glob patterns")
+	cmd.Flags().Bool("safe-mode", false, "Only select files with allowlisted text extensions")
+	cmd.Flags().Bool("no-default-excludes", false, "Disable the built-in ignore lists (hidden files, images, fonts, ...)")
+	cmd.Flags().Bool("include-manifests", false, "Select dependency manifests (go.mod, go.sum, poetry.lock) ignored by default")
+	cmd.Flags().Bool("git-tracked-only", false, "Only select files tracked by git")
+	cmd.Flags().StringSlice("ignore-source", nil, "Exclude the files ignored by these ignore files (supported: dockerignore)")
+	cmd.Flags().Bool("detect-shebang", false, "Match extensionless scripts against include patterns by the language of their shebang")
+	cmd.Flags().Bool("pragmas", false, "Honor crev:ignore and crev:include comments near the top of files")
+	cmd.Flags().Int("max-lines-filter", 0, "Leave out files with more lines (0: no limit)")
+	cmd.Flags().String("select", "", "Only select files matching this expression, e.g. 'lang:go and not test and size<50kb'")
+}
+
+// stringSliceSetting returns the value of the flag key of cmd if it was set, and the value
+// from the config file otherwise. It is used by commands whose flags are not bound to viper,
+// as the config keys are already bound to the flags of the bundle command.
+func stringSliceSetting(cmd *cobra.Command, key string) []string {
+	if cmd.Flags().Changed(key) {
+		value, _ := cmd.Flags().GetStringSlice(key)
+		return value
+	}
+	return viper.GetStringSlice(key)
+}
+
+// boolSetting is like stringSliceSetting for boolean flags.
+func boolSetting(cmd *cobra.Command, key string) bool {
+	if cmd.Flags().Changed(key) {
+		value, _ := cmd.Flags().GetBool(key)
+		return value
+	}
+	return viper.GetBool(key)
+}
+
+// stringSetting is like stringSliceSetting for string flags.
+func stringSetting(cmd *cobra.Command, key string) string {
+	if cmd.Flags().Changed(key) {
+		value, _ := cmd.Flags().GetString(key)
+		return value
+	}
+	return viper.GetString(key)
+}
+
+// intSetting is like stringSliceSetting for integer flags.
+func intSetting(cmd *cobra.Command, key string) int {
+	if cmd.Flags().Changed(key) {
+		value, _ := cmd.Flags().GetInt(key)
+		return value
+	}
+	return viper.GetInt(key)
+}
+
+// selectionOptions returns the bundle options describing the file selection of cmd,
+// applying the same defaults as the bundle command.
+func selectionOptions(cmd *cobra.Command, args []string) BundleOptions {
+	opts := DefaultBundleOptions()
+	if len(args) > 0 {
+		opts.RootDir = args[0]
+	}
+	opts.ExplicitFiles = stringSliceSetting(cmd, "files")
+	opts.IncludePatterns = stringSliceSetting(cmd, "include")
+	opts.ExcludePatterns = stringSliceSetting(cmd, "exclude")
+	opts.SafeMode = boolSetting(cmd, "safe-mode")
+	opts.NoDefaultExcludes = boolSetting(cmd, "no-default-excludes")
+	opts.IncludeManifests = boolSetting(cmd, "include-manifests")
+	opts.GitTrackedOnly = boolSetting(cmd, "git-tracked-only")
+	opts.IgnoreSources = stringSliceSetting(cmd, "ignore-source")
+	opts.DetectShebang = boolSetting(cmd, "detect-shebang")
+	opts.Pragmas = boolSetting(cmd, "pragmas")
+	opts.Select = stringSetting(cmd, "select")
+	opts.MaxLinesFilter = intSetting(cmd, "max-lines-filter")
+	opts.DefaultExcludes = configuredDefaultExcludes()
+	opts.SafeExtensions = viper.GetStringSlice("safe-extensions")
+
+	// The bundle file chosen in the config is not selected, like by the bundle command
+	if output := viper.GetString("output"); output != "" && files.IsFileSink(output) {
+		if target, err := resolveOutput(output, ".", bundleBaseName+formatExtensions[formatText]); err == nil {
+			opts.OutputDir, opts.OutputName = outputFile(target)
+		}
+	}
+
+	// Without explicit files or include patterns everything is included
+	if len(opts.ExplicitFiles) == 0 && len(opts.IncludePatterns) == 0 {
+		opts.IncludePatterns = []string{"**/*"}
+	}
+	return opts
+}
+
+// selectEntries returns the entries selected by opts, with paths relative to the root directory,
+// using the same rules as the bundle command.
+func selectEntries(opts BundleOptions) ([]files.Entry, error) {
+	if _, err := os.Stat(opts.RootDir); err != nil {
+		return nil, fmt.Errorf("error accessing directory %q: %w", opts.RootDir, err)
+	}
+	if len(opts.ExplicitFiles) > 0 {
+		opts.ExplicitFiles = files.ResolveExplicitFiles(opts.RootDir, opts.ExplicitFiles)
+		if err := files.ValidateExplicitFiles(opts.ExplicitFiles); err != nil {
+			return nil, err
+		}
+	}
+	opts.ExcludePatterns = excludePatternsWithDefaults(opts)
+	if opts.SafeMode && len(opts.SafeExtensions) == 0 {
+		opts.SafeExtensions = safeModeExtensions
+	}
+
+	sel, err := newSelection(opts)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := files.SelectEntries(opts.RootDir, sel)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file paths: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,140 @@
+// Description: This file contains the splitting of bundles into parts with --split-tokens.
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/budget"
+	"github.com/devinbarry/crev/internal/formatting"
+)
+
+// partMarker is inserted before the extensions of the bundle file in the names of its parts, e.g.
+// crev-project.part1.txt.
+const partMarker = ".part"
+
+// validateSplit checks that the options can be used to split the bundle into parts.
+func validateSplit(opts BundleOptions) error {
+	if opts.Stdout || opts.Format == formatNDJSON || opts.Index || opts.Append || opts.Keep > 0 || opts.Gist || opts.Open {
+		return fmt.Errorf("--split-tokens cannot be used with --stdout, --format %s, --index, --append, --keep, --gist or --open", formatNDJSON)
+	}
+	return nil
+}
+
+// partTarget returns the file of the 1-based part of the bundle saved at outputTarget, e.g.
+// crev-project.part2.txt.gz for crev-project.txt.gz.
+func partTarget(outputTarget string, part int) string {
+	stem, ext := splitBundleName(filepath.Base(outputTarget))
+	return filepath.Join(filepath.Dir(outputTarget), stem+partMarker+strconv.Itoa(part)+ext)
+}
+
+// bundlePart returns the part of the bundle with the given files. Every part has the project tree
+// and the custom sections, the symbol index and the import graph of all files are only in the
+// first part.
+func bundlePart(bundle *formatting.Bundle, partFiles []formatting.FileEntry, first bool) *formatting.Bundle {
+	part := *bundle
+	part.Files = partFiles
+	part.Stats = formatting.Stats{FileCount: len(partFiles)}
+	for _, file := range partFiles {
+		part.Stats.TotalBytes += len(file.Content)
+	}
+	if !first {
+		part.Symbols, part.Imports = "", ""
+	}
+	return &part
+}
+
+// splitBundle distributes the files of the bundle over as few parts as needed for each part to
+// fit in maxTokens, in path order. A file is never split across parts, it is an error if a file
+// does not fit in a part on its own.
+func splitBundle(bundle *formatting.Bundle, maxTokens int, formatter formatting.Formatter) ([]*formatting.Bundle, error) {
+	maxBytes := budget.Bytes(maxTokens)
+	var parts []*formatting.Bundle
+	var current []formatting.FileEntry
+	for _, file := range bundle.Files {
+		// Each candidate part is rendered to measure it, which is bounded by the size of a part
+		candidate := append(slices.Clip(current), file)
+		if renderedSize(bundlePart(bundle, candidate, len(parts) == 0), formatter) <= maxBytes {
+			current = candidate
+			continue
+		}
+		if len(current) > 0 {
+			parts = append(parts, bundlePart(bundle, current, len(parts) == 0))
+			current = []formatting.FileEntry{file}
+			if renderedSize(bundlePart(bundle, current, len(parts) == 0), formatter) <= maxBytes {
+				continue
+			}
+		}
+		return nil, fmt.Errorf("file %s does not fit in a part of %d tokens together with the project tree, "+
+			"raise --split-tokens or omit it with --max-file-size", file.Path, maxTokens)
+	}
+	if len(current) > 0 || len(parts) == 0 {
+		part := bundlePart(bundle, current, len(parts) == 0)
+		if renderedSize(part, formatter) > maxBytes {
+			return nil, fmt.Errorf("the project tree and sections do not fit in a part of %d tokens, raise --split-tokens", maxTokens)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// writeParts splits the bundle into parts of at most opts.SplitTokens tokens, see splitBundle,
+// and saves them next to outputTarget, see partTarget. Parts of an earlier run that are not
+// written again are removed. It returns the written files and their total size.
+func writeParts(logger *slog.Logger, bundle *formatting.Bundle, outputTarget string, opts BundleOptions) ([]string, int, error) {
+	formatter := bundleFormatter(opts.Format)
+	parts, err := splitBundle(bundle, opts.SplitTokens, formatter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	targets := make([]string, 0, len(parts))
+	written := 0
+	for i, part := range parts {
+		target := partTarget(outputTarget, i+1)
+		n, err := writePart(target, part, formatter, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		targets = append(targets, target)
+		written += n
+	}
+
+	// A smaller bundle has fewer parts, the remaining ones would be mistaken for a part of it
+	for i := len(parts) + 1; ; i++ {
+		if err := os.Remove(partTarget(outputTarget, i)); err != nil {
+			if os.IsNotExist(err) {
+				break
+			}
+			return nil, 0, fmt.Errorf("error removing stale part: %w", err)
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Split the bundle into %d parts of at most %d tokens: %s", len(parts), opts.SplitTokens, strings.Join(targets, ", ")),
+		"phase", "write", "split_tokens", opts.SplitTokens, "parts", targets)
+	return targets, written, nil
+}
+
+// writePart saves a part of the bundle to target and returns the number of bytes written.
+func writePart(target string, part *formatting.Bundle, formatter formatting.Formatter, opts BundleOptions) (written int, err error) {
+	sink, err := openOutput(target, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if closeErr := sink.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing output: %w", closeErr)
+		}
+	}()
+
+	counter := &countingWriter{w: sink}
+	if err := formatter.Write(counter, part); err != nil {
+		return 0, fmt.Errorf("error saving file: %w", err)
+	}
+	return counter.n, nil
+}
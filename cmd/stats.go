@@ -0,0 +1,78 @@
+// Description: This file implements the "stats" command, which breaks down the tokens of the files that would be bundled.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/stats"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [path]",
+	Short: "Print the lines, size and estimated tokens of the files that would be bundled",
+	Long: `Print the lines, size and estimated tokens of the files that "crev bundle" would include, per
+file and aggregated per directory, sorted by decreasing tokens, using the same file selection
+rules and the include/exclude patterns of the config file.
+
+The largest directories and files are the candidates to exclude before the bundle fills the
+context window. Tokens are estimated as 1 token per 3 bytes, like for --max-tokens.
+
+Example usage:
+  # Show the 20 largest directories and files
+  crev stats
+
+  # Show all directories and files of the src directory
+  crev stats --include='src/**' --top 0
+
+  # Print the breakdown as JSON
+  crev stats --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		top, _ := cmd.Flags().GetInt("top")
+		if top < 0 {
+			return fmt.Errorf("invalid top %d: must be 0 (all) or more", top)
+		}
+		asJSON, _ := cmd.Flags().GetBool("json")
+		return Stats(cmd.OutOrStdout(), selectionOptions(cmd, args), top, asJSON)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	addStatsFlags(statsCmd)
+}
+
+// addStatsFlags adds the stats flags to cmd.
+func addStatsFlags(cmd *cobra.Command) {
+	addSelectionFlags(cmd)
+	cmd.Flags().Int("top", 20, "Only show this many of the largest directories and files (0: all)")
+	cmd.Flags().Bool("json", false, "Print all directories and files as JSON")
+}
+
+// Stats reads the files selected by opts and writes their lines, size and estimated tokens per
+// file and per directory to w, as tables of the top largest ones or as JSON.
+func Stats(w io.Writer, opts BundleOptions, top int, asJSON bool) error {
+	entries, err := selectEntries(opts)
+	if err != nil {
+		return err
+	}
+	// Empty directories are selected for the project tree, but only files are counted
+	entries = slices.DeleteFunc(entries, func(entry files.Entry) bool { return entry.IsDir })
+	fileContentMap, err := files.GetContentMapOfEntriesFS(files.HostFS(opts.RootDir), entries, opts.MaxConcurrency)
+	if err != nil {
+		return fmt.Errorf("error getting file contents: %w", err)
+	}
+
+	r := stats.New(fileContentMap)
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	return r.Write(w, top)
+}
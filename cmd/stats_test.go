@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/stats"
+	"github.com/stretchr/testify/require"
+)
+
+// executeStatsCmd runs the stats command with fresh flags and returns its output
+func (env *testEnv) executeStatsCmd(args ...string) (string, error) {
+	statsCmd.ResetFlags()
+	addStatsFlags(statsCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	env.t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs(append([]string{"stats"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// TestStatsCmd tests the breakdown of the selected files per file and per directory
+func TestStatsCmd(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":      "package main\n",
+		"src/gen/types.go": strings.Repeat("type T int\n", 30),
+		"docs/readme.md":   "# Readme\n",
+		"logo.png":         "png",
+	})
+
+	out, err := env.executeStatsCmd(".", "--exclude", "docs", "--top", "1")
+	require.NoError(t, err)
+	require.Equal(t, `Directories:
+    TOKENS       SIZE    LINES  PATH
+       115      343 B       31  src/
+  ... and 1 more
+
+Files:
+    TOKENS       SIZE    LINES  PATH
+       110      330 B       30  src/gen/types.go
+  ... and 1 more
+
+Total: 2 files, 31 lines, 343 B, about 115 tokens
+`, out)
+
+	out, err = env.executeStatsCmd(".", "--json")
+	require.NoError(t, err)
+	var r stats.Report
+	require.NoError(t, json.Unmarshal([]byte(out), &r))
+	require.Len(t, r.Files, 3)
+	require.Equal(t, stats.Stat{Path: "src", Files: 2, Lines: 31, Bytes: 343, Tokens: 115}, r.Dirs[0])
+
+	_, err = env.executeStatsCmd(".", "--top", "-1")
+	env.assertErrorContains(err, "invalid top -1")
+}
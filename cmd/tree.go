@@ -0,0 +1,130 @@
+// Description: This file implements the "tree" command, which prints the project structure crev would bundle.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree [path]",
+	Short: "Print the project structure that would be bundled",
+	Long: `Print the project structure that "crev bundle" would include, using the same file selection
+rules and the include/exclude patterns of the config file.
+
+The structure can be printed as nested JSON or YAML including the size of each file and
+directory, so editor plugins and scripts can consume it.
+
+Example usage:
+  # Print the project tree
+  crev tree
+
+  # Print the tree of the src directory as JSON
+  crev tree --include='src/**' --json
+
+  # Print the tree of another project as YAML
+  crev tree /path/to/project --yaml
+
+  # Only show the first two levels of the tree
+  crev tree --depth 2
+
+  # Start the tree at a root node, like the output of the tree command
+  crev tree --tree-label .`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := selectionOptions(cmd, args)
+		opts.TreeDepth = viper.GetInt("depth")
+		if cmd.Flags().Changed("depth") {
+			opts.TreeDepth, _ = cmd.Flags().GetInt("depth")
+		}
+		opts.TreeLabel = viper.GetString("tree-label")
+		if cmd.Flags().Changed("tree-label") {
+			opts.TreeLabel, _ = cmd.Flags().GetString("tree-label")
+		}
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+		asYAML, _ := cmd.Flags().GetBool("yaml")
+		if asJSON && asYAML {
+			return fmt.Errorf("--json and --yaml cannot be used together")
+		}
+		format := "text"
+		if asJSON {
+			format = "json"
+		} else if asYAML {
+			format = "yaml"
+		}
+
+		return Tree(cmd.OutOrStdout(), opts, format)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+	addTreeFlags(treeCmd)
+}
+
+// addTreeFlags adds the tree flags to cmd.
+func addTreeFlags(cmd *cobra.Command) {
+	addSelectionFlags(cmd)
+	cmd.Flags().Int("depth", 0, "Only show this many levels of the tree, collapsing deeper directories")
+	cmd.Flags().String("tree-label", "", "Label of the root node of the tree, e.g. the project name or '.'")
+	cmd.Flags().Bool("json", false, "Print the tree as nested JSON including sizes")
+	cmd.Flags().Bool("yaml", false, "Print the tree as nested YAML including sizes")
+}
+
+// Tree writes the structure of the files selected by opts to w, in the text, json or yaml format.
+func Tree(w io.Writer, opts BundleOptions, format string) error {
+	entries, err := selectEntries(opts)
+	if err != nil {
+		return err
+	}
+	filePaths := files.EntryPaths(entries)
+
+	if format == "text" {
+		_, err := io.WriteString(w, formatting.GenerateLabeledPathTree(filePaths, opts.TreeDepth, opts.TreeLabel))
+		return err
+	}
+
+	// Look up the sizes of the regular files for the machine readable formats
+	sizes := make(map[string]int64, len(entries))
+	for _, entry := range entries {
+		if entry.Mode.IsRegular() {
+			sizes[entry.Path] = entry.Size
+		}
+	}
+
+	absRootDir, err := filepath.Abs(opts.RootDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path %q: %w", opts.RootDir, err)
+	}
+	rootName := filepath.Base(absRootDir)
+	if opts.TreeLabel != "" {
+		rootName = opts.TreeLabel
+	}
+	tree := formatting.BuildTree(rootName, filePaths, sizes)
+	tree.Collapse(opts.TreeDepth)
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tree)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(tree); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unsupported tree format %q", format)
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// executeTreeCmd runs the tree command with fresh flags and returns its output
+func (env *testEnv) executeTreeCmd(args ...string) (string, error) {
+	treeCmd.ResetFlags()
+	addTreeFlags(treeCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	env.t.Cleanup(func() { rootCmd.SetOut(nil) })
+	rootCmd.SetArgs(append([]string{"tree"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// TestTreeCmd tests that the tree command prints the selected project structure
+func TestTreeCmd(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":    "package main",
+		"docs/readme.md": "# Readme",
+		"logo.png":       "png",
+	})
+
+	out, err := env.executeTreeCmd(".", "--exclude", "docs")
+	require.NoError(t, err)
+	require.Equal(t, "└── src (1 file)\n    └── main.go\n", out)
+}
+
+// TestTreeCmdLabel tests starting the tree at a labeled root node, from the flag or the config
+func TestTreeCmdLabel(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go": "package main",
+		"go.work":     "go 1.23",
+	})
+
+	out, err := env.executeTreeCmd(".", "--tree-label", ".")
+	require.NoError(t, err)
+	require.Equal(t, ". (2 files)\n├── go.work\n└── src (1 file)\n    └── main.go\n", out)
+
+	env.setupConfig("tree-label: my-project\n")
+	out, err = env.executeTreeCmd(".", "--depth", "1")
+	require.NoError(t, err)
+	require.Equal(t, "my-project (2 files)\n├── go.work\n└── src (1 file)\n    └── … (1 file)\n", out)
+
+	out, err = env.executeTreeCmd(".", "--json")
+	require.NoError(t, err)
+	var tree formatting.TreeNode
+	require.NoError(t, json.Unmarshal([]byte(out), &tree))
+	require.Equal(t, "my-project", tree.Name)
+}
+
+// TestTreeCmdJSON tests the nested JSON output with file and directory sizes
+func TestTreeCmdJSON(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":     "package main",
+		"src/util/str.go": "package util",
+		"go.work":         "go 1.23",
+	})
+
+	out, err := env.executeTreeCmd(".", "--json")
+	require.NoError(t, err)
+
+	var tree formatting.TreeNode
+	require.NoError(t, json.Unmarshal([]byte(out), &tree))
+	require.Equal(t, formatting.TreeNodeDir, tree.Type)
+	require.EqualValues(t, 31, tree.Size)
+	require.Len(t, tree.Children, 2)
+	require.Equal(t, formatting.TreeNode{Name: "go.work", Path: "go.work", Type: formatting.TreeNodeFile, Size: 7}, *tree.Children[0])
+
+	src := tree.Children[1]
+	require.Equal(t, "src", src.Path)
+	require.Equal(t, formatting.TreeNodeDir, src.Type)
+	require.EqualValues(t, 24, src.Size)
+	require.Equal(t, "src/util/str.go", src.Children[1].Children[0].Path)
+}
+
+// TestTreeCmdYAML tests the nested YAML output and the config include patterns
+func TestTreeCmdYAML(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":  "package main",
+		"lib/utils.go": "package lib",
+	})
+	env.setupConfig(`
+include:
+  - "src/**"
+`)
+
+	out, err := env.executeTreeCmd("--yaml")
+	require.NoError(t, err)
+
+	var tree formatting.TreeNode
+	require.NoError(t, yaml.Unmarshal([]byte(out), &tree))
+	require.Len(t, tree.Children, 1)
+	require.Equal(t, "src", tree.Children[0].Name)
+	require.EqualValues(t, 12, tree.Size)
+}
+
+// TestTreeCmdConflictingFormats tests that only one machine readable format can be requested
+func TestTreeCmdConflictingFormats(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	_, err := env.executeTreeCmd("--json", "--yaml")
+	env.assertErrorContains(err, "--json and --yaml cannot be used together")
+}
+
+// TestTreeCmdDepth tests that --depth collapses deeper directories in the tree command and the bundle header
+func TestTreeCmdDepth(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{
+		"src/main.go":     "package main",
+		"src/util/str.go": "package util",
+	})
+
+	out, err := env.executeTreeCmd("--depth", "1")
+	require.NoError(t, err)
+	require.Equal(t, "└── src (2 files)\n    └── … (2 files)\n", out)
+
+	err = env.executeBundleCmd(".", "--depth", "2")
+	require.NoError(t, err)
+	env.assertFileContents("crev-project.txt", []string{"    └── util (1 file)\n        └── … (1 file)", "File: \nsrc/util/str.go"}, nil)
+}
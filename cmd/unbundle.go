@@ -0,0 +1,128 @@
+// Description: This file implements the "unbundle" command, which extracts the files of a bundle back into a directory.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/spf13/cobra"
+)
+
+var unbundleCmd = &cobra.Command{
+	Use:   "unbundle <bundle>",
+	Short: "Extract the files of a bundle into a directory",
+	Long: `Extract the files of a bundle created by "crev bundle" into a directory.
+
+Compressed (.gz, .zst) and encrypted (.age) bundles are detected by their file extension.
+Encrypted bundles require the age identity file of one of their recipients.
+
+Example usage:
+  # Extract a bundle into the current directory
+  crev unbundle crev-project.txt
+
+  # Decrypt and extract a bundle into another directory
+  crev unbundle crev-project.txt.gz.age --identity key.txt --output-dir ./extracted`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir, _ := cmd.Flags().GetString("output-dir")
+		identityFile, _ := cmd.Flags().GetString("identity")
+		force, _ := cmd.Flags().GetBool("force")
+		return Unbundle(args[0], outputDir, identityFile, force)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unbundleCmd)
+
+	unbundleCmd.Flags().StringP("output-dir", "o", ".", "Directory to extract the files into")
+	unbundleCmd.Flags().StringP("identity", "i", "", "age identity file used to decrypt .age bundles")
+	unbundleCmd.Flags().Bool("force", false, "Overwrite files that already exist")
+}
+
+// Unbundle extracts the files of bundleFile into outputDir
+func Unbundle(bundleFile, outputDir, identityFile string, force bool) error {
+	bundle, err := readBundle(bundleFile, identityFile)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range bundle.Files {
+		if err := extractFile(outputDir, file, force); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Extracted %d files to: %s", len(bundle.Files), outputDir)
+	return nil
+}
+
+// readBundle reads and parses bundleFile, decrypting it with identityFile and decompressing it
+// according to its extensions.
+func readBundle(bundleFile, identityFile string) (*formatting.Bundle, error) {
+	f, err := os.Open(bundleFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+
+	// Decrypt the bundle if needed
+	var r io.Reader = f
+	name := bundleFile
+	if strings.HasSuffix(name, files.EncryptionExtension) {
+		if identityFile == "" {
+			return nil, fmt.Errorf("bundle %s is encrypted, please specify an identity file with --identity", bundleFile)
+		}
+		r, err = files.NewDecryptedReader(r, identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt bundle: %w", err)
+		}
+		name = strings.TrimSuffix(name, files.EncryptionExtension)
+	}
+
+	// Decompress the bundle if needed
+	dr, err := files.NewDecompressedReader(r, files.CompressionFromExtension(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress bundle: %w", err)
+	}
+	defer dr.Close()
+
+	content, err := io.ReadAll(dr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	return formatting.ParseText(string(content))
+}
+
+// extractFile writes a single bundle entry below outputDir
+func extractFile(outputDir string, file formatting.FileEntry, force bool) error {
+	// Refuse paths that would escape the output directory
+	cleanPath := path.Clean(file.Path)
+	if path.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+		return fmt.Errorf("refusing to extract %q outside of the output directory", file.Path)
+	}
+	target := filepath.Join(outputDir, filepath.FromSlash(cleanPath))
+
+	// Empty directories are recorded with a placeholder instead of content
+	if file.Content == files.EmptyDirMarker {
+		return os.MkdirAll(target, 0755)
+	}
+
+	if _, err := os.Stat(target); err == nil && !force {
+		return fmt.Errorf("file %s already exists, use --force to overwrite it", target)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", target, err)
+	}
+	if err := os.WriteFile(target, []byte(file.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUnbundleRoundTrip tests that a bundle can be extracted back into the original files
+func TestUnbundleRoundTrip(t *testing.T) {
+	env := newTestEnv(t)
+	files := map[string]string{
+		"main.go":                "package main\n\nfunc main() {}\n",
+		"internal/util/util.go":  "package util",
+		"docs/guide/overview.md": "# Overview\n\nFile: \nnot a header\n",
+	}
+	env.createProjectStructure(files)
+
+	err := env.executeBundleCmd(".")
+	require.NoError(t, err)
+
+	outputDir := t.TempDir()
+	err = Unbundle("crev-project.txt", outputDir, "", false)
+	require.NoError(t, err)
+
+	for path, content := range files {
+		extracted, err := os.ReadFile(filepath.Join(outputDir, path))
+		require.NoError(t, err, "Expected %s to be extracted", path)
+		require.Equal(t, content, string(extracted))
+	}
+	env.assertLogContains("Extracted 3 files to:")
+}
+
+// TestUnbundleEncryptedCompressed tests that an encrypted and compressed bundle is decrypted with the identity file
+func TestUnbundleEncryptedCompressed(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600))
+
+	err = env.executeBundleCmd(".", "--compress", "gzip", "--encrypt-to", identity.Recipient().String())
+	require.NoError(t, err)
+	require.FileExists(t, "crev-project.txt.gz.age")
+
+	outputDir := t.TempDir()
+	err = Unbundle("crev-project.txt.gz.age", outputDir, "", false)
+	env.assertErrorContains(err, "please specify an identity file")
+
+	err = Unbundle("crev-project.txt.gz.age", outputDir, identityFile, false)
+	require.NoError(t, err)
+	extracted, err := os.ReadFile(filepath.Join(outputDir, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main", string(extracted))
+}
+
+// TestBundleInvalidRecipient tests that an invalid age recipient is rejected
+func TestBundleInvalidRecipient(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProjectStructure(map[string]string{"main.go": "package main"})
+
+	err := env.executeBundleCmd(".", "--encrypt-to", "not-a-recipient")
+	env.assertErrorContains(err, `invalid age recipient "not-a-recipient"`)
+}
+
+// TestUnbundleRefusesPathTraversal tests that entries outside the output directory are not extracted
+func TestUnbundleRefusesPathTraversal(t *testing.T) {
+	bundleFile := filepath.Join(t.TempDir(), "evil.txt")
+	content := "Project Directory Structure:\n└── x\n\n\nFile: \n../escape.txt\nContent: \npwned\n\n"
+	require.NoError(t, os.WriteFile(bundleFile, []byte(content), 0644))
+
+	err := Unbundle(bundleFile, t.TempDir(), "", false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "refusing to extract")
+}
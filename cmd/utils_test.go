@@ -40,21 +40,13 @@ func newTestEnv(t *testing.T) *testEnv {
 	// Reset viper completely
 	viper.Reset()
 
-	// Reset the command's flags
-	generateCmd.ResetFlags()
-
-	// Re-add the original flags
-	generateCmd.Flags().StringSliceP("files", "f", nil,
-		"Specify files to always include (overrides exclude patterns for these files)")
-	generateCmd.Flags().StringSliceP("include", "i", nil,
-		"Include files matching these glob patterns (e.g., 'src/**', '**/*.go')")
-	generateCmd.Flags().StringSliceP("exclude", "e", nil,
-		"Exclude files matching these glob patterns (except those specified by --files)")
-
-	// Re-bind flags to viper
-	viper.BindPFlag("files", generateCmd.Flags().Lookup("files"))
-	viper.BindPFlag("include", generateCmd.Flags().Lookup("include"))
-	viper.BindPFlag("exclude", generateCmd.Flags().Lookup("exclude"))
+	// Reset the command's flags and re-add the original flags bound to viper
+	bundleCmd.ResetFlags()
+	addBundleFlags(bundleCmd)
+
+	// Isolate the tests from the global config file and the cache of the user
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
 
 	// Create temporary directory
 	tempDir := t.TempDir()
@@ -98,16 +90,9 @@ func (env *testEnv) setupConfig(configContent string) {
 	// Reset viper completely
 	viper.Reset()
 
-	// Reset the command's flags
-	generateCmd.ResetFlags()
-
-	// Re-add the original flags
-	generateCmd.Flags().StringSliceP("files", "f", nil,
-		"Specify files to always include (overrides exclude patterns for these files)")
-	generateCmd.Flags().StringSliceP("include", "i", nil,
-		"Include files matching these glob patterns (e.g., 'src/**', '**/*.go')")
-	generateCmd.Flags().StringSliceP("exclude", "e", nil,
-		"Exclude files matching these glob patterns (except those specified by --files)")
+	// Reset the command's flags and re-add the original flags bound to viper
+	bundleCmd.ResetFlags()
+	addBundleFlags(bundleCmd)
 
 	// Create config file
 	configPath := filepath.Join(env.TempDir, ".crev-config.yaml")
@@ -118,11 +103,6 @@ func (env *testEnv) setupConfig(configContent string) {
 	viper.SetConfigType("yaml")
 	viper.SetConfigFile(configPath)
 
-	// Re-bind flags to viper
-	viper.BindPFlag("files", generateCmd.Flags().Lookup("files"))
-	viper.BindPFlag("include", generateCmd.Flags().Lookup("include"))
-	viper.BindPFlag("exclude", generateCmd.Flags().Lookup("exclude"))
-
 	// Read the config
 	err = viper.ReadInConfig()
 	require.NoError(env.t, err, "Failed to read config file")
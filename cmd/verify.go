@@ -0,0 +1,100 @@
+// Description: This file implements the "verify" command, which checks whether the files of a bundle changed since it was created.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [bundle]",
+	Short: "Check whether the files of a bundle changed since it was created",
+	Long: `Compare the checksums recorded in the index of a bundle with the current files and report the
+files that were changed or deleted since the bundle was created. The command fails if any file
+changed, so a stale bundle is not trusted in a review. Only the files of the bundle are checked,
+files added since it was created are not reported.
+
+The checksums are written to crev-project.index.json next to the bundle by "crev bundle --index",
+together with the directory the file paths are relative to, so verify can be run from anywhere.
+
+Example usage:
+  # Create a bundle with an index, then check later whether it is still up to date
+  crev bundle --index
+  crev verify crev-project.txt`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundleFile := bundleFileName
+		if len(args) > 0 {
+			bundleFile = args[0]
+		}
+		return Verify(cmd.OutOrStdout(), bundleFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// Verify compares the files recorded in the index of bundleFile with the current files, writes the
+// changed and deleted files to w and returns an error if there are any. Relative paths are resolved
+// against the directory recorded in the index, or the current directory for older indexes.
+func Verify(w io.Writer, bundleFile string) error {
+	if _, err := os.Stat(bundleFile); err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	indexFile := filepath.Join(filepath.Dir(bundleFile), indexFileName)
+	f, err := os.Open(indexFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no index found for %s, create the bundle with --index to verify it", bundleFile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer f.Close()
+	index, err := formatting.ReadIndex(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", indexFile, err)
+	}
+	if index.Bundle != filepath.Base(bundleFile) {
+		return fmt.Errorf("index %s belongs to %s, not %s", indexFile, index.Bundle, filepath.Base(bundleFile))
+	}
+
+	stale := 0
+	for _, entry := range index.Files {
+		if entry.SHA256 == "" {
+			return fmt.Errorf("index %s has no checksums, recreate the bundle with --index to verify it", indexFile)
+		}
+		path := filepath.FromSlash(entry.Path)
+		if index.Dir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(index.Dir, path)
+		}
+		// Empty directories are recorded with a placeholder instead of content
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			fmt.Fprintf(w, "deleted: %s\n", entry.Path)
+			stale++
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", entry.Path, err)
+		case files.Checksum(string(content)) != entry.SHA256:
+			fmt.Fprintf(w, "changed: %s\n", entry.Path)
+			stale++
+		}
+	}
+	if stale > 0 {
+		return fmt.Errorf("bundle %s is stale: %d of %d files changed since it was created", bundleFile, stale, len(index.Files))
+	}
+	fmt.Fprintf(w, "Bundle %s is up to date (%d files)\n", bundleFile, len(index.Files))
+	return nil
+}
@@ -0,0 +1,147 @@
+// Package budget trims the files of a bundle to fit a token budget, dropping or truncating the
+// files that matter least for a review first.
+package budget
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+)
+
+// BytesPerToken is the number of bytes per token of the upper token estimate, which the summary
+// shows as the higher end of its estimated range.
+const BytesPerToken = 3
+
+// Tokens returns the upper estimate of the number of tokens of n bytes.
+func Tokens(n int) int {
+	return (n + BytesPerToken - 1) / BytesPerToken
+}
+
+// Bytes returns the number of bytes that fit in tokens by the upper estimate.
+func Bytes(tokens int) int {
+	return tokens * BytesPerToken
+}
+
+// File is a file of a bundle that can be trimmed.
+type File struct {
+	// Path is the slash-separated path of the file.
+	Path string
+	// Size is the number of bytes the file adds to the bundle.
+	Size int
+	// Pinned files, e.g. the files given explicitly, are never trimmed.
+	Pinned bool
+}
+
+// lockFiles are dependency lock files, which are rarely useful in a review.
+var lockFiles = map[string]bool{
+	"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "poetry.lock": true,
+	"Cargo.lock": true, "Gemfile.lock": true, "composer.lock": true, "Pipfile.lock": true, "uv.lock": true,
+}
+
+// dataExtensions are the extensions of data and fixture files.
+var dataExtensions = map[string]bool{
+	".json": true, ".csv": true, ".tsv": true, ".xml": true, ".sql": true, ".svg": true, ".txt": true, ".log": true,
+}
+
+// Priority returns the priority of the file at the slash-separated path: files with a lower
+// priority are trimmed first. Source files have the highest priority, followed by docs, tests,
+// data files, generated and vendored files, and lock files. Deeper files have a slightly lower priority.
+func Priority(filePath string) int {
+	name := path.Base(filePath)
+	lower := strings.ToLower(filePath)
+	priority := 100
+	switch {
+	case lockFiles[name]:
+		priority = 10
+	case strings.HasPrefix(lower, "vendor/") || strings.Contains(lower, "/vendor/") ||
+		strings.Contains(lower, "third_party/") || strings.Contains(lower, "node_modules/"):
+		priority = 20
+	case strings.Contains(name, ".pb.") || strings.Contains(name, "_generated.") || strings.Contains(name, ".gen."):
+		priority = 30
+	case dataExtensions[strings.ToLower(path.Ext(name))]:
+		priority = 40
+	case files.IsTestFile(filePath):
+		priority = 60
+	case strings.HasPrefix(strings.ToLower(name), "readme") && !strings.Contains(filePath, "/"):
+		priority = 110
+	case files.Language(filePath) == "markdown" || strings.HasPrefix(lower, "docs/"):
+		priority = 70
+	}
+	return priority - 2*strings.Count(filePath, "/")
+}
+
+// trimOrder returns the files that can be trimmed, lowest priority and then largest first.
+func trimOrder(candidates []File) []File {
+	var order []File
+	for _, f := range candidates {
+		if !f.Pinned {
+			order = append(order, f)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		pi, pj := Priority(order[i].Path), Priority(order[j].Path)
+		if pi != pj {
+			return pi < pj
+		}
+		if order[i].Size != order[j].Size {
+			return order[i].Size > order[j].Size
+		}
+		return order[i].Path < order[j].Path
+	})
+	return order
+}
+
+// Drop returns the paths of the files to drop to remove at least excess bytes, lowest priority
+// first. It reports false if dropping all files that are not pinned is not enough.
+func Drop(candidates []File, excess int) ([]string, bool) {
+	var dropped []string
+	for _, f := range trimOrder(candidates) {
+		if excess <= 0 {
+			break
+		}
+		dropped = append(dropped, f.Path)
+		excess -= f.Size
+	}
+	return dropped, excess <= 0
+}
+
+// Truncate returns the sizes to cut the largest files to, keyed by path, so at least excess bytes
+// are removed: all files larger than a common limit are cut to it, so large files lose the most
+// and small files are kept whole. It reports false if the files that are not pinned are too small.
+func Truncate(candidates []File, excess int) (map[string]int, bool) {
+	order := trimOrder(candidates)
+	removed := func(limit int) int {
+		n := 0
+		for _, f := range order {
+			n += max(f.Size-limit, 0)
+		}
+		return n
+	}
+	largest := 0
+	for _, f := range order {
+		largest = max(largest, f.Size)
+	}
+	if removed(0) < excess {
+		return nil, false
+	}
+
+	// Find the largest limit removing enough bytes
+	low, high := 0, largest
+	for low < high {
+		mid := (low + high + 1) / 2
+		if removed(mid) >= excess {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	limits := make(map[string]int)
+	for _, f := range order {
+		if f.Size > low {
+			limits[f.Path] = low
+		}
+	}
+	return limits, true
+}
@@ -0,0 +1,202 @@
+// Package coverage reads test coverage reports, Go coverage profiles and lcov tracefiles, to show
+// the coverage of the bundled files.
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// File is the coverage of a single file.
+type File struct {
+	// Covered is the number of covered statements (Go) or lines (lcov) and Total the number of them.
+	Covered int
+	Total   int
+}
+
+// Percent returns the covered percentage of the file, 0 if it has nothing to cover.
+func (f File) Percent() float64 {
+	if f.Total == 0 {
+		return 0
+	}
+	return 100 * float64(f.Covered) / float64(f.Total)
+}
+
+// Report is the coverage of the files of a report, keyed by their slash-separated path as written
+// in the report, e.g. the import path of the package followed by the file name for Go.
+type Report map[string]File
+
+// ReadFile reads a coverage report, see Parse.
+func ReadFile(name string) (Report, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading coverage report: %w", err)
+	}
+	defer f.Close()
+	report, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing coverage report %s: %w", name, err)
+	}
+	return report, nil
+}
+
+// Parse parses a Go coverage profile, as written by "go test -coverprofile", or an lcov tracefile.
+// The format is detected from the first line, Go profiles start with "mode:".
+func Parse(r io.Reader) (Report, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("empty report")
+	}
+	first := strings.TrimSpace(scanner.Text())
+	if strings.HasPrefix(first, "mode:") {
+		return parseGoProfile(scanner)
+	}
+	return parseLcov(first, scanner)
+}
+
+// parseGoProfile parses the blocks of a Go coverage profile after the mode line. Blocks listed
+// several times, e.g. by profiles merged from several test binaries, are counted once and covered
+// if any of them is.
+func parseGoProfile(scanner *bufio.Scanner) (Report, error) {
+	type block struct {
+		statements int
+		covered    bool
+	}
+	blocks := make(map[string]map[string]block)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "mode:") {
+			continue
+		}
+		// file.go:startLine.startCol,endLine.endCol statements count
+		fields := strings.Fields(text)
+		colon := strings.LastIndex(text, ":")
+		if len(fields) != 3 || colon < 0 {
+			return nil, fmt.Errorf("line %d: invalid block %q", line+1, text)
+		}
+		statements, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("line %d: invalid block %q", line+1, text)
+		}
+		file, position := text[:colon], fields[0][colon:]
+		if blocks[file] == nil {
+			blocks[file] = make(map[string]block)
+		}
+		b := blocks[file][position]
+		blocks[file][position] = block{statements: statements, covered: b.covered || count > 0}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	report := make(Report, len(blocks))
+	for file, fileBlocks := range blocks {
+		var f File
+		for _, b := range fileBlocks {
+			f.Total += b.statements
+			if b.covered {
+				f.Covered += b.statements
+			}
+		}
+		report[file] = f
+	}
+	return report, nil
+}
+
+// parseLcov parses an lcov tracefile starting with first. The coverage of a file is taken from
+// its LH and LF records, or counted from its DA records if they are missing.
+func parseLcov(first string, scanner *bufio.Scanner) (Report, error) {
+	report := make(Report)
+	var file string
+	var f, counted File
+	hasTotals := false
+	for text, ok := first, true; ok; ok = scanner.Scan() {
+		if text == "" {
+			text = strings.TrimSpace(scanner.Text())
+		}
+		record, value, _ := strings.Cut(text, ":")
+		switch record {
+		case "SF":
+			file, f, counted, hasTotals = filepath.ToSlash(value), File{}, File{}, false
+		case "DA":
+			// DA:line,hits[,checksum]
+			parts := strings.Split(value, ",")
+			if len(parts) >= 2 {
+				counted.Total++
+				if hits, err := strconv.Atoi(parts[1]); err == nil && hits > 0 {
+					counted.Covered++
+				}
+			}
+		case "LF":
+			f.Total, _ = strconv.Atoi(value)
+			hasTotals = true
+		case "LH":
+			f.Covered, _ = strconv.Atoi(value)
+			hasTotals = true
+		case "end_of_record":
+			if file == "" {
+				return nil, fmt.Errorf("end_of_record without SF record")
+			}
+			if !hasTotals {
+				f = counted
+			}
+			previous := report[file]
+			report[file] = File{Covered: previous.Covered + f.Covered, Total: previous.Total + f.Total}
+			file = ""
+		}
+		text = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(report) == 0 {
+		return nil, fmt.Errorf("not a Go coverage profile or lcov tracefile")
+	}
+	return report, nil
+}
+
+// Match returns the coverage of the files at paths, slash-separated and relative to root, keyed
+// by path. A file of the report matches a path if it is the same path, relative to root or
+// absolute, or ends with "/" followed by the path, like the Go import path of the file. Paths
+// without coverage are left out.
+func (r Report) Match(root string, paths []string) map[string]File {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		absRoot = root
+	}
+	byPath := make(map[string]File, len(r))
+	for file, f := range r {
+		if filepath.IsAbs(filepath.FromSlash(file)) {
+			if rel, err := filepath.Rel(absRoot, filepath.FromSlash(file)); err == nil {
+				file = filepath.ToSlash(rel)
+			}
+		}
+		byPath[path.Clean(file)] = f
+	}
+
+	matched := make(map[string]File)
+	for _, p := range paths {
+		if f, ok := byPath[p]; ok {
+			matched[p] = f
+			continue
+		}
+		for file, f := range byPath {
+			if strings.HasSuffix(file, "/"+p) {
+				matched[p] = f
+				break
+			}
+		}
+	}
+	return matched
+}
@@ -0,0 +1,27 @@
+package files
+
+import (
+	"sort"
+	"strings"
+)
+
+// CaseCollisions returns the groups of paths that only differ by case, each sorted, which would
+// overwrite each other on case-insensitive file systems like the default ones of macOS and Windows.
+// The groups are sorted by their first path.
+func CaseCollisions(paths []string) [][]string {
+	byFolded := make(map[string][]string)
+	for _, p := range paths {
+		folded := strings.ToLower(p)
+		byFolded[folded] = append(byFolded[folded], p)
+	}
+
+	var collisions [][]string
+	for _, group := range byFolded {
+		if len(group) > 1 {
+			sort.Strings(group)
+			collisions = append(collisions, group)
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i][0] < collisions[j][0] })
+	return collisions
+}
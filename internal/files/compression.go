@@ -0,0 +1,137 @@
+// Contains code to compress output written to sinks.
+package files
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported compression algorithms
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// CompressionExtension returns the file extension appended to outputs compressed with algorithm.
+func CompressionExtension(algorithm string) (string, error) {
+	switch algorithm {
+	case CompressionNone:
+		return "", nil
+	case CompressionGzip:
+		return ".gz", nil
+	case CompressionZstd:
+		return ".zst", nil
+	default:
+		return "", fmt.Errorf("unsupported compression %q (supported: %s, %s)", algorithm, CompressionGzip, CompressionZstd)
+	}
+}
+
+// ValidateCompressionLevel checks that level is a valid level for algorithm, where 0 selects
+// the default level of the algorithm.
+func ValidateCompressionLevel(algorithm string, level int) error {
+	if level == 0 {
+		return nil
+	}
+	switch algorithm {
+	case CompressionGzip:
+		if level < gzip.BestSpeed || level > gzip.BestCompression {
+			return fmt.Errorf("invalid gzip compression level %d (supported: 1-9)", level)
+		}
+	case CompressionZstd:
+		if level < 1 || level > 22 {
+			return fmt.Errorf("invalid zstd compression level %d (supported: 1-22)", level)
+		}
+	default:
+		return fmt.Errorf("a compression level requires a compression algorithm")
+	}
+	return nil
+}
+
+// NewCompressedSink wraps sink so that everything written to it is compressed with algorithm.
+// A level of 0 selects the default level of the algorithm; otherwise it is the gzip level (1-9)
+// or the zstd level (1-22). Closing the returned writer flushes the compressor and closes the
+// underlying sink.
+func NewCompressedSink(sink io.WriteCloser, algorithm string, level int) (io.WriteCloser, error) {
+	if _, err := CompressionExtension(algorithm); err != nil {
+		return nil, err
+	}
+	if err := ValidateCompressionLevel(algorithm, level); err != nil {
+		return nil, err
+	}
+
+	switch algorithm {
+	case CompressionNone:
+		return sink, nil
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gz, err := gzip.NewWriterLevel(sink, level)
+		if err != nil {
+			return nil, err
+		}
+		return &wrappedSink{WriteCloser: gz, sink: sink}, nil
+	case CompressionZstd:
+		encoderLevel := zstd.SpeedDefault
+		if level != 0 {
+			encoderLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		zw, err := zstd.NewWriter(sink, zstd.WithEncoderLevel(encoderLevel))
+		if err != nil {
+			return nil, err
+		}
+		return &wrappedSink{WriteCloser: zw, sink: sink}, nil
+	}
+	return sink, nil
+}
+
+// NewDecompressedReader returns a reader that decompresses r, which was compressed with algorithm.
+func NewDecompressedReader(r io.Reader, algorithm string) (io.ReadCloser, error) {
+	switch algorithm {
+	case CompressionNone:
+		return io.NopCloser(r), nil
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		_, err := CompressionExtension(algorithm)
+		return nil, err
+	}
+}
+
+// CompressionFromExtension returns the compression algorithm of a file named name,
+// based on its extension.
+func CompressionFromExtension(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".gz"):
+		return CompressionGzip
+	case strings.HasSuffix(name, ".zst"):
+		return CompressionZstd
+	default:
+		return CompressionNone
+	}
+}
+
+// wrappedSink closes both a wrapping writer, such as a compressor or an encryptor, and the sink it writes to.
+type wrappedSink struct {
+	io.WriteCloser
+	sink io.WriteCloser
+}
+
+func (c *wrappedSink) Close() error {
+	if err := c.WriteCloser.Close(); err != nil {
+		c.sink.Close()
+		return err
+	}
+	return c.sink.Close()
+}
@@ -0,0 +1,50 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FilterByContent removes the files whose content does not match pattern from fileContentMap,
+// and returns filePaths without them and without the directories left without files.
+// Files in keep, or in a directory in keep, are never removed.
+func FilterByContent(filePaths []string, fileContentMap map[string]string, pattern *regexp.Regexp, keep []string) []string {
+	for filePath, content := range fileContentMap {
+		if !isKept(filePath, keep) && !pattern.MatchString(content) {
+			delete(fileContentMap, filePath)
+		}
+	}
+
+	// Keep the parent directories of the remaining files
+	dirs := make(map[string]bool)
+	for filePath := range fileContentMap {
+		for dir := path.Dir(filePath); dir != "." && dir != "/" && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	var filtered []string
+	for _, filePath := range filePaths {
+		if _, ok := fileContentMap[filePath]; ok || dirs[strings.TrimSuffix(filePath, "/")] {
+			filtered = append(filtered, filePath)
+		}
+	}
+	return filtered
+}
+
+// isKept reports whether filePath is one of the kept paths or inside a kept directory.
+func isKept(filePath string, keep []string) bool {
+	return slices.ContainsFunc(keep, func(kept string) bool {
+		return filePath == kept || strings.HasPrefix(filePath, strings.TrimSuffix(kept, "/")+"/")
+	})
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of content.
+func Checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+package files
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// DirRules are include and exclude patterns that only apply to the subtree of a directory,
+// like the rules of a nested .gitignore file. The patterns are relative to Dir.
+type DirRules struct {
+	// Dir is the slash-separated path of the directory relative to the root of the selection.
+	Dir             string
+	IncludePatterns []string
+	ExcludePatterns []string
+}
+
+// DirRulesFilter returns a FileFilter applying the rules to the files in their subtree.
+// A file is rejected if it matches an exclude pattern of any rules above it, or if it does not
+// match an include pattern of rules above it that have include patterns. Like the top level
+// exclude patterns, an exclude pattern also matches the files inside a matching directory.
+func DirRulesFilter(rules []DirRules) FileFilter {
+	// Compile the patterns once instead of for every file
+	type compiledRules struct {
+		prefix   string
+		includes globSet
+		excludes globSet
+	}
+	compiled := make([]compiledRules, len(rules))
+	for i, r := range rules {
+		var excludePatterns []string
+		for _, pattern := range r.ExcludePatterns {
+			if pattern = strings.TrimRight(pattern, "/\\"); pattern != "" {
+				excludePatterns = append(excludePatterns, pattern)
+			}
+		}
+		compiled[i] = compiledRules{
+			prefix:   path.Clean(r.Dir) + "/",
+			includes: compileGlobs(r.IncludePatterns),
+			excludes: compileGlobs(excludePatterns),
+		}
+	}
+
+	return func(relPath string, d fs.DirEntry) (bool, error) {
+		for _, r := range compiled {
+			subPath, ok := strings.CutPrefix(relPath, r.prefix)
+			if !ok {
+				continue
+			}
+
+			for dirPath := subPath; dirPath != "."; dirPath = path.Dir(dirPath) {
+				matched, _, err := r.excludes.match(dirPath)
+				if err != nil || matched {
+					return false, err
+				}
+			}
+
+			if len(r.includes) > 0 {
+				include, _, err := shouldIncludePath(subPath, r.includes)
+				if err != nil || !include {
+					return false, err
+				}
+			}
+		}
+		return true, nil
+	}
+}
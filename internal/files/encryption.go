@@ -0,0 +1,52 @@
+// Contains code to encrypt output written to sinks and decrypt it again.
+package files
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// EncryptionExtension is the file extension appended to encrypted outputs.
+const EncryptionExtension = ".age"
+
+// ParseRecipients parses age recipients (age1...) as given on the command line.
+func ParseRecipients(recipients []string) ([]age.Recipient, error) {
+	var parsed []age.Recipient
+	for _, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", recipient, err)
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// NewEncryptedSink wraps sink so that everything written to it is encrypted to recipients with age.
+// Closing the returned writer finishes the encryption and closes the underlying sink.
+func NewEncryptedSink(sink io.WriteCloser, recipients []age.Recipient) (io.WriteCloser, error) {
+	w, err := age.Encrypt(sink, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedSink{WriteCloser: w, sink: sink}, nil
+}
+
+// NewDecryptedReader returns a reader that decrypts r with the identities in identityFile.
+func NewDecryptedReader(r io.Reader, identityFile string) (io.Reader, error) {
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open identity file: %w", err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity file %s: %w", identityFile, err)
+	}
+	return age.Decrypt(r, identities...)
+}
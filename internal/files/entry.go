@@ -0,0 +1,52 @@
+package files
+
+import (
+	"io/fs"
+	"time"
+)
+
+// Entry is a file or directory selected by a Selection, with the metadata read while selecting it,
+// so callers do not need to stat the selected paths again.
+type Entry struct {
+	// Path is the slash-separated path relative to the root of the selection.
+	Path    string
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+	IsDir   bool
+}
+
+// newEntry returns the entry for path with the metadata of info.
+func newEntry(path string, info fs.FileInfo) Entry {
+	return Entry{
+		Path:    path,
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+}
+
+// walkEntry returns the entry for a path found while walking fsys. Symbolic links are followed,
+// like when the file is read. If the metadata cannot be read, only the path and type are set.
+func walkEntry(fsys fs.FS, relPath string, d fs.DirEntry) Entry {
+	if d.Type()&fs.ModeSymlink != 0 {
+		if info, err := fs.Stat(fsys, relPath); err == nil {
+			return newEntry(relPath, info)
+		}
+	}
+	info, err := d.Info()
+	if err != nil {
+		return Entry{Path: relPath, Mode: d.Type(), IsDir: d.IsDir()}
+	}
+	return newEntry(relPath, info)
+}
+
+// EntryPaths returns the paths of entries.
+func EntryPaths(entries []Entry) []string {
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		paths[i] = entry.Path
+	}
+	return paths
+}
@@ -0,0 +1,41 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoFilesSelected is returned when a selection selects no files.
+var ErrNoFilesSelected = errors.New("no files found to bundle")
+
+// ErrMissingExplicitFiles is matched by errors.Is for a MissingExplicitFilesError.
+var ErrMissingExplicitFiles = errors.New("explicit files do not exist")
+
+// ErrBadPattern is matched by errors.Is for a BadPatternError.
+var ErrBadPattern = errors.New("syntax error in pattern")
+
+// MissingExplicitFilesError is returned by ValidateExplicitFiles with the explicit files that do not exist.
+type MissingExplicitFilesError struct {
+	Paths []string
+}
+
+func (e *MissingExplicitFilesError) Error() string {
+	return fmt.Sprintf("the following files specified via --files do not exist: %v", e.Paths)
+}
+
+func (e *MissingExplicitFilesError) Is(target error) bool {
+	return target == ErrMissingExplicitFiles
+}
+
+// BadPatternError is returned when an include or exclude pattern is not a valid glob pattern.
+type BadPatternError struct {
+	Pattern string
+}
+
+func (e *BadPatternError) Error() string {
+	return fmt.Sprintf("invalid pattern %q: %v", e.Pattern, ErrBadPattern)
+}
+
+func (e *BadPatternError) Is(target error) bool {
+	return target == ErrBadPattern
+}
@@ -0,0 +1,38 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ResolveExplicitFiles resolves relative explicit files against root, so they can be given
+// relative to the bundled directory. Paths that do not exist below root are kept relative to the
+// working directory. The returned paths are relative to the working directory, like root.
+func ResolveExplicitFiles(root string, paths []string) []string {
+	resolved := make([]string, len(paths))
+	for i, path := range paths {
+		resolved[i] = path
+		if filepath.IsAbs(path) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, path)); err == nil {
+			resolved[i] = filepath.Join(root, path)
+		}
+	}
+	return resolved
+}
+
+// ValidateExplicitFiles checks that all explicit files exist, relative to the working directory,
+// and returns a *MissingExplicitFilesError listing those that do not.
+func ValidateExplicitFiles(paths []string) error {
+	var missing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingExplicitFilesError{Paths: missing}
+	}
+	return nil
+}
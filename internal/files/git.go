@@ -0,0 +1,134 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GitTrackedFiles returns the slash-separated paths, relative to root, of the files tracked by
+// the git repository containing root, as listed by "git ls-files".
+func GitTrackedFiles(root string) (map[string]bool, error) {
+	return gitFiles(root, "tracked", "ls-files", "-z")
+}
+
+// Git statuses of files supported by GitStatusFiles.
+const (
+	GitTracked   = "tracked"
+	GitModified  = "modified"
+	GitStaged    = "staged"
+	GitUntracked = "untracked"
+)
+
+// GitStatusFiles returns the slash-separated paths, relative to root, of the files with the given
+// git status: tracked, modified (changed in the working tree or staged), staged, or untracked
+// (not ignored by .gitignore).
+func GitStatusFiles(root, status string) (map[string]bool, error) {
+	switch status {
+	case GitTracked:
+		return GitTrackedFiles(root)
+	case GitModified:
+		modified, err := gitFiles(root, status, "ls-files", "-z", "--modified")
+		if err != nil {
+			return nil, err
+		}
+		staged, err := GitStatusFiles(root, GitStaged)
+		for file := range staged {
+			modified[file] = true
+		}
+		return modified, err
+	case GitStaged:
+		return gitFiles(root, status, "diff", "--cached", "--name-only", "--relative", "-z")
+	case GitUntracked:
+		return gitFiles(root, status, "ls-files", "-z", "--others", "--exclude-standard")
+	default:
+		return nil, fmt.Errorf("unsupported git status %q (supported: %s, %s, %s, %s)", status, GitTracked, GitModified, GitStaged, GitUntracked)
+	}
+}
+
+// gitRepoVariables are the environment variables pointing git at a repository, set for example
+// by git hooks. They are not passed on, so the repository is always discovered from the root
+// directory.
+var gitRepoVariables = []string{
+	"GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE", "GIT_COMMON_DIR", "GIT_PREFIX",
+	"GIT_OBJECT_DIRECTORY", "GIT_ALTERNATE_OBJECT_DIRECTORIES", "GIT_NAMESPACE",
+}
+
+// gitCommand returns the command running git in root with args. git discovers the repository
+// from root, including linked worktrees and submodules, whose .git is a file pointing to the
+// actual git directory.
+func gitCommand(root string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+	cmd.Env = slices.DeleteFunc(os.Environ(), func(variable string) bool {
+		name, _, _ := strings.Cut(variable, "=")
+		return slices.Contains(gitRepoVariables, name)
+	})
+	return cmd
+}
+
+// isBareRepository reports whether root is inside a bare repository, which has no working tree.
+func isBareRepository(root string) bool {
+	out, err := gitCommand(root, "rev-parse", "--is-bare-repository").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// gitFiles runs git in root with args and returns the NUL separated paths it prints.
+// status describes the listed files in errors. Bare repositories are rejected, as git lists no
+// files for them instead of failing.
+func gitFiles(root, status string, args ...string) (map[string]bool, error) {
+	if isBareRepository(root) {
+		return nil, fmt.Errorf("unable to list the files %s by git: %s is in a bare repository without working tree, bundle a worktree of it instead (git worktree add)", status, root)
+	}
+	cmd := gitCommand(root, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("unable to list the files %s by git: %s", status, msg)
+		}
+		return nil, fmt.Errorf("unable to list the files %s by git: %w", status, err)
+	}
+
+	files := make(map[string]bool)
+	for _, file := range strings.Split(string(out), "\x00") {
+		if file != "" {
+			files[file] = true
+		}
+	}
+	return files, nil
+}
+
+// GitLog returns the messages of the last n commits changing files below root, newest first.
+// Each commit starts with a line with its abbreviated hash and date, followed by its message
+// indented by two spaces.
+func GitLog(root string, n int) (string, error) {
+	cmd := gitCommand(root, "log", "-n", strconv.Itoa(n), "--date=short", "--format=%h %ad%n%w(0,2,2)%B", "--", ".")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("unable to list the commits by git: %s", msg)
+		}
+		return "", fmt.Errorf("unable to list the commits by git: %w", err)
+	}
+	// The indentation of empty lines of the messages is trailing whitespace
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// GitTrackedFilter returns a FileFilter that only selects the tracked files, see GitTrackedFiles.
+func GitTrackedFilter(tracked map[string]bool) FileFilter {
+	return func(relPath string, d fs.DirEntry) (bool, error) {
+		return tracked[relPath], nil
+	}
+}
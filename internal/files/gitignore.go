@@ -0,0 +1,56 @@
+package files
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AddToGitignore appends the names that are not listed yet to the .gitignore file of dir,
+// creating it if needed, and returns the added names. Names are matched against the lines of the
+// file with and without a leading "/".
+func AddToGitignore(dir string, names ...string) ([]string, error) {
+	path := filepath.Join(dir, ".gitignore")
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	listed := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		listed[strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "/")] = true
+	}
+
+	var added []string
+	var sb strings.Builder
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		sb.WriteString("\n")
+	}
+	for _, name := range names {
+		if listed[name] {
+			continue
+		}
+		listed[name] = true
+		added = append(added, name)
+		sb.WriteString(name + "\n")
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", path, err)
+	}
+	if _, err := f.WriteString(sb.String()); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error writing %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return added, nil
+}
@@ -0,0 +1,119 @@
+package files
+
+import (
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// globKind is the shape of a compiled glob pattern. Most patterns, like those of the ignore lists,
+// are a literal with a wildcard at one end and are matched with string operations. Other patterns
+// are matched with doublestar.
+type globKind int
+
+const (
+	// globGeneric is matched with doublestar.
+	globGeneric globKind = iota
+	// globLiteral ("go.mod") matches the literal path.
+	globLiteral
+	// globBaseName ("**/go.sum") matches paths whose last element is the literal.
+	globBaseName
+	// globBaseSuffix ("**/*.png") matches paths whose last element ends with the literal.
+	globBaseSuffix
+	// globBasePrefix ("**/.*") matches paths whose last element starts with the literal.
+	globBasePrefix
+	// globPrefix (".*", "src/gen*") matches paths starting with the literal without a "/" after it.
+	globPrefix
+	// globSubtree ("vendor/**") matches the literal path and all paths below it.
+	globSubtree
+)
+
+// glob is a compiled glob pattern.
+type glob struct {
+	pattern string
+	kind    globKind
+	literal string
+}
+
+// globSet is a list of glob patterns compiled once for matching many paths.
+type globSet []glob
+
+// compileGlobs compiles patterns, keeping their order.
+func compileGlobs(patterns []string) globSet {
+	globs := make(globSet, len(patterns))
+	for i, pattern := range patterns {
+		globs[i] = compileGlob(pattern)
+	}
+	return globs
+}
+
+// compileGlob determines the kind of a pattern. Patterns with wildcards or escapes anywhere else
+// than in the recognized positions are generic.
+func compileGlob(pattern string) glob {
+	g := glob{pattern: pattern, kind: globGeneric}
+	switch rest, ok := strings.CutPrefix(pattern, "**/"); {
+	case isLiteral(pattern):
+		g.kind, g.literal = globLiteral, pattern
+	case ok && isLiteral(rest) && !strings.Contains(rest, "/"):
+		g.kind, g.literal = globBaseName, rest
+	case ok && strings.HasPrefix(rest, "*") && isBaseLiteral(rest[1:]):
+		g.kind, g.literal = globBaseSuffix, rest[1:]
+	case ok && strings.HasSuffix(rest, "*") && isBaseLiteral(rest[:len(rest)-1]):
+		g.kind, g.literal = globBasePrefix, rest[:len(rest)-1]
+	case strings.HasSuffix(pattern, "/**") && isLiteral(pattern[:len(pattern)-3]) && len(pattern) > 3:
+		g.kind, g.literal = globSubtree, pattern[:len(pattern)-3]
+	case strings.HasSuffix(pattern, "*") && isLiteral(pattern[:len(pattern)-1]):
+		g.kind, g.literal = globPrefix, pattern[:len(pattern)-1]
+	}
+	return g
+}
+
+// isLiteral reports whether s contains no wildcards or escapes.
+func isLiteral(s string) bool {
+	return !strings.ContainsAny(s, `*?[]{}\`)
+}
+
+// isBaseLiteral reports whether s is a literal part of a single path element.
+func isBaseLiteral(s string) bool {
+	return isLiteral(s) && !strings.Contains(s, "/")
+}
+
+// match reports whether name matches the pattern.
+func (g glob) match(name string) (bool, error) {
+	switch g.kind {
+	case globLiteral:
+		return name == g.literal, nil
+	case globBaseName:
+		return path.Base(name) == g.literal, nil
+	case globBaseSuffix:
+		return strings.HasSuffix(path.Base(name), g.literal), nil
+	case globBasePrefix:
+		return strings.HasPrefix(path.Base(name), g.literal), nil
+	case globPrefix:
+		rest, ok := strings.CutPrefix(name, g.literal)
+		return ok && !strings.Contains(rest, "/"), nil
+	case globSubtree:
+		return name == g.literal || strings.HasPrefix(name, g.literal+"/"), nil
+	default:
+		matched, err := doublestar.Match(g.pattern, name)
+		if err != nil {
+			return false, &BadPatternError{Pattern: g.pattern}
+		}
+		return matched, nil
+	}
+}
+
+// match reports whether name matches any pattern of the set and returns the first matching pattern.
+func (s globSet) match(name string) (bool, string, error) {
+	for _, g := range s {
+		matched, err := g.match(name)
+		if err != nil {
+			return false, "", err
+		}
+		if matched {
+			return true, g.pattern, nil
+		}
+	}
+	return false, "", nil
+}
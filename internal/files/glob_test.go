@@ -0,0 +1,44 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompileGlob tests that compiled patterns match the same paths as doublestar.
+func TestCompileGlob(t *testing.T) {
+	patterns := []string{
+		"go.mod", "src/main.go", "**/go.sum", "**/*.png", "**/*", "**/.*", "**/test_*", ".*", "*",
+		"src/gen*", "vendor/**", "src/**", "**", "**/a/b", "src/*.go", "**/*.{js,ts}", "[ab].go",
+		`\*.go`, "src/**/*.go", "/**", "",
+	}
+	paths := []string{
+		"go.mod", "go.sum", "sub/go.sum", "go.sum/x", "image.png", "a/b/image.png", ".png", ".git",
+		"a/.git", "a/.git/config", "test_main.go", "a/test_x.go", "src", "src/main.go", "src/generated.go",
+		"src/gen/x.go", "srcgen", "vendor", "vendor/a/b.go", "vendorx", "a/b", "x/a/b", "x.js", "a/x.ts",
+		"a.go", "*.go", "src/a/b.go",
+	}
+
+	for _, pattern := range patterns {
+		g := compileGlob(pattern)
+		for _, p := range paths {
+			expected, err := doublestar.Match(pattern, p)
+			require.NoError(t, err)
+			matched, err := g.match(p)
+			require.NoError(t, err)
+			require.Equal(t, expected, matched, "pattern %q (kind %d), path %q", pattern, g.kind, p)
+		}
+	}
+
+	require.Equal(t, globBaseSuffix, compileGlob("**/*.png").kind)
+	require.Equal(t, globSubtree, compileGlob("vendor/**").kind)
+	require.Equal(t, globGeneric, compileGlob("src/**/*.go").kind)
+}
+
+// TestCompileGlobBadPattern tests that invalid patterns still return an error.
+func TestCompileGlobBadPattern(t *testing.T) {
+	_, _, err := compileGlobs([]string{"[a"}).match("a")
+	require.Error(t, err)
+}
@@ -1,115 +1,365 @@
 package files
 
 import (
-	"github.com/bmatcuk/doublestar/v4"
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
+// FileFilter decides whether a file that passed the include and exclude patterns is selected.
+// It is called with the slash-separated path relative to the root and the file's directory entry.
+// Filters are not applied to directories or to explicit files.
+type FileFilter func(relPath string, d fs.DirEntry) (bool, error)
+
+// Selection describes which files are selected from a directory tree.
+type Selection struct {
+	IncludePatterns []string
+	ExcludePatterns []string
+	ExplicitFiles   []string
+	Filters         []FileFilter
+	// DetectShebang matches extensionless scripts against the include patterns as if they had the
+	// extension of the language of their shebang line, e.g. "**/*.py" matches a "#!/usr/bin/env python" script.
+	DetectShebang bool
+	// Pragmas honors the crev:ignore and crev:include pragmas near the top of files, see FilePragma.
+	Pragmas bool
+	// MaxLines rejects the files with more lines, after the filters, 0 disables the limit.
+	// Like the filters, it does not apply to explicit files.
+	MaxLines int
+	// MaxFiles stops the selection with ErrTooManyFiles as soon as more files are selected, 0 disables the limit.
+	MaxFiles int
+	// Stats, if not nil, is filled with statistics about the selection.
+	Stats *SelectionStats
+}
+
+// ErrTooManyFiles is returned when a selection selects more files than Selection.MaxFiles.
+var ErrTooManyFiles = errors.New("too many files selected")
+
+// SelectionStats counts how many paths each include and exclude pattern matched during a selection,
+// and how many files were not selected.
+// Include patterns count the files they matched first; exclude patterns count the files and
+// directories they excluded first (an excluded directory is counted once, not its contents).
+type SelectionStats struct {
+	Include map[string]int
+	Exclude map[string]int
+	// NotIncluded is the number of files that did not match any include pattern.
+	NotIncluded int
+	// Filtered is the number of files that matched the patterns but were rejected by a filter.
+	Filtered int
+	// TooLong is the number of the filtered files that were rejected for exceeding Selection.MaxLines.
+	TooLong int
+
+	// excludeOrigins maps preprocessed exclude patterns back to the pattern they came from
+	excludeOrigins map[string]string
+}
+
+// Excluded returns the number of paths that were not selected. Excluded directories count once.
+func (s *SelectionStats) Excluded() int {
+	excluded := s.NotIncluded + s.Filtered
+	for _, count := range s.Exclude {
+		excluded += count
+	}
+	return excluded
+}
+
+// reset initializes all counts of sel to zero.
+func (s *SelectionStats) reset(fsys fs.FS, sel Selection) {
+	s.NotIncluded = 0
+	s.Filtered = 0
+	s.TooLong = 0
+	s.Include = make(map[string]int, len(sel.IncludePatterns))
+	s.Exclude = make(map[string]int, len(sel.ExcludePatterns))
+	s.excludeOrigins = make(map[string]string)
+	for _, pattern := range sel.IncludePatterns {
+		s.Include[pattern] = 0
+	}
+	for _, pattern := range sel.ExcludePatterns {
+		if pattern == "" {
+			continue
+		}
+		s.Exclude[pattern] = 0
+		for _, processed := range preprocessExcludePatterns(fsys, []string{pattern}) {
+			if _, exists := s.excludeOrigins[processed]; !exists {
+				s.excludeOrigins[processed] = pattern
+			}
+		}
+	}
+}
+
+func (s *SelectionStats) recordInclude(pattern string) {
+	if s != nil {
+		s.Include[pattern]++
+	}
+}
+
+func (s *SelectionStats) recordExclude(processedPattern string) {
+	if s != nil {
+		s.Exclude[s.excludeOrigins[processedPattern]]++
+	}
+}
+
+func (s *SelectionStats) recordNotIncluded() {
+	if s != nil {
+		s.NotIncluded++
+	}
+}
+
+func (s *SelectionStats) recordFiltered() {
+	if s != nil {
+		s.Filtered++
+	}
+}
+
+func (s *SelectionStats) recordTooLong() {
+	if s != nil {
+		s.TooLong++
+	}
+}
+
 // GetAllFilePaths returns all the file paths in the root directory and its subdirectories,
 // while respecting inclusion and exclusion patterns.
 // Explicit files (provided by --files flag) override any exclude patterns.
 func GetAllFilePaths(root string, includePatterns, excludePatterns, explicitFiles []string) ([]string, error) {
+	return Select(root, Selection{
+		IncludePatterns: includePatterns,
+		ExcludePatterns: excludePatterns,
+		ExplicitFiles:   explicitFiles,
+	})
+}
+
+// GetAllFilePathsFS returns all the file paths in fsys, while respecting inclusion and exclusion
+// patterns. Explicit files are slash-separated paths relative to the root of fsys and override
+// any exclude patterns. The returned paths are slash-separated and relative to the root of fsys.
+func GetAllFilePathsFS(fsys fs.FS, includePatterns, excludePatterns, explicitFiles []string) ([]string, error) {
+	return SelectFS(fsys, Selection{
+		IncludePatterns: includePatterns,
+		ExcludePatterns: excludePatterns,
+		ExplicitFiles:   explicitFiles,
+	})
+}
+
+// Select returns the paths in the root directory and its subdirectories selected by sel,
+// relative to root. Explicit files are given relative to the working directory.
+func Select(root string, sel Selection) ([]string, error) {
+	entries, err := SelectEntries(root, sel)
+	if err != nil {
+		return nil, err
+	}
+	return EntryPaths(entries), nil
+}
+
+// SelectEntries is like Select, but returns the selected paths with their metadata.
+func SelectEntries(root string, sel Selection) ([]Entry, error) {
 	// Normalize root path to absolute path
 	absRoot, err := filepath.Abs(root)
 	if err != nil {
 		return nil, err
 	}
 
-	processedExcludePatterns := preprocessExcludePatterns(absRoot, excludePatterns)
+	// Explicit files are given relative to the working directory. Those inside the root are
+	// resolved against it so they can be handled by the fs.FS walk, while those outside of
+	// it are passed straight through as paths relative to the root.
+	var insideRoot []string
+	var outsideRoot []Entry
+	for _, file := range sel.ExplicitFiles {
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			continue
+		}
+		relPath, err := filepath.Rel(absRoot, absPath)
+		if err != nil {
+			return nil, err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath == ".." || strings.HasPrefix(relPath, "../") {
+			if !info.IsDir() {
+				outsideRoot = append(outsideRoot, newEntry(relPath, info))
+				continue
+			}
+			dirEntries, err := explicitDirEntries(os.DirFS(absPath), ".")
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range dirEntries {
+				entry.Path = path.Join(relPath, entry.Path)
+				outsideRoot = append(outsideRoot, entry)
+			}
+		} else {
+			insideRoot = append(insideRoot, relPath)
+		}
+	}
+
+	sel.ExplicitFiles = insideRoot
+	entries, err := SelectEntriesFS(os.DirFS(absRoot), sel)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(outsideRoot, entries...), nil
+}
+
+// SelectFS returns the paths in fsys selected by sel. Explicit files are slash-separated paths
+// relative to the root of fsys and override any exclude patterns and filters. The returned paths
+// are slash-separated and relative to the root of fsys.
+func SelectFS(fsys fs.FS, sel Selection) ([]string, error) {
+	entries, err := SelectEntriesFS(fsys, sel)
+	if err != nil {
+		return nil, err
+	}
+	return EntryPaths(entries), nil
+}
+
+// SelectEntriesFS is like SelectFS, but returns the selected paths with their metadata.
+func SelectEntriesFS(fsys fs.FS, sel Selection) ([]Entry, error) {
+	processedExcludePatterns := preprocessExcludePatterns(fsys, sel.ExcludePatterns)
+	if sel.Stats != nil {
+		sel.Stats.reset(fsys, sel)
+	}
 
 	// Handle explicit files: add them to the results and keep track of them
-	filePaths, explicitPaths, err := collectExplicitFiles(explicitFiles)
+	explicitEntries, explicitPaths, err := collectExplicitFiles(fsys, sel.ExplicitFiles)
 	if err != nil {
 		return nil, err
 	}
 
+	// The line count is checked last, as it reads the files
+	filters := sel.Filters
+	if sel.MaxLines > 0 {
+		filters = append(slices.Clip(filters), lineCountFilter(fsys, sel.MaxLines, sel.Stats))
+	}
+
 	// Now walk the directory and handle non-explicit files
-	collectedPaths, err := walkAndCollectPaths(absRoot, includePatterns, processedExcludePatterns, explicitPaths, filePaths)
+	collectedEntries, err := walkAndCollectPaths(fsys, sel.IncludePatterns, processedExcludePatterns, filters, sel.DetectShebang, sel.Pragmas, sel.MaxFiles, sel.Stats, explicitPaths, explicitEntries)
 	if err != nil {
 		return nil, err
 	}
 
 	// Post-processing step:
 	// Remove any directories that do not contain any included (explicit or pattern-included) files
-	finalPaths := filterEmptyDirectories(collectedPaths)
+	return filterEmptyDirectoryEntries(collectedEntries), nil
+}
 
-	// Convert all paths to relative before returning
-	relativePaths := make([]string, 0, len(finalPaths))
-	for _, path := range finalPaths {
-		relPath, err := filepath.Rel(absRoot, path)
-		if err != nil {
-			return nil, err
-		}
-		relativePaths = append(relativePaths, filepath.ToSlash(relPath))
+// ExtensionAllowlistFilter returns a FileFilter that only selects files whose extension is in
+// allowed. Entries without a leading dot, such as "Makefile", are matched against the whole file name.
+// Matching is case-insensitive.
+func ExtensionAllowlistFilter(allowed []string) FileFilter {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, entry := range allowed {
+		allowedSet[strings.ToLower(entry)] = true
+	}
+	return func(relPath string, d fs.DirEntry) (bool, error) {
+		name := strings.ToLower(path.Base(relPath))
+		return allowedSet[name] || allowedSet[path.Ext(name)], nil
 	}
-
-	return relativePaths, nil
 }
 
 // collectExplicitFiles adds explicit files (those specified by --files) to the output list,
-// ensuring they exist and tracking them for later checks.
-func collectExplicitFiles(explicitFiles []string) (filePaths []string, explicitPaths map[string]bool, err error) {
+// ensuring they exist and tracking them for later checks. An explicit directory adds its whole
+// tree, so the files in it are explicit files as well.
+func collectExplicitFiles(fsys fs.FS, explicitFiles []string) (entries []Entry, explicitPaths map[string]bool, err error) {
 	explicitPaths = make(map[string]bool)
 
 	// First, add explicit files and track their paths
 	for _, file := range explicitFiles {
-		absPath, err := filepath.Abs(file)
+		cleanPath := path.Clean(filepath.ToSlash(file))
+		info, err := fs.Stat(fsys, cleanPath)
 		if err != nil {
-			return nil, nil, err
+			continue
 		}
-		if _, err := os.Stat(absPath); err == nil {
-			explicitPaths[absPath] = true
-			filePaths = append(filePaths, absPath)
+		fileEntries := []Entry{newEntry(cleanPath, info)}
+		if info.IsDir() {
+			if fileEntries, err = explicitDirEntries(fsys, cleanPath); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, entry := range fileEntries {
+			if !explicitPaths[entry.Path] {
+				explicitPaths[entry.Path] = true
+				entries = append(entries, entry)
+			}
 		}
 	}
 
-	return filePaths, explicitPaths, nil
+	return entries, explicitPaths, nil
+}
+
+// explicitDirEntries returns the entries of the explicit directory dir and everything below it,
+// regardless of the include and exclude patterns.
+func explicitDirEntries(fsys fs.FS, dir string) ([]Entry, error) {
+	var entries []Entry
+	err := fs.WalkDir(fsys, dir, func(relPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if relPath != "." {
+			entries = append(entries, walkEntry(fsys, relPath, d))
+		}
+		return nil
+	})
+	return entries, err
 }
 
-// walkAndCollectPaths walks the directory from absRoot, applying exclude patterns, include patterns,
-// and considering explicit files. It returns a full list of file paths that meet the criteria.
-func walkAndCollectPaths(absRoot string, includePatterns, processedExcludePatterns []string, explicitPaths map[string]bool, initialFiles []string) ([]string, error) {
-	filePaths := append([]string(nil), initialFiles...) // copy to avoid mutation
+// walkAndCollectPaths walks fsys from its root, applying exclude patterns, include patterns,
+// file filters, and considering explicit files. It returns a full list of entries that meet the criteria.
+// If pragmas is true, the pragmas of the files reached by the walk override the patterns and filters.
+// If maxFiles is positive, the walk stops with ErrTooManyFiles once more files are collected.
+func walkAndCollectPaths(fsys fs.FS, includePatterns, processedExcludePatterns []string, filters []FileFilter, detectShebang, pragmas bool, maxFiles int, stats *SelectionStats, explicitPaths map[string]bool, initialEntries []Entry) ([]Entry, error) {
+	entries := append([]Entry(nil), initialEntries...) // copy to avoid mutation
+	fileCount := 0
+	for _, entry := range entries {
+		if !entry.IsDir {
+			fileCount++
+		}
+	}
+	includes := compileGlobs(includePatterns)
+	excludes := newExcludeMatcher(processedExcludePatterns)
 	seenPaths := make(map[string]bool)
-	for _, path := range filePaths {
-		seenPaths[path] = true
+	for _, entry := range entries {
+		seenPaths[entry.Path] = true
 	}
 
-	err := filepath.WalkDir(absRoot, func(path string, d fs.DirEntry, err error) error {
+	err := fs.WalkDir(fsys, ".", func(relPath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		// Skip the root directory itself
-		if path == absRoot {
+		if relPath == "." {
 			return nil
 		}
 
 		// Skip if we've already seen this path (explicit files)
-		if seenPaths[path] {
+		if seenPaths[relPath] {
 			return nil
 		}
 
-		// Get path relative to root for pattern matching
-		relPath, err := filepath.Rel(absRoot, path)
+		// Determine if this path is excluded and if it's a parent of an explicit file
+		excluded, isParentOfExplicit, excludePattern, err := isExcludedPath(relPath, excludes, explicitPaths)
 		if err != nil {
 			return err
 		}
-		relPath = filepath.ToSlash(relPath) // Convert to forward slashes for consistent pattern matching
 
-		// Determine if this path is excluded and if it's a parent of an explicit file
-		excluded, isParentOfExplicit, err := isExcludedPath(absRoot, relPath, processedExcludePatterns, explicitPaths)
-		if err != nil {
-			return err
+		// Read the pragma of the file, which can pin it even if it is excluded
+		var pragma Pragma
+		if pragmas && !d.IsDir() {
+			if pragma, err = readPragma(fsys, relPath); err != nil {
+				return err
+			}
 		}
 
 		// If this directory (or file) is excluded and not a parent of an explicit file, skip it
-		if excluded && !isParentOfExplicit {
+		if excluded && !isParentOfExplicit && pragma != PragmaInclude {
+			stats.recordExclude(excludePattern)
 			if d.IsDir() {
-				return filepath.SkipDir
+				return fs.SkipDir
 			}
 			return nil
 		}
@@ -122,17 +372,62 @@ func walkAndCollectPaths(absRoot string, includePatterns, processedExcludePatter
 		}
 
 		// Check include patterns
-		include, err := shouldIncludePath(relPath, includePatterns)
+		include, includePattern, err := shouldIncludePath(relPath, includes)
 		if err != nil {
 			return err
 		}
+		if !include && !d.IsDir() && detectShebang {
+			scriptPath, err := shebangPath(fsys, relPath)
+			if err != nil {
+				return err
+			}
+			if scriptPath != "" {
+				include, includePattern, err = shouldIncludePath(scriptPath, includes)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if !d.IsDir() && pragma == "" {
+			if include {
+				stats.recordInclude(includePattern)
+			} else {
+				stats.recordNotIncluded()
+			}
+		}
+
+		// Pragmas override the patterns and filters
+		switch pragma {
+		case PragmaInclude:
+			include = true
+		case PragmaIgnore:
+			include = false
+			stats.recordFiltered()
+		}
+
+		// Apply the file filters to files that passed the patterns
+		if include && !d.IsDir() && pragma == "" {
+			include, err = applyFilters(relPath, d, filters)
+			if err != nil {
+				return err
+			}
+			if !include {
+				stats.recordFiltered()
+			}
+		}
 
 		// If we are including this path, add it to the results
 		// Note: We add directories that pass the include test. We will later remove empty directories
 		// that have no included files after we finish traversal.
 		if include {
-			filePaths = append(filePaths, path)
-			seenPaths[path] = true
+			entries = append(entries, walkEntry(fsys, relPath, d))
+			seenPaths[relPath] = true
+			if !d.IsDir() {
+				fileCount++
+				if maxFiles > 0 && fileCount > maxFiles {
+					return fmt.Errorf("%w: more than %d", ErrTooManyFiles, maxFiles)
+				}
+			}
 		}
 
 		return nil
@@ -142,83 +437,102 @@ func walkAndCollectPaths(absRoot string, includePatterns, processedExcludePatter
 		return nil, err
 	}
 
-	return filePaths, nil
+	return entries, nil
+}
+
+// applyFilters reports whether the file passes all filters.
+func applyFilters(relPath string, d fs.DirEntry, filters []FileFilter) (bool, error) {
+	for _, filter := range filters {
+		keep, err := filter(relPath, d)
+		if err != nil || !keep {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// excludeMatcher matches paths against the exclude patterns. The results for directories are
+// cached, as every parent directory of a path is checked again for each path below it.
+type excludeMatcher struct {
+	patterns globSet
+	dirs     map[string]string // matching pattern of a checked directory, "" if none
+}
+
+// newExcludeMatcher compiles the exclude patterns.
+func newExcludeMatcher(patterns []string) *excludeMatcher {
+	return &excludeMatcher{patterns: compileGlobs(patterns), dirs: make(map[string]string)}
+}
+
+// match reports whether name matches an exclude pattern and returns the pattern. If isDir is
+// true, the result is cached.
+func (m *excludeMatcher) match(name string, isDir bool) (bool, string, error) {
+	if isDir {
+		if pattern, ok := m.dirs[name]; ok {
+			return pattern != "", pattern, nil
+		}
+	}
+	matched, pattern, err := m.patterns.match(name)
+	if err != nil {
+		return false, "", err
+	}
+	if isDir {
+		m.dirs[name] = pattern
+	}
+	return matched, pattern, nil
 }
 
 // isExcludedPath checks if any parent directory of relPath (including itself) matches the exclude patterns.
-// It returns whether the path is excluded and whether it is a parent of an explicit file.
+// It returns whether the path is excluded, whether it is a parent of an explicit file and the matching pattern.
 //
 // If a directory is excluded but also a parent directory of an explicit file, we set isParentOfExplicit = true.
 // This allows traversal of the directory without adding it to the output, so that explicit files can be found.
-func isExcludedPath(absRoot, relPath string, processedExcludePatterns []string, explicitPaths map[string]bool) (bool, bool, error) {
+func isExcludedPath(relPath string, excludes *excludeMatcher, explicitPaths map[string]bool) (bool, bool, string, error) {
 	dirPath := relPath
-	excluded := false
-	isParentOfExplicit := false
 
 	for dirPath != "." {
-		for _, pattern := range processedExcludePatterns {
-			matched, err := doublestar.PathMatch(pattern, dirPath)
-			if err != nil {
-				return false, false, err
-			}
-			if matched {
-				excluded = true
-				// Check if this excluded directory is a parent of any explicit file
-				absDir := filepath.Join(absRoot, dirPath)
-				for explicit := range explicitPaths {
-					if strings.HasPrefix(explicit, absDir+string(os.PathSeparator)) {
-						isParentOfExplicit = true
-						break
-					}
-				}
-				if isParentOfExplicit {
-					// Even though it's excluded, it's a parent of explicit file
-					// We'll let traversal continue, but we won't add this directory to filePaths.
-					return excluded, isParentOfExplicit, nil
-				} else {
-					// This directory is excluded and not a parent of any explicit file.
-					// We can return now knowing it's excluded without explicit override.
-					return excluded, isParentOfExplicit, nil
+		matched, pattern, err := excludes.match(dirPath, dirPath != relPath)
+		if err != nil {
+			return false, false, "", err
+		}
+		if matched {
+			// Check if this excluded directory is a parent of any explicit file.
+			// Even though it's excluded, traversal of such a directory continues,
+			// but it won't be added to filePaths.
+			for explicit := range explicitPaths {
+				if strings.HasPrefix(explicit, dirPath+"/") {
+					return true, true, pattern, nil
 				}
 			}
+			// This directory is excluded and not a parent of any explicit file.
+			return true, false, pattern, nil
 		}
-		dirPath = filepath.Dir(dirPath)
+		dirPath = path.Dir(dirPath)
 	}
 
-	return excluded, isParentOfExplicit, nil
+	return false, false, "", nil
 }
 
 // shouldIncludePath checks whether a path should be included based on the provided includePatterns.
 // Previously, if no includePatterns were specified, we included all files by default.
 // According to the updated logic, if no include patterns are specified, we do not include any files.
 // Explicit files are handled separately by the calling logic (they bypass this check).
-func shouldIncludePath(relPath string, includePatterns []string) (bool, error) {
+// It also returns the first pattern that matched the path.
+func shouldIncludePath(relPath string, includePatterns globSet) (bool, string, error) {
 	// If no includePatterns are specified, do not include this path by default.
 	// The caller may still include this file explicitly, but that logic is handled elsewhere.
 	if len(includePatterns) == 0 {
-		return false, nil
+		return false, "", nil
 	}
 
 	// If include patterns are specified, we need to check if this path matches any of them.
-	for _, pattern := range includePatterns {
-		matched, err := doublestar.PathMatch(pattern, relPath)
-		if err != nil {
-			return false, err
-		}
-		if matched {
-			return true, nil
-		}
-	}
-
-	// If no pattern matched, do not include this file.
-	return false, nil
+	return includePatterns.match(relPath)
 }
 
 // preprocessExcludePatterns adjusts exclude patterns to handle directories and trailing slashes.
 // For directories, it adds both the directory itself and "/**" pattern to exclude all contents.
 // For files or non-existent paths, it uses the pattern as-is.
 // Empty patterns are skipped to avoid unintended matches.
-func preprocessExcludePatterns(root string, excludePatterns []string) []string {
+func preprocessExcludePatterns(fsys fs.FS, excludePatterns []string) []string {
 	var processedPatterns []string
 
 	for _, pattern := range excludePatterns {
@@ -231,8 +545,7 @@ func preprocessExcludePatterns(root string, excludePatterns []string) []string {
 		cleanPattern := strings.TrimRight(pattern, "/\\")
 
 		// Check if the pattern corresponds to an existing path
-		fullPath := filepath.Join(root, cleanPattern)
-		if info, err := os.Stat(fullPath); err == nil && info.IsDir() {
+		if info, err := fs.Stat(fsys, cleanPattern); err == nil && info.IsDir() {
 			// For directories, add both the directory pattern and its contents
 			processedPatterns = append(processedPatterns,
 				cleanPattern,       // Match the directory itself
@@ -247,59 +560,32 @@ func preprocessExcludePatterns(root string, excludePatterns []string) []string {
 	return processedPatterns
 }
 
-// filterEmptyDirectories removes directories from filePaths that do not contain any included file.
-// This ensures that directories with only excluded files are not listed.
-func filterEmptyDirectories(filePaths []string) []string {
-	// Normalize all filePaths to absolute paths to ensure consistent lookups
-	for i, p := range filePaths {
-		absPath, err := filepath.Abs(p)
-		if err == nil {
-			filePaths[i] = absPath
-		}
-	}
-
-	// Build a map of all paths for quick lookup
-	pathSet := make(map[string]bool, len(filePaths))
-	for _, p := range filePaths {
-		pathSet[p] = true
-	}
-
+// filterEmptyDirectoryEntries removes the directories that do not contain any included file from entries.
+// This ensures that directories with only excluded files are not listed. It only uses the metadata
+// collected during the walk, so no path is read again.
+func filterEmptyDirectoryEntries(entries []Entry) []Entry {
 	// Identify which directories have included files underneath
 	directoryHasIncludedFile := make(map[string]bool)
-	for _, p := range filePaths {
-		info, err := os.Stat(p)
-		if err != nil {
-			continue
-		}
-		if !info.IsDir() {
-			// Mark all parent directories as containing an included file
-			dir := filepath.Dir(p)
-			for dir != "." && dir != "/" {
+	for _, entry := range entries {
+		if !entry.IsDir {
+			// Mark all parent directories (up to and including the root) as containing an included file
+			for dir := path.Dir(entry.Path); ; dir = path.Dir(dir) {
 				directoryHasIncludedFile[dir] = true
-				dir = filepath.Dir(dir)
+				if dir == "." || dir == ".." || dir == "/" {
+					break
+				}
 			}
 		}
 	}
 
 	// Filter out directories that do not have any included files
-	var finalPaths []string
-	for _, p := range filePaths {
-		info, err := os.Stat(p)
-		if err != nil {
-			// If we can't stat it, just keep it (edge case)
-			finalPaths = append(finalPaths, p)
-			continue
-		}
-		if info.IsDir() {
-			// Only keep this directory if we know it leads to included files
-			if directoryHasIncludedFile[p] {
-				finalPaths = append(finalPaths, p)
-			}
-		} else {
-			// Files are always kept
-			finalPaths = append(finalPaths, p)
+	var finalEntries []Entry
+	for _, entry := range entries {
+		// Files are always kept, directories only if we know they lead to included files
+		if !entry.IsDir || directoryHasIncludedFile[entry.Path] {
+			finalEntries = append(finalEntries, entry)
 		}
 	}
 
-	return finalPaths
+	return finalEntries
 }
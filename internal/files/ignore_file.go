@@ -0,0 +1,188 @@
+package files
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// IgnoreFile is the list of patterns of an ignore file such as .dockerignore, relative to the
+// directory of the file. A path is ignored if the last pattern matching it or one of its parent
+// directories is not negated with "!". With the semantics of .gitignore files, a path in an
+// ignored directory stays ignored, as git does not descend into ignored directories.
+type IgnoreFile struct {
+	rules     []ignoreRule
+	gitignore bool
+}
+
+// ignoreRule is a pattern of an ignore file.
+type ignoreRule struct {
+	glob   glob
+	negate bool
+	// dirOnly only matches directories, from a pattern with a trailing "/" in a .gitignore file.
+	dirOnly bool
+}
+
+// CrevignoreFile is the name of the ignore file of a project, with the syntax of .gitignore files.
+const CrevignoreFile = ".crevignore"
+
+// ReadDockerignore reads the .dockerignore file in dir. It returns nil if there is none.
+func ReadDockerignore(dir string) (*IgnoreFile, error) {
+	return readIgnoreFile(dir, ".dockerignore", ParseDockerignore)
+}
+
+// ReadCrevignore reads the .crevignore file in dir. It returns nil if there is none.
+func ReadCrevignore(dir string) (*IgnoreFile, error) {
+	return readIgnoreFile(dir, CrevignoreFile, ParseGitignore)
+}
+
+// readIgnoreFile reads and parses the ignore file name in dir. It returns nil if there is none.
+func readIgnoreFile(dir, name string, parse func(content string) (*IgnoreFile, error)) (*IgnoreFile, error) {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	ignore, err := parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", name, err)
+	}
+	return ignore, nil
+}
+
+// ParseDockerignore parses the content of a .dockerignore file. Like in docker builds, patterns
+// are anchored at the directory of the file, "**" matches any number of directories, lines
+// starting with "#" are comments and "!" negates a pattern.
+func ParseDockerignore(content string) (*IgnoreFile, error) {
+	ignore := &IgnoreFile{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, negate := strings.CutPrefix(line, "!")
+		pattern = path.Clean(strings.TrimPrefix(strings.TrimSpace(pattern), "/"))
+		if pattern == "." {
+			continue
+		}
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, &BadPatternError{Pattern: pattern}
+		}
+		ignore.rules = append(ignore.rules, ignoreRule{glob: compileGlob(pattern), negate: negate})
+	}
+	return ignore, scanner.Err()
+}
+
+// ParseGitignore parses the content of an ignore file with the syntax of .gitignore files:
+// patterns with a "/" at the start or in the middle are anchored at the directory of the file,
+// other patterns match at any depth, a trailing "/" only matches directories, "**" matches any
+// number of directories, lines starting with "#" are comments, "!" negates a pattern and "\"
+// escapes a leading "#" or "!" and trailing spaces.
+func ParseGitignore(content string) (*IgnoreFile, error) {
+	ignore := &IgnoreFile{gitignore: true}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := trimUnescapedSpaces(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		line, rule.negate = strings.CutPrefix(line, "!")
+		if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		line, rule.dirOnly = strings.CutSuffix(line, "/")
+		if line == "" {
+			continue
+		}
+		pattern, anchored := strings.CutPrefix(line, "/")
+		if !anchored && !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, &BadPatternError{Pattern: pattern}
+		}
+		rule.glob = compileGlob(pattern)
+		ignore.rules = append(ignore.rules, rule)
+	}
+	return ignore, scanner.Err()
+}
+
+// trimUnescapedSpaces removes the trailing spaces of a line of a .gitignore file that are not
+// escaped with "\".
+func trimUnescapedSpaces(line string) string {
+	trimmed := strings.TrimRight(line, " \t\r")
+	if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) && line[len(trimmed)] == ' ' {
+		return trimmed[:len(trimmed)-1] + " "
+	}
+	return trimmed
+}
+
+// Ignored reports whether the slash-separated path relative to the directory of the file is ignored.
+func (f *IgnoreFile) Ignored(relPath string) (bool, error) {
+	if f.gitignore {
+		// Files in an ignored directory can not be re-included
+		dirs := strings.Split(relPath, "/")
+		for i := 1; i < len(dirs); i++ {
+			ignored, err := f.lastMatch(path.Join(dirs[:i]...), true)
+			if ignored || err != nil {
+				return ignored, err
+			}
+		}
+		return f.lastMatch(relPath, false)
+	}
+
+	ignored := false
+	for _, rule := range f.rules {
+		// Only a negated pattern can change the result of an ignored path, and vice versa
+		if rule.negate != ignored {
+			continue
+		}
+		for dirPath := relPath; dirPath != "."; dirPath = path.Dir(dirPath) {
+			matched, err := rule.glob.match(dirPath)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				ignored = !rule.negate
+				break
+			}
+		}
+	}
+	return ignored, nil
+}
+
+// lastMatch reports whether the last pattern matching the path itself is not negated.
+func (f *IgnoreFile) lastMatch(relPath string, isDir bool) (bool, error) {
+	ignored := false
+	for _, rule := range f.rules {
+		if rule.negate != ignored || (rule.dirOnly && !isDir) {
+			continue
+		}
+		matched, err := rule.glob.match(relPath)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored, nil
+}
+
+// Filter returns a FileFilter rejecting the files ignored by the file.
+func (f *IgnoreFile) Filter() FileFilter {
+	return func(relPath string, d fs.DirEntry) (bool, error) {
+		ignored, err := f.Ignored(relPath)
+		return !ignored, err
+	}
+}
@@ -0,0 +1,30 @@
+package files
+
+import (
+	"path"
+	"strings"
+)
+
+// languages maps file extensions, and the names of files without a meaningful extension, to the
+// name of their language, which is also the language tag of Markdown code fences.
+var languages = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".mjs": "javascript", ".cjs": "javascript",
+	".jsx": "jsx", ".ts": "typescript", ".tsx": "tsx", ".rb": "ruby", ".sh": "bash", ".bash": "bash",
+	".zsh": "zsh", ".fish": "fish", ".rs": "rust", ".java": "java", ".kt": "kotlin", ".scala": "scala",
+	".swift": "swift", ".c": "c", ".h": "c", ".cpp": "cpp", ".cc": "cpp", ".hpp": "cpp", ".cs": "csharp",
+	".php": "php", ".pl": "perl", ".lua": "lua", ".r": "r", ".sql": "sql", ".html": "html", ".css": "css",
+	".scss": "scss", ".json": "json", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".xml": "xml",
+	".md": "markdown", ".proto": "protobuf", ".tf": "hcl", ".dart": "dart", ".awk": "awk", ".tcl": "tcl",
+	"dockerfile": "dockerfile", "makefile": "makefile",
+}
+
+// Language returns the language of a file from its name or extension, e.g. "go" for "main.go" or
+// "dockerfile" for "Dockerfile", or an empty string if it is unknown. filePath can also be a bare
+// extension, e.g. ".py".
+func Language(filePath string) string {
+	name := strings.ToLower(path.Base(filePath))
+	if language, ok := languages[name]; ok {
+		return language
+	}
+	return languages[path.Ext(name)]
+}
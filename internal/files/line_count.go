@@ -0,0 +1,55 @@
+package files
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+)
+
+// lineCountFilter returns a FileFilter rejecting the files of fsys with more than maxLines lines,
+// counting them in stats. The files are read until the limit is exceeded.
+func lineCountFilter(fsys fs.FS, maxLines int, stats *SelectionStats) FileFilter {
+	return func(relPath string, d fs.DirEntry) (bool, error) {
+		file, err := fsys.Open(relPath)
+		if err != nil {
+			return false, err
+		}
+		defer file.Close()
+
+		longer, err := hasMoreLines(file, maxLines)
+		if err != nil {
+			return false, err
+		}
+		if longer {
+			stats.recordTooLong()
+		}
+		return !longer, nil
+	}
+}
+
+// hasMoreLines reports whether r has more than maxLines lines, counting a last line without newline.
+func hasMoreLines(r io.Reader, maxLines int) (bool, error) {
+	buf := make([]byte, 32*1024)
+	lines := 0
+	lastNewline := true
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			lastNewline = buf[n-1] == '\n'
+			if lines > maxLines {
+				return true, nil
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	if !lastNewline {
+		lines++
+	}
+	return lines > maxLines, nil
+}
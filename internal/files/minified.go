@@ -0,0 +1,66 @@
+package files
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// minifiableExtensions contains the extensions of files that are checked for minified content.
+var minifiableExtensions = map[string]bool{
+	".js":  true,
+	".mjs": true,
+	".cjs": true,
+	".css": true,
+}
+
+const (
+	// minifiedMinSize is the size below which files are never considered minified.
+	minifiedMinSize = 1024
+	// minifiedMaxAvgLineLength is the average line length above which a file may be minified.
+	minifiedMaxAvgLineLength = 300
+	// minifiedMaxWhitespaceRatio is the whitespace ratio below which a file may be minified.
+	minifiedMaxWhitespaceRatio = 0.1
+)
+
+// IsMinified reports whether the file at filePath with the given content looks like a minified
+// JS or CSS asset. Files named *.min.js or *.min.css are always considered minified; other JS and
+// CSS files are considered minified if they have very long lines and little whitespace.
+func IsMinified(filePath, content string) bool {
+	name := strings.ToLower(path.Base(filePath))
+	ext := path.Ext(name)
+	if !minifiableExtensions[ext] {
+		return false
+	}
+	if strings.HasSuffix(strings.TrimSuffix(name, ext), ".min") {
+		return true
+	}
+	if len(content) < minifiedMinSize {
+		return false
+	}
+
+	lines := strings.Count(content, "\n") + 1
+	whitespace := 0
+	for _, c := range content {
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			whitespace++
+		}
+	}
+
+	avgLineLength := len(content) / lines
+	whitespaceRatio := float64(whitespace) / float64(len(content))
+	return avgLineLength > minifiedMaxAvgLineLength && whitespaceRatio < minifiedMaxWhitespaceRatio
+}
+
+// ReplaceMinified replaces the content of minified files in fileContentMap with a placeholder
+// and returns the paths of the replaced files.
+func ReplaceMinified(fileContentMap map[string]string) []string {
+	var replaced []string
+	for filePath, content := range fileContentMap {
+		if IsMinified(filePath, content) {
+			fileContentMap[filePath] = fmt.Sprintf("minified file skipped (%d bytes)", len(content))
+			replaced = append(replaced, filePath)
+		}
+	}
+	return replaced
+}
@@ -54,7 +54,7 @@ func TestPreprocessExcludePatterns(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := preprocessExcludePatterns(rootDir, []string{tc.pattern})
+			result := preprocessExcludePatterns(os.DirFS(rootDir), []string{tc.pattern})
 
 			// Check that all expected patterns are present
 			for _, exp := range tc.expected {
@@ -0,0 +1,58 @@
+package files
+
+import (
+	"bufio"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// Pragma is a directive in a magic comment near the top of a file, such as "// crev:ignore".
+type Pragma string
+
+const (
+	// PragmaIgnore excludes the file from the selection, unless it is an explicit file.
+	PragmaIgnore Pragma = "ignore"
+	// PragmaInclude includes the file even if it does not match the include patterns or matches
+	// an exclude pattern. Files in excluded directories are not read, so they cannot be pinned.
+	PragmaInclude Pragma = "include"
+)
+
+const (
+	// pragmaLines is the number of lines at the top of a file that are searched for a pragma
+	pragmaLines = 10
+	// pragmaBytes limits the bytes read to find a pragma, for files with long lines
+	pragmaBytes = 4096
+)
+
+// pragmaPattern matches a pragma in a line comment or the first line of a block comment of the
+// common languages, e.g. "// crev:ignore", "# crev:include", "-- crev:ignore" or "<!-- crev:ignore -->".
+var pragmaPattern = regexp.MustCompile(`^\s*(?://|#|--|;|%|/\*|\*|<!--|\{-|\(\*)\s*crev:(ignore|include)\b`)
+
+// FilePragma returns the first pragma in the first lines of content, or an empty Pragma if there is none.
+func FilePragma(content string) Pragma {
+	for i, line := range strings.SplitN(content, "\n", pragmaLines+1) {
+		if i == pragmaLines {
+			break
+		}
+		if match := pragmaPattern.FindStringSubmatch(line); match != nil {
+			return Pragma(match[1])
+		}
+	}
+	return ""
+}
+
+// readPragma returns the pragma of the file relPath, see FilePragma.
+func readPragma(fsys fs.FS, relPath string) (Pragma, error) {
+	file, err := fsys.Open(relPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head, err := bufio.NewReaderSize(file, pragmaBytes).Peek(pragmaBytes)
+	if err != nil && len(head) == 0 {
+		return "", nil
+	}
+	return FilePragma(string(head)), nil
+}
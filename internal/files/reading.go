@@ -1,13 +1,39 @@
 package files
 
 import (
+	"io/fs"
 	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 )
 
+// hostFS is an fs.FS that opens names directly on the host filesystem, relative to root if it is
+// not empty. Unlike os.DirFS it accepts absolute and parent-relative paths, which callers of the
+// non-FS helpers rely on.
+type hostFS struct {
+	root string
+}
+
+func (h hostFS) Open(name string) (fs.File, error) {
+	return os.Open(filepath.Join(h.root, name))
+}
+
+// HostFS returns an fs.FS opening paths relative to the root directory on the host filesystem.
+// Unlike os.DirFS it also opens paths outside of root, such as explicit files selected with a
+// "../" prefix.
+func HostFS(root string) fs.FS {
+	return hostFS{root: root}
+}
+
+// EmptyDirMarker is the placeholder content of empty directories, which unbundle recreates as
+// directories. Selection removes directories without included files, so it is only produced for
+// directories passed directly to GetContentMapOfFiles.
+const EmptyDirMarker = "empty directory"
+
 // getFileContent returns the content of the given file.
-func getFileContent(filePath string) (string, error) {
-	dat, err := os.ReadFile(filePath)
+func getFileContent(fsys fs.FS, filePath string) (string, error) {
+	dat, err := fs.ReadFile(fsys, filePath)
 	if err != nil {
 		return "", err
 	}
@@ -16,52 +42,101 @@ func getFileContent(filePath string) (string, error) {
 
 // GetContentMapOfFiles returns a map of file paths to their content.
 func GetContentMapOfFiles(filePaths []string, maxConcurrency int) (map[string]string, error) {
-	var fileContentMap sync.Map
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(filePaths))
-	semaphore := make(chan struct{}, maxConcurrency)
+	return GetContentMapOfFilesFS(hostFS{}, filePaths, maxConcurrency)
+}
+
+// GetContentMapOfFilesFS returns a map of file paths to their content, reading the files from fsys.
+func GetContentMapOfFilesFS(fsys fs.FS, filePaths []string, maxConcurrency int) (map[string]string, error) {
+	entries := make([]Entry, len(filePaths))
+	for i, path := range filePaths {
+		entries[i] = Entry{Path: path}
+	}
+	return readEntries(fsys, entries, true, maxConcurrency)
+}
+
+// GetContentMapOfEntries returns a map of the paths of the selected entries to their content.
+// Unlike GetContentMapOfFiles it uses the metadata of the entries instead of reading it again.
+func GetContentMapOfEntries(entries []Entry, maxConcurrency int) (map[string]string, error) {
+	return GetContentMapOfEntriesFS(hostFS{}, entries, maxConcurrency)
+}
+
+// GetContentMapOfEntriesFS is like GetContentMapOfEntries, reading the files from fsys.
+func GetContentMapOfEntriesFS(fsys fs.FS, entries []Entry, maxConcurrency int) (map[string]string, error) {
+	return readEntries(fsys, entries, false, maxConcurrency)
+}
+
+// readResult is the content read for an entry. ok is false for entries without content, i.e.
+// directories that are not empty.
+type readResult struct {
+	content string
+	ok      bool
+	err     error
+}
 
-	for _, path := range filePaths {
+// readEntries reads the content of the entries with a fixed pool of at most maxConcurrency workers,
+// which store the results by the position of their entry, so no goroutine is started per entry.
+// Empty directories get a placeholder content. If stat is true, the metadata of the entries is read
+// first. After an entry fails, the remaining entries are skipped and the error is returned.
+func readEntries(fsys fs.FS, entries []Entry, stat bool, maxConcurrency int) (map[string]string, error) {
+	results := make([]readResult, len(entries))
+	indexes := make(chan int)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for range min(max(maxConcurrency, 1), len(entries)) {
 		wg.Add(1)
-		go func(p string) {
+		go func() {
 			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			info, err := os.Stat(p)
-			if err != nil {
-				errChan <- err
-				return
-			}
-			if !info.IsDir() {
-				fileContent, err := getFileContent(p)
-				if err != nil {
-					errChan <- err
-					return
-				}
-				fileContentMap.Store(p, fileContent)
-			} else {
-				dirEntries, err := os.ReadDir(p)
-				if err != nil {
-					errChan <- err
-					return
+			for i := range indexes {
+				if failed.Load() {
+					continue
 				}
-				if len(dirEntries) == 0 {
-					fileContentMap.Store(p, "empty directory")
+				results[i] = readEntry(fsys, entries[i], stat)
+				if results[i].err != nil {
+					failed.Store(true)
 				}
 			}
-		}(path)
+		}()
 	}
-	wg.Wait()
-	close(errChan)
-	if len(errChan) > 0 {
-		return nil, <-errChan
+	for i := range entries {
+		indexes <- i
 	}
+	close(indexes)
+	wg.Wait()
 
-	resultMap := make(map[string]string)
-	fileContentMap.Range(func(key, value interface{}) bool {
-		resultMap[key.(string)] = value.(string)
-		return true
-	})
+	fileContentMap := make(map[string]string, len(entries))
+	for i, result := range results {
+		if result.err != nil {
+			return nil, result.err
+		}
+		if result.ok {
+			fileContentMap[entries[i].Path] = result.content
+		}
+	}
+	return fileContentMap, nil
+}
 
-	return resultMap, nil
+// readEntry reads the content of a single entry, see readEntries.
+func readEntry(fsys fs.FS, e Entry, stat bool) readResult {
+	if stat {
+		info, err := fs.Stat(fsys, e.Path)
+		if err != nil {
+			return readResult{err: err}
+		}
+		e = newEntry(e.Path, info)
+	}
+	if !e.IsDir {
+		fileContent, err := getFileContent(fsys, e.Path)
+		if err != nil {
+			return readResult{err: err}
+		}
+		return readResult{content: fileContent, ok: true}
+	}
+	dirEntries, err := fs.ReadDir(fsys, e.Path)
+	if err != nil {
+		return readResult{err: err}
+	}
+	if len(dirEntries) == 0 {
+		return readResult{content: EmptyDirMarker, ok: true}
+	}
+	return readResult{}
 }
@@ -0,0 +1,327 @@
+package files
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// SelectExpr is a boolean expression of file predicates, such as
+// "lang:go and not test and size<50kb", parsed by ParseSelectExpr.
+//
+// The predicates are:
+//
+//	glob:PATTERN   the path matches the glob pattern, e.g. glob:src/**
+//	lang:NAME      the language of the file is NAME or its extension is .NAME, e.g. lang:go, lang:py
+//	test           the file is a test, e.g. main_test.go, test_app.py, app.spec.ts or tests/data.json
+//	size<N         the size compares to N bytes with <, <=, >, >= or =, units b, kb, mb and gb
+//	mtime<N        the time since the last modification compares to N, units s, m, h, d and w,
+//	               e.g. mtime<7d for files changed in the last week
+//	git:STATUS     the file has the git status tracked, modified, staged or untracked
+//
+// Predicates are combined with "and", "or" and "not", in that order of precedence from lowest to
+// highest, and grouped with parentheses.
+type SelectExpr struct {
+	root exprNode
+	// gitStatuses are the git statuses used by git predicates
+	gitStatuses map[string]bool
+}
+
+// exprFile is a file a SelectExpr is evaluated for.
+type exprFile struct {
+	relPath string
+	d       fs.DirEntry
+	now     time.Time
+	git     map[string]map[string]bool
+}
+
+// exprNode is a node of the syntax tree of a SelectExpr.
+type exprNode interface {
+	eval(f exprFile) (bool, error)
+}
+
+type andNode struct{ left, right exprNode }
+type orNode struct{ left, right exprNode }
+type notNode struct{ operand exprNode }
+
+// predicateNode is a leaf of the syntax tree.
+type predicateNode func(f exprFile) (bool, error)
+
+func (n andNode) eval(f exprFile) (bool, error) {
+	if ok, err := n.left.eval(f); !ok || err != nil {
+		return false, err
+	}
+	return n.right.eval(f)
+}
+
+func (n orNode) eval(f exprFile) (bool, error) {
+	if ok, err := n.left.eval(f); ok || err != nil {
+		return ok, err
+	}
+	return n.right.eval(f)
+}
+
+func (n notNode) eval(f exprFile) (bool, error) {
+	ok, err := n.operand.eval(f)
+	return !ok, err
+}
+
+func (n predicateNode) eval(f exprFile) (bool, error) {
+	return n(f)
+}
+
+// ParseSelectExpr parses a select expression, see SelectExpr.
+func ParseSelectExpr(expr string) (*SelectExpr, error) {
+	p := &exprParser{tokens: tokenizeExpr(expr), expr: &SelectExpr{gitStatuses: make(map[string]bool)}}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("invalid select expression %q: empty expression", expr)
+	}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid select expression %q: %w", expr, err)
+	}
+	p.expr.root = root
+	return p.expr, nil
+}
+
+// Filter returns a FileFilter selecting the files matching the expression. root is the directory
+// the paths of the selection are relative to, the git statuses of its files are listed once here.
+func (e *SelectExpr) Filter(root string) (FileFilter, error) {
+	git := make(map[string]map[string]bool, len(e.gitStatuses))
+	for status := range e.gitStatuses {
+		files, err := GitStatusFiles(root, status)
+		if err != nil {
+			return nil, err
+		}
+		git[status] = files
+	}
+	now := time.Now()
+	return func(relPath string, d fs.DirEntry) (bool, error) {
+		return e.root.eval(exprFile{relPath: relPath, d: d, now: now, git: git})
+	}, nil
+}
+
+// tokenizeExpr splits an expression into parentheses and words separated by whitespace.
+func tokenizeExpr(expr string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range expr {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// exprParser is a recursive descent parser of select expressions.
+type exprParser struct {
+	tokens []string
+	pos    int
+	expr   *SelectExpr
+}
+
+// accept consumes the next token if it is the keyword, case-insensitively.
+func (p *exprParser) accept(keyword string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.accept("not") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	if p.accept("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return node, nil
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	token := p.tokens[p.pos]
+	switch strings.ToLower(token) {
+	case ")", "and", "or":
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+	p.pos++
+	return p.parsePredicate(token)
+}
+
+// comparisonPattern matches the size and mtime predicates
+var comparisonPattern = regexp.MustCompile(`^(size|mtime)(<=|>=|<|>|=)(\d+(?:\.\d+)?)([a-z]*)$`)
+
+// sizeUnits and durationUnits are the units of the size and mtime predicates
+var (
+	sizeUnits     = map[string]float64{"": 1, "b": 1, "kb": 1 << 10, "mb": 1 << 20, "gb": 1 << 30}
+	durationUnits = map[string]time.Duration{
+		"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+	}
+)
+
+// parsePredicate parses a predicate token, see SelectExpr.
+func (p *exprParser) parsePredicate(token string) (exprNode, error) {
+	if strings.EqualFold(token, "test") {
+		return predicateNode(func(f exprFile) (bool, error) {
+			matched, _, err := testFilePatterns.match(f.relPath)
+			return matched, err
+		}), nil
+	}
+
+	if kind, value, ok := strings.Cut(token, ":"); ok {
+		if value == "" {
+			return nil, fmt.Errorf("missing value of predicate %q", token)
+		}
+		switch strings.ToLower(kind) {
+		case "glob":
+			if !doublestar.ValidatePattern(value) {
+				return nil, &BadPatternError{Pattern: value}
+			}
+			g := compileGlob(value)
+			return predicateNode(func(f exprFile) (bool, error) {
+				return g.match(f.relPath)
+			}), nil
+		case "lang":
+			lang := strings.ToLower(value)
+			return predicateNode(func(f exprFile) (bool, error) {
+				return Language(f.relPath) == lang || strings.EqualFold(path.Ext(f.relPath), "."+lang), nil
+			}), nil
+		case "git":
+			status := strings.ToLower(value)
+			switch status {
+			case GitTracked, GitModified, GitStaged, GitUntracked:
+			default:
+				return nil, fmt.Errorf("unsupported git status %q (supported: %s, %s, %s, %s)", value, GitTracked, GitModified, GitStaged, GitUntracked)
+			}
+			p.expr.gitStatuses[status] = true
+			return predicateNode(func(f exprFile) (bool, error) {
+				return f.git[status][f.relPath], nil
+			}), nil
+		}
+	}
+
+	if match := comparisonPattern.FindStringSubmatch(strings.ToLower(token)); match != nil {
+		number, _ := strconv.ParseFloat(match[3], 64)
+		op := match[2]
+		if match[1] == "size" {
+			unit, ok := sizeUnits[match[4]]
+			if !ok {
+				return nil, fmt.Errorf("unsupported size unit %q in %q (supported: b, kb, mb, gb)", match[4], token)
+			}
+			limit := number * unit
+			return predicateNode(func(f exprFile) (bool, error) {
+				info, err := f.d.Info()
+				if err != nil {
+					return false, err
+				}
+				return compare(float64(info.Size()), op, limit), nil
+			}), nil
+		}
+		unit, ok := durationUnits[match[4]]
+		if !ok {
+			return nil, fmt.Errorf("unsupported duration unit %q in %q (supported: s, m, h, d, w)", match[4], token)
+		}
+		limit := number * float64(unit)
+		return predicateNode(func(f exprFile) (bool, error) {
+			info, err := f.d.Info()
+			if err != nil {
+				return false, err
+			}
+			return compare(float64(f.now.Sub(info.ModTime())), op, limit), nil
+		}), nil
+	}
+
+	return nil, fmt.Errorf("unknown predicate %q (supported: glob:, lang:, test, size, mtime, git:)", token)
+}
+
+// compare applies a comparison operator of the size and mtime predicates.
+func compare(value float64, op string, limit float64) bool {
+	switch op {
+	case "<":
+		return value < limit
+	case "<=":
+		return value <= limit
+	case ">":
+		return value > limit
+	case ">=":
+		return value >= limit
+	default:
+		return value == limit
+	}
+}
+
+// IsTestFile reports whether the slash-separated path is a test file, like the test predicate of
+// select expressions.
+func IsTestFile(relPath string) bool {
+	matched, _, err := testFilePatterns.match(relPath)
+	return matched && err == nil
+}
+
+// testFilePatterns match the test files of the common languages and the files in test directories
+var testFilePatterns = compileGlobs([]string{
+	"**/*_test.*", "**/test_*.py", "**/*.test.*", "**/*.spec.*", "**/*Test.java", "**/*Tests.cs",
+	"**/test/**", "**/tests/**", "**/__tests__/**", "**/spec/**",
+})
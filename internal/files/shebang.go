@@ -0,0 +1,85 @@
+package files
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// shebangExtensions maps script interpreters, without version suffix, to the extension of their language.
+var shebangExtensions = map[string]string{
+	"python":  ".py",
+	"pypy":    ".py",
+	"sh":      ".sh",
+	"bash":    ".sh",
+	"dash":    ".sh",
+	"ksh":     ".sh",
+	"zsh":     ".zsh",
+	"fish":    ".fish",
+	"node":    ".js",
+	"nodejs":  ".js",
+	"deno":    ".ts",
+	"ts-node": ".ts",
+	"tsx":     ".ts",
+	"ruby":    ".rb",
+	"perl":    ".pl",
+	"php":     ".php",
+	"lua":     ".lua",
+	"rscript": ".r",
+	"awk":     ".awk",
+	"tclsh":   ".tcl",
+}
+
+// ShebangExtension returns the extension of the language of a script from the interpreter in its
+// shebang line, e.g. ".py" for "#!/usr/bin/env python3", or an empty string if it is unknown.
+func ShebangExtension(content string) string {
+	line, _, _ := strings.Cut(content, "\n")
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Skip env and its options, e.g. "#!/usr/bin/env -S python3 -u"
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = path.Base(field)
+				break
+			}
+		}
+	}
+
+	// Strip the version, e.g. python3.12
+	interpreter = strings.ToLower(strings.TrimRight(interpreter, "0123456789."))
+	return shebangExtensions[interpreter]
+}
+
+// shebangPath returns relPath with the extension of the language of its shebang line appended,
+// or an empty string if relPath has an extension or no known shebang.
+func shebangPath(fsys fs.FS, relPath string) (string, error) {
+	if path.Ext(relPath) != "" {
+		return "", nil
+	}
+	file, err := fsys.Open(relPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// The shebang is on the first line, which is short
+	line, err := bufio.NewReaderSize(file, 256).Peek(256)
+	if err != nil && len(line) == 0 {
+		return "", nil
+	}
+	ext := ShebangExtension(string(line))
+	if ext == "" {
+		return "", nil
+	}
+	return relPath + ext, nil
+}
@@ -0,0 +1,29 @@
+package files
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sizePattern matches a size with an optional unit, e.g. "1048576", "500kb" or "1.5 MB".
+var sizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-z]*)$`)
+
+// ParseSize parses a size in bytes with an optional unit b, kb, mb or gb, like the size predicate
+// of select expressions. Units are case-insensitive and powers of 1024.
+func ParseSize(value string) (int64, error) {
+	match := sizePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q: expected a number of bytes with an optional unit, e.g. 500kb", value)
+	}
+	unit, ok := sizeUnits[match[2]]
+	if !ok {
+		return 0, fmt.Errorf("unsupported size unit %q in %q (supported: b, kb, mb, gb)", match[2], value)
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", value, err)
+	}
+	return int64(number * unit), nil
+}
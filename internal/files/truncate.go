@@ -0,0 +1,56 @@
+package files
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TruncateLines cuts the content of the files in fileContentMap with more than maxLines lines
+// after the first maxLines lines, followed by a "… truncated (M more lines)" marker, and returns
+// the paths of the truncated files. A maxLines of 0 or less keeps all lines.
+func TruncateLines(fileContentMap map[string]string, maxLines int) []string {
+	if maxLines <= 0 {
+		return nil
+	}
+	var truncated []string
+	for filePath, content := range fileContentMap {
+		lines := strings.Count(content, "\n")
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			lines++ // the last line has no newline
+		}
+		if lines <= maxLines {
+			continue
+		}
+
+		end := 0
+		for i := 0; i < maxLines; i++ {
+			end += strings.IndexByte(content[end:], '\n') + 1
+		}
+		fileContentMap[filePath] = content[:end] + truncationMarker(lines-maxLines)
+		truncated = append(truncated, filePath)
+	}
+	return truncated
+}
+
+// TruncateBytes cuts content after the last complete line within the first maxBytes bytes,
+// followed by a "… truncated (M more lines)" marker. Content of at most maxBytes bytes is kept.
+func TruncateBytes(content string, maxBytes int) string {
+	if len(content) <= maxBytes {
+		return content
+	}
+	end := strings.LastIndexByte(content[:max(maxBytes, 0)], '\n') + 1
+	rest := content[end:]
+	lines := strings.Count(rest, "\n")
+	if !strings.HasSuffix(rest, "\n") {
+		lines++ // the last line has no newline
+	}
+	return content[:end] + truncationMarker(lines)
+}
+
+// truncationMarker returns the marker replacing the remaining lines of a truncated file.
+func truncationMarker(lines int) string {
+	if lines == 1 {
+		return "… truncated (1 more line)"
+	}
+	return fmt.Sprintf("… truncated (%d more lines)", lines)
+}
@@ -1,11 +1,19 @@
-// Contains code to write content to files.
+// Contains code to write content to files and other output sinks.
 package files
 
 import (
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
+// StdoutSink is the sink target that writes to standard output.
+const StdoutSink = "-"
+
 // Saves a string to a file.
 func SaveStringToFile(content string, path string) (err error) {
 	f, err := os.Create(path)
@@ -26,3 +34,160 @@ func SaveStringToFile(content string, path string) (err error) {
 	}
 	return nil
 }
+
+// OpenSink opens the output sink described by target. The target is one of:
+//   - "-" to write to standard output
+//   - "|command args" to pipe into the standard input of a shell command
+//   - "tcp://host:port" or "unix:///path/to/socket" to write to a network connection
+//   - any other value is treated as a file path
+//
+// The caller must close the returned writer; for pipes Close waits for the command to exit.
+// File sinks write to a uniquely named temporary file next to the target, which replaces the
+// target on Close, so concurrent runs writing the same output never interleave their writes.
+func OpenSink(target string) (io.WriteCloser, error) {
+	switch {
+	case target == StdoutSink:
+		return nopWriteCloser{os.Stdout}, nil
+	case strings.HasPrefix(target, "|"):
+		return openPipeSink(strings.TrimSpace(strings.TrimPrefix(target, "|")))
+	case strings.HasPrefix(target, "tcp://"):
+		return net.Dial("tcp", strings.TrimPrefix(target, "tcp://"))
+	case strings.HasPrefix(target, "unix://"):
+		return net.Dial("unix", strings.TrimPrefix(target, "unix://"))
+	default:
+		return openFileSink(target)
+	}
+}
+
+// IsFileSink reports whether target is written to a file by OpenSink, and not to standard
+// output, a command or a network connection.
+func IsFileSink(target string) bool {
+	return target != StdoutSink && !strings.HasPrefix(target, "|") && !strings.HasPrefix(target, "unix://") && !IsNetworkSink(target)
+}
+
+// IsNetworkSink reports whether target is written to a TCP connection by OpenSink.
+func IsNetworkSink(target string) bool {
+	return strings.HasPrefix(target, "tcp://")
+}
+
+// fileSink writes to a temporary file that atomically replaces the target file on Close.
+type fileSink struct {
+	*os.File
+	target string
+	failed bool
+}
+
+// openFileSink creates a temporary file in the directory of target to write the output to.
+func openFileSink(target string) (io.WriteCloser, error) {
+	dir, base := filepath.Split(target)
+	if dir == "" {
+		dir = "."
+	}
+	// Name the temporary file with a dot prefix so that it is excluded from discovery by default
+	f, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{File: f, target: target}, nil
+}
+
+func (s *fileSink) Write(p []byte) (int, error) {
+	n, err := s.File.Write(p)
+	if err != nil {
+		s.failed = true
+	}
+	return n, err
+}
+
+// Close closes the temporary file and renames it to the target, with the permissions of the
+// target if it exists, see targetMode. If a write failed, the temporary file is removed instead
+// and the target is left untouched.
+func (s *fileSink) Close() error {
+	tempName := s.File.Name()
+	if err := s.File.Close(); err != nil || s.failed {
+		os.Remove(tempName)
+		if err == nil {
+			err = fmt.Errorf("discarded incomplete output for %s", s.target)
+		}
+		return err
+	}
+	mode, err := targetMode(s.target, tempName)
+	if err == nil {
+		err = os.Chmod(tempName, mode)
+	}
+	if err == nil {
+		err = os.Rename(tempName, s.target)
+	}
+	if err != nil {
+		os.Remove(tempName)
+		return err
+	}
+	return nil
+}
+
+// targetMode returns the permissions of the file replacing target: the permissions of target if it
+// exists, and 0666 without the bits of the umask, like for a file created by os.Create, otherwise.
+// The umask can only be read by changing it for the whole process, so it is applied to a probe
+// file named after tempName instead.
+func targetMode(target, tempName string) (os.FileMode, error) {
+	info, err := os.Stat(target)
+	if err == nil {
+		return info.Mode().Perm(), nil
+	}
+	if !os.IsNotExist(err) {
+		return 0, err
+	}
+	probeName := tempName + ".mode"
+	probe, err := os.OpenFile(probeName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(probeName)
+	info, err = probe.Stat()
+	probe.Close()
+	if err != nil {
+		return 0, err
+	}
+	return info.Mode().Perm(), nil
+}
+
+// nopWriteCloser wraps a writer that must not be closed by the sink, such as stdout.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// pipeSink writes to the standard input of a running command.
+type pipeSink struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+// Close closes the command's standard input and waits for it to exit.
+func (p *pipeSink) Close() error {
+	if err := p.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return p.cmd.Wait()
+}
+
+// openPipeSink starts command via the shell and returns a writer connected to its standard input.
+func openPipeSink(command string) (io.WriteCloser, error) {
+	if command == "" {
+		return nil, fmt.Errorf("no command given for pipe sink")
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start pipe command %q: %w", command, err)
+	}
+	return &pipeSink{WriteCloser: stdin, cmd: cmd}, nil
+}
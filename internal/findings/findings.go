@@ -0,0 +1,176 @@
+// Package findings parses review findings from the output of a model and renders them as a report.
+package findings
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Finding is a single review comment tied to a range of lines in a file.
+type Finding struct {
+	File string `json:"file"`
+	// Line is the first line of the finding, starting at 1. EndLine is the last line,
+	// it is equal to Line for findings on a single line.
+	Line     int    `json:"line"`
+	EndLine  int    `json:"end_line,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Lines returns the line range of the finding, e.g. "12" or "12-15".
+func (f Finding) Lines() string {
+	if f.EndLine > f.Line {
+		return fmt.Sprintf("%d-%d", f.Line, f.EndLine)
+	}
+	return strconv.Itoa(f.Line)
+}
+
+// jsonBlockPattern matches a fenced JSON code block
+var jsonBlockPattern = regexp.MustCompile("(?s)```json\\s*\n(.*?)```")
+
+// linePattern matches a finding written on a single line, e.g.
+// "path/to/file.go:12-15: [warning] message" or "- `path/to/file.go:12`: message"
+var linePattern = regexp.MustCompile("^[-*\\s]*`?([^\\s:`]+):(\\d+)(?:-(\\d+))?`?:?\\s+(?:\\[(\\w+)\\]\\s*)?(.+)$")
+
+// Parse returns the findings in the output of a model. The findings are either a JSON array of
+// findings (or an object with a "findings" array), optionally in a fenced json code block,
+// or lines of the form "path:line[-end]: [severity] message". Other text is ignored.
+func Parse(text string) ([]Finding, error) {
+	// Structured output
+	candidates := []string{text}
+	for _, match := range jsonBlockPattern.FindAllStringSubmatch(text, -1) {
+		candidates = append(candidates, match[1])
+	}
+	for _, candidate := range candidates {
+		findings, ok, err := parseJSON(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return findings, nil
+		}
+	}
+
+	// One finding per line
+	var findings []Finding
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		match := linePattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		f := Finding{File: match[1], Severity: strings.ToLower(match[4]), Message: strings.TrimSpace(match[5])}
+		f.Line, _ = strconv.Atoi(match[2])
+		f.EndLine, _ = strconv.Atoi(match[3])
+		findings = append(findings, f)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return normalize(findings)
+}
+
+// parseJSON parses text as a JSON array of findings or an object with a "findings" array.
+// It reports whether text is JSON at all, so that other formats can be tried.
+func parseJSON(text string) ([]Finding, bool, error) {
+	text = strings.TrimSpace(text)
+	var findings []Finding
+	switch {
+	case strings.HasPrefix(text, "["):
+		if err := json.Unmarshal([]byte(text), &findings); err != nil {
+			return nil, false, nil
+		}
+	case strings.HasPrefix(text, "{"):
+		var wrapper struct {
+			Findings *[]Finding `json:"findings"`
+		}
+		if err := json.Unmarshal([]byte(text), &wrapper); err != nil || wrapper.Findings == nil {
+			return nil, false, nil
+		}
+		findings = *wrapper.Findings
+	default:
+		return nil, false, nil
+	}
+	findings, err := normalize(findings)
+	return findings, true, err
+}
+
+// normalize validates the findings and fills in the end line of single line findings
+func normalize(findings []Finding) ([]Finding, error) {
+	for i := range findings {
+		f := &findings[i]
+		if f.File == "" {
+			return nil, fmt.Errorf("finding %d has no file", i+1)
+		}
+		if f.Line < 1 {
+			return nil, fmt.Errorf("finding %d (%s) has no valid line number", i+1, f.File)
+		}
+		if f.EndLine < f.Line {
+			f.EndLine = f.Line
+		}
+		f.Severity = strings.ToLower(f.Severity)
+	}
+	return findings, nil
+}
+
+// Sort orders findings by file and line.
+func Sort(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
+
+// WriteReport writes the findings to w grouped by file, e.g.
+//
+//	internal/files/globbing.go (2 findings)
+//	  12-15  warning  Exclude patterns are compiled on every call
+//	  40              Missing error check
+func WriteReport(w io.Writer, findings []Finding) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No findings.")
+		return err
+	}
+
+	sorted := append([]Finding(nil), findings...)
+	Sort(sorted)
+
+	var sb strings.Builder
+	for start := 0; start < len(sorted); {
+		end := start
+		lineWidth, severityWidth := 0, 0
+		for end < len(sorted) && sorted[end].File == sorted[start].File {
+			lineWidth = max(lineWidth, len(sorted[end].Lines()))
+			severityWidth = max(severityWidth, len(sorted[end].Severity))
+			end++
+		}
+
+		if start > 0 {
+			sb.WriteString("\n")
+		}
+		count := "1 finding"
+		if end-start != 1 {
+			count = fmt.Sprintf("%d findings", end-start)
+		}
+		fmt.Fprintf(&sb, "%s (%s)\n", sorted[start].File, count)
+		for _, f := range sorted[start:end] {
+			line := fmt.Sprintf("  %-*s  %-*s  %s", lineWidth, f.Lines(), severityWidth, f.Severity, f.Message)
+			if severityWidth == 0 {
+				line = fmt.Sprintf("  %-*s  %s", lineWidth, f.Lines(), f.Message)
+			}
+			sb.WriteString(line + "\n")
+		}
+		start = end
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,75 @@
+package formatting
+
+import (
+	"sort"
+)
+
+// FileEntry is a single file in a bundle, together with its metadata and content.
+type FileEntry struct {
+	Path    string
+	Size    int
+	Content string
+	// SHA256 is the hex encoded SHA-256 checksum of the file as read, before its content was
+	// redacted or truncated, if known.
+	SHA256 string
+}
+
+// Stats summarizes the contents of a bundle.
+type Stats struct {
+	FileCount  int
+	TotalBytes int
+}
+
+// Bundle is the structured representation of a project bundle. It is produced from the
+// selected file paths and their contents, and consumed by the renderers.
+type Bundle struct {
+	// Tree is the project tree. The rendered bundle has no project tree section if it is empty.
+	Tree  string
+	Files []FileEntry
+	Stats Stats
+	// Fence, if not nil, wraps the content of each file in a code fence.
+	Fence *Fence
+	// Sentinel, if not nil, encloses the content of each file in untrusted content markers.
+	Sentinel *Sentinel
+	// Symbols is the symbol index, see GenerateSymbolIndex. The rendered bundle has no symbol index
+	// section if it is empty, it is rendered at the end unless Sections places it elsewhere.
+	Symbols string
+	// Imports is the import graph, see GenerateImportGraph. Like the symbol index, it is rendered at
+	// the end unless Sections places it elsewhere.
+	Imports string
+	// Sections is the order of the sections of the rendered bundle, DefaultSections if empty.
+	Sections []Section
+}
+
+// NewBundle creates a Bundle from the selected file paths and a map of file paths to their content.
+func NewBundle(filePaths []string, fileContentMap map[string]string) *Bundle {
+	// GeneratePathTree sorts its input, so hand it a copy to leave the caller's slice untouched
+	paths := append([]string(nil), filePaths...)
+	return newBundleWithTree(GeneratePathTree(paths), fileContentMap)
+}
+
+// newBundleWithTree creates a Bundle from an already generated project tree.
+func newBundleWithTree(projectTree string, fileContentMap map[string]string) *Bundle {
+	b := &Bundle{Tree: projectTree}
+
+	// Collect and sort the file paths lexicographically to make the bundle deterministic
+	filePaths := make([]string, 0, len(fileContentMap))
+	for filePath := range fileContentMap {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	b.Files = make([]FileEntry, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		content := fileContentMap[filePath]
+		b.Files = append(b.Files, FileEntry{
+			Path:    filePath,
+			Size:    len(content),
+			Content: content,
+		})
+		b.Stats.FileCount++
+		b.Stats.TotalBytes += len(content)
+	}
+
+	return b
+}
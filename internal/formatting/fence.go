@@ -0,0 +1,74 @@
+package formatting
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+)
+
+// Fence configures wrapping the content of each file in a Markdown code fence.
+type Fence struct {
+	// Char is the fence character, '`' or '~'.
+	Char byte
+	// Length is the minimum length of the fence. Fences are made longer than any fence of the
+	// same character inside the content, so the content cannot close them.
+	Length int
+	// Language adds the language of the file after the opening fence, e.g. ```go.
+	Language bool
+}
+
+// MinFenceLength is the shortest fence allowed by Markdown.
+const MinFenceLength = 3
+
+// ValidateFence checks the fence character and length.
+func ValidateFence(f Fence) error {
+	if f.Char != '`' && f.Char != '~' {
+		return fmt.Errorf("invalid fence character %q (supported: ` or ~)", string(f.Char))
+	}
+	if f.Length < MinFenceLength {
+		return fmt.Errorf("invalid fence length %d: fences are at least %d characters long", f.Length, MinFenceLength)
+	}
+	return nil
+}
+
+// fenceFor returns the fence wrapping content, at least one character longer than the longest
+// fence of the same character starting a line of content.
+func (f Fence) fenceFor(content string) string {
+	length := f.Length
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimLeft(line, " ")
+		run := len(line) - len(strings.TrimLeft(line, string(f.Char)))
+		if run >= length {
+			length = run + 1
+		}
+	}
+	return strings.Repeat(string(f.Char), length)
+}
+
+// fenceLanguage returns the language tag of a file from its extension, its name or the shebang
+// line of an extensionless script, or an empty string if it is unknown.
+func fenceLanguage(filePath, content string) string {
+	if language := files.Language(filePath); language != "" || path.Ext(filePath) != "" {
+		return language
+	}
+	return files.Language(files.ShebangExtension(content))
+}
+
+// openingFencePattern matches the opening fence written before the content of a file
+var openingFencePattern = regexp.MustCompile("^(`{3,}|~{3,})[^`\n]*\n")
+
+// unfence returns content without the code fence wrapped around it by a Fence, if any.
+func unfence(content string) string {
+	match := openingFencePattern.FindStringSubmatch(content)
+	if match == nil {
+		return content
+	}
+	inner := content[len(match[0]):]
+	if !strings.HasSuffix(inner, "\n"+match[1]) {
+		return content
+	}
+	return strings.TrimSuffix(inner, "\n"+match[1])
+}
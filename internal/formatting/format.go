@@ -2,6 +2,9 @@
 package formatting
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -15,11 +18,37 @@ import (
 type node struct {
 	name     string
 	children map[string]*node
+	// note is shown in parentheses after the name of a file, e.g. its size.
+	note string
+	// dir is true for directories given with a trailing slash, which are not counted as files
+	// when they are empty.
+	dir bool
 }
 
 // GeneratePathTree Given a list of paths, GeneratePathTree returns a string representation of the
-// directory structure.
+// directory structure. Paths ending with a slash are directories. Directories are annotated with
+// the number of files beneath them.
 func GeneratePathTree(paths []string) string {
+	return GeneratePathTreeDepth(paths, 0)
+}
+
+// GeneratePathTreeDepth is like GeneratePathTree, but only shows the first depth levels of the
+// directory structure. The contents of deeper directories are collapsed into a single
+// "… (N files)" entry. A depth of 0 or less shows the whole structure.
+func GeneratePathTreeDepth(paths []string, depth int) string {
+	return GenerateLabeledPathTree(paths, depth, "")
+}
+
+// GenerateLabeledPathTree is like GeneratePathTreeDepth, but starts with a root node labeled label
+// and annotated with the number of files, like the first line of the tree command. An empty label
+// starts directly at the children of the root.
+func GenerateLabeledPathTree(paths []string, depth int, label string) string {
+	return GenerateAnnotatedPathTree(paths, depth, label, nil)
+}
+
+// GenerateAnnotatedPathTree is like GenerateLabeledPathTree, but shows the notes of the files
+// keyed by their path in parentheses after their name, e.g. "data.json (12.0 MB, omitted)".
+func GenerateAnnotatedPathTree(paths []string, depth int, label string, notes map[string]string) string {
 	root := &node{children: make(map[string]*node)}
 
 	// Sort the paths lexicographically to ensure correct tree structure
@@ -34,20 +63,29 @@ func GeneratePathTree(paths []string) string {
 		parts := strings.Split(filepath.ToSlash(cleanedPath), "/")
 		current := root
 		for _, part := range parts {
+			if part == "" {
+				part = "/" // the root of an absolute path
+			}
 			if _, exists := current.children[part]; !exists {
 				current.children[part] = &node{name: part, children: make(map[string]*node)}
 			}
 			current = current.children[part]
 		}
+		current.note = notes[filepath.ToSlash(cleanedPath)]
+		current.dir = current.dir || strings.HasSuffix(filepath.ToSlash(path), "/")
 	}
 
 	// Generate the tree string
 	var sb strings.Builder
-	printTree(root, "", &sb)
+	if label != "" {
+		sb.WriteString(label + " " + fileCount(root.countFiles()) + "\n")
+	}
+	printTree(root, "", &sb, depth)
 	return sb.String()
 }
 
-func printTree(n *node, prefix string, sb *strings.Builder) {
+// printTree writes the children of n to sb. If depth is positive, it is the number of levels left to show.
+func printTree(n *node, prefix string, sb *strings.Builder, depth int) {
 	children := make([]*node, 0, len(n.children))
 	for _, child := range n.children {
 		children = append(children, child)
@@ -65,6 +103,12 @@ func printTree(n *node, prefix string, sb *strings.Builder) {
 			sb.WriteString("├── ")
 		}
 		sb.WriteString(child.name)
+		// Annotate directories with the number of files beneath them
+		if len(child.children) > 0 {
+			sb.WriteString(" " + fileCount(child.countFiles()))
+		} else if child.note != "" {
+			sb.WriteString(" (" + child.note + ")")
+		}
 		sb.WriteString("\n") // Always append a newline
 
 		newPrefix := prefix
@@ -73,34 +117,73 @@ func printTree(n *node, prefix string, sb *strings.Builder) {
 		} else {
 			newPrefix += "│   "
 		}
-		printTree(child, newPrefix, sb)
+		if depth == 1 && len(child.children) > 0 {
+			sb.WriteString(newPrefix + "└── " + collapsedEntry(child.countFiles()) + "\n")
+			continue
+		}
+		printTree(child, newPrefix, sb, depth-1)
 	}
 }
 
-// CreateProjectString Creates a string representation of the project.
-func CreateProjectString(projectTree string, fileContentMap map[string]string) string {
-	var projectString strings.Builder
-	projectString.WriteString("Project Directory Structure:" + "\n")
-	projectString.WriteString(projectTree + "\n\n")
+// collapsedEntry returns the tree entry replacing the contents of a collapsed directory.
+func collapsedEntry(files int) string {
+	return "… " + fileCount(files)
+}
 
-	// Collect and sort the file paths lexicographically to make the function deterministic
-	filePaths := make([]string, 0, len(fileContentMap))
-	for filePath := range fileContentMap {
-		filePaths = append(filePaths, filePath)
+// fileCount returns the parenthesized number of files shown next to directories.
+func fileCount(files int) string {
+	if files == 1 {
+		return "(1 file)"
 	}
-	sort.Strings(filePaths)
+	return fmt.Sprintf("(%d files)", files)
+}
 
-	for _, fileName := range filePaths {
-		fileContent := fileContentMap[fileName]
-		// Skip displaying the file if it has no content
-		if strings.TrimSpace(fileContent) == "" {
-			continue
+// countFiles returns the number of files beneath n, the nodes without children that are not
+// directories.
+func (n *node) countFiles() int {
+	count := 0
+	for _, child := range n.children {
+		if len(child.children) > 0 {
+			count += child.countFiles()
+		} else if !child.dir {
+			count++
 		}
-		// Add file name and content if the file has non-empty content
-		projectString.WriteString("File: " + "\n")
-		projectString.WriteString(fileName + "\n")
-		projectString.WriteString("Content: " + "\n")
-		projectString.WriteString(fileContent + "\n\n")
 	}
+	return count
+}
+
+// CreateProjectString Creates a string representation of the project.
+func CreateProjectString(projectTree string, fileContentMap map[string]string) string {
+	return RenderText(newBundleWithTree(projectTree, fileContentMap))
+}
+
+// RenderText renders a bundle in the plain text format.
+func RenderText(b *Bundle) string {
+	var projectString strings.Builder
+	// Writing to a strings.Builder never fails
+	_ = WriteText(&projectString, b)
 	return projectString.String()
 }
+
+// WriteText writes a bundle in the plain text format to w.
+func WriteText(w io.Writer, b *Bundle) error {
+	bw := bufio.NewWriter(w)
+	renderText(b, func(text string, _ *FileEntry) {
+		bw.WriteString(text)
+	})
+	// bufio.Writer keeps the first error, so it is enough to check it on Flush
+	return bw.Flush()
+}
+
+// textTreeHeader is the heading of the project tree in the plain text format.
+const textTreeHeader = "Project Directory Structure:" + "\n"
+
+// textFileHeader returns the lines preceding the content of a file in the plain text format.
+func textFileHeader(path string) string {
+	return "File: " + "\n" + path + "\n" + "Content: " + "\n"
+}
+
+// hasTextContent reports whether a file is shown in the plain text format.
+func hasTextContent(file FileEntry) bool {
+	return strings.TrimSpace(file.Content) != ""
+}
@@ -0,0 +1,42 @@
+package formatting
+
+import (
+	"io"
+)
+
+// Formatter writes a bundle in an output format.
+type Formatter interface {
+	Write(w io.Writer, b *Bundle) error
+}
+
+// TextFormatter writes bundles in the plain text format, see WriteText.
+type TextFormatter struct{}
+
+// Write writes b in the plain text format to w.
+func (TextFormatter) Write(w io.Writer, b *Bundle) error {
+	return WriteText(w, b)
+}
+
+// JSONFormatter writes bundles as a single JSON document, see JSONDocument.
+type JSONFormatter struct{}
+
+// Write writes b as an indented JSON document to w.
+func (JSONFormatter) Write(w io.Writer, b *Bundle) error {
+	return WriteJSON(w, b)
+}
+
+// XMLFormatter writes bundles with XML document tags, see WriteXML.
+type XMLFormatter struct{}
+
+// Write writes b with XML document tags to w.
+func (XMLFormatter) Write(w io.Writer, b *Bundle) error {
+	return WriteXML(w, b)
+}
+
+// HTMLFormatter writes bundles as a self-contained HTML page, see WriteHTML.
+type HTMLFormatter struct{}
+
+// Write writes b as an HTML page to w.
+func (HTMLFormatter) Write(w io.Writer, b *Bundle) error {
+	return WriteHTML(w, b)
+}
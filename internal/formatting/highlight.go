@@ -0,0 +1,214 @@
+package formatting
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// syntax describes the tokens of a language that are highlighted in the HTML format.
+type syntax struct {
+	lineComments []string
+	// blockComment is the opening and closing delimiter of block comments, if any.
+	blockComment [2]string
+	// quotes are the string delimiters, "\" escapes the next character inside strings.
+	quotes   string
+	keywords map[string]bool
+}
+
+// keywordSet returns the set of the space-separated keywords.
+func keywordSet(keywords string) map[string]bool {
+	set := make(map[string]bool)
+	for _, keyword := range strings.Fields(keywords) {
+		set[keyword] = true
+	}
+	return set
+}
+
+var (
+	cSyntax = syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+	}
+	hashSyntax = syntax{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+	}
+)
+
+// syntaxes maps the languages of files.Language to their syntax. Files of other languages are
+// not highlighted.
+var syntaxes = map[string]syntax{
+	"go": withKeywords(syntax{lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: "\"'`"},
+		"break case chan const continue default defer else fallthrough for func go goto if import interface "+
+			"map package range return select struct switch type var nil true false iota"),
+	"python": withKeywords(hashSyntax,
+		"and as assert async await break class continue def del elif else except finally for from global if "+
+			"import in is lambda nonlocal not or pass raise return try while with yield None True False self"),
+	"javascript": withKeywords(withQuotes(cSyntax, "\"'`"), jsKeywords),
+	"jsx":        withKeywords(withQuotes(cSyntax, "\"'`"), jsKeywords),
+	"typescript": withKeywords(withQuotes(cSyntax, "\"'`"), jsKeywords+" "+tsKeywords),
+	"tsx":        withKeywords(withQuotes(cSyntax, "\"'`"), jsKeywords+" "+tsKeywords),
+	"rust": withKeywords(withQuotes(cSyntax, `"`),
+		"as async await break const continue crate dyn else enum extern false fn for if impl in let loop match mod "+
+			"move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+	"java": withKeywords(cSyntax, cFamilyKeywords+" abstract extends final implements import instanceof interface "+
+		"native new package private protected public super synchronized this throw throws try catch finally null true false"),
+	"kotlin": withKeywords(cSyntax, "as break class continue do else false for fun if import in interface is null "+
+		"object package return super this throw true try catch typealias val var when while"),
+	"scala": withKeywords(cSyntax, "case class def do else extends false final for if import lazy match new null "+
+		"object override package private protected return sealed super this throw trait true try type val var while with yield"),
+	"swift": withKeywords(cSyntax, "as break case class continue default defer do else enum extension false for func "+
+		"guard if import in init let nil protocol return self struct switch throw throws true try var where while"),
+	"c":      withKeywords(cSyntax, cFamilyKeywords+" extern sizeof typedef union unsigned signed NULL"),
+	"cpp":    withKeywords(cSyntax, cFamilyKeywords+" auto class delete namespace new nullptr private protected public template this throw try catch using virtual true false"),
+	"csharp": withKeywords(cSyntax, cFamilyKeywords+" abstract as async await base class namespace new null override private protected public readonly this throw try catch using var virtual true false"),
+	"dart":   withKeywords(cSyntax, cFamilyKeywords+" async await class extends final import late new null required this throw try catch var true false"),
+	"php": withKeywords(syntax{lineComments: []string{"//", "#"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`},
+		cFamilyKeywords+" array as class echo elseif extends foreach function namespace new null public private protected return static this throw try catch use true false"),
+	"protobuf": withKeywords(cSyntax, "enum import message option package repeated reserved returns rpc service syntax optional map oneof"),
+	"ruby": withKeywords(hashSyntax, "alias and begin break case class def do else elsif end ensure false for if in "+
+		"module next nil not or redo rescue retry return self super then true undef unless until when while yield"),
+	"bash": withKeywords(hashSyntax, shellKeywords),
+	"zsh":  withKeywords(hashSyntax, shellKeywords),
+	"fish": withKeywords(hashSyntax, "and begin break case continue else end for function if in not or return set switch while"),
+	"perl": withKeywords(hashSyntax, "else elsif for foreach if last local my next our package return sub unless until use while"),
+	"r":    withKeywords(hashSyntax, "break else FALSE for function if in NA next NULL repeat return TRUE while"),
+	"lua": withKeywords(syntax{lineComments: []string{"--"}, blockComment: [2]string{"--[[", "]]"}, quotes: `"'`},
+		"and break do else elseif end false for function goto if in local nil not or repeat return then true until while"),
+	"sql": withKeywords(syntax{lineComments: []string{"--"}, blockComment: [2]string{"/*", "*/"}, quotes: `'"`},
+		"select from where and or not insert into values update set delete create table drop alter index join left "+
+			"right inner outer on group by order having limit as distinct null is in primary key references "+
+			"SELECT FROM WHERE AND OR NOT INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE DROP ALTER INDEX JOIN LEFT "+
+			"RIGHT INNER OUTER ON GROUP BY ORDER HAVING LIMIT AS DISTINCT NULL IS IN PRIMARY KEY REFERENCES"),
+	"css":        {blockComment: [2]string{"/*", "*/"}, quotes: `"'`},
+	"scss":       cSyntax,
+	"yaml":       withKeywords(hashSyntax, "true false null yes no"),
+	"toml":       withKeywords(hashSyntax, "true false"),
+	"hcl":        withKeywords(syntax{lineComments: []string{"#", "//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"`}, "true false null for in if"),
+	"json":       withKeywords(syntax{quotes: `"`}, "true false null"),
+	"dockerfile": hashSyntax,
+	"makefile":   hashSyntax,
+	"awk":        withKeywords(hashSyntax, "BEGIN END if else for while do break continue function return next exit print printf"),
+	"tcl":        withKeywords(hashSyntax, "proc set if else elseif for foreach while return puts"),
+}
+
+const (
+	cFamilyKeywords = "break case char const continue default do double else enum float for goto if int long return short static struct switch void volatile while"
+	jsKeywords      = "async await break case catch class const continue debugger default delete do else export extends false finally for from function if import in instanceof let new null of return super switch this throw true try typeof undefined var void while yield"
+	tsKeywords      = "abstract any as boolean declare enum implements interface keyof namespace never number private protected public readonly string type unknown"
+	shellKeywords   = "case do done elif else esac export fi for function if in local return then until while"
+)
+
+// withKeywords returns s with the space-separated keywords.
+func withKeywords(s syntax, keywords string) syntax {
+	s.keywords = keywordSet(keywords)
+	return s
+}
+
+// withQuotes returns s with the string delimiters quotes.
+func withQuotes(s syntax, quotes string) syntax {
+	s.quotes = quotes
+	return s
+}
+
+// highlight returns content as HTML, with comments, strings, numbers and keywords of the language
+// enclosed in <span> elements with the classes "c", "s", "n" and "k". Content of a language
+// without known syntax is only escaped. The highlighting is lexical and does not parse the
+// content, so it can be off for constructs like nested or raw strings.
+func highlight(language, content string) string {
+	syn, ok := syntaxes[language]
+	if !ok {
+		return html.EscapeString(content)
+	}
+
+	var sb strings.Builder
+	span := func(class, text string) {
+		sb.WriteString(`<span class="` + class + `">` + html.EscapeString(text) + "</span>")
+	}
+	for i := 0; i < len(content); {
+		rest := content[i:]
+		if open, end := syn.blockComment[0], syn.blockComment[1]; open != "" && strings.HasPrefix(rest, open) {
+			n := strings.Index(rest[len(open):], end)
+			if n < 0 {
+				n = len(rest)
+			} else {
+				n += len(open) + len(end)
+			}
+			span("c", rest[:n])
+			i += n
+			continue
+		}
+		if hasAnyPrefix(rest, syn.lineComments) {
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				n = len(rest)
+			}
+			span("c", rest[:n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case strings.ContainsRune(syn.quotes, r):
+			n := stringLength(rest, r)
+			span("s", rest[:n])
+			i += n
+		case unicode.IsDigit(r):
+			n := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) && r != '.' })
+			if n < 0 {
+				n = len(rest)
+			}
+			span("n", rest[:n])
+			i += n
+		case isWordRune(r):
+			n := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) })
+			if n < 0 {
+				n = len(rest)
+			}
+			if syn.keywords[rest[:n]] {
+				span("k", rest[:n])
+			} else {
+				sb.WriteString(html.EscapeString(rest[:n]))
+			}
+			i += n
+		default:
+			sb.WriteString(html.EscapeString(rest[:size]))
+			i += size
+		}
+	}
+	return sb.String()
+}
+
+// stringLength returns the length of the string literal at the start of s, delimited by quote.
+// Strings quoted with " or ' end at the end of the line if they are not closed.
+func stringLength(s string, quote rune) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+		case rune(s[i]) == quote:
+			return i + 1
+		case s[i] == '\n' && quote != '`':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// isWordRune reports whether r can be part of an identifier or a number.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hasAnyPrefix reports whether s starts with one of the prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package formatting
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+)
+
+// htmlStyle is the stylesheet of the HTML format, embedded in the page so it is self-contained.
+const htmlStyle = `body { font-family: system-ui, sans-serif; margin: 2em auto; max-width: 70em; padding: 0 1em; color: #1f2328; background: #fff; }
+details { border: 1px solid #d0d7de; border-radius: 6px; margin: 0.5em 0; }
+summary { cursor: pointer; padding: 0.4em 0.8em; background: #f6f8fa; font-family: ui-monospace, monospace; }
+summary .meta { color: #59636e; font-family: system-ui, sans-serif; font-size: 0.85em; margin-left: 0.5em; }
+pre { margin: 0; padding: 0.8em; overflow-x: auto; font-family: ui-monospace, monospace; font-size: 0.85em; line-height: 1.45; }
+.c { color: #6e7781; font-style: italic; }
+.s { color: #0a3069; }
+.n { color: #0550ae; }
+.k { color: #cf222e; font-weight: 600; }
+@media (prefers-color-scheme: dark) {
+  body { color: #e6edf3; background: #0d1117; }
+  details { border-color: #30363d; }
+  summary { background: #161b22; }
+  summary .meta { color: #9198a1; }
+  .c { color: #9198a1; }
+  .s { color: #a5d6ff; }
+  .n { color: #79c0ff; }
+  .k { color: #ff7b72; }
+}
+`
+
+// WriteHTML writes a bundle as a self-contained HTML page to w, for human review. The project
+// tree, the custom sections, the appendices and each file are collapsible sections, in the order
+// of the sections, and the content of the files is highlighted by their language. Files without
+// content are left out, like in the plain text format.
+func WriteHTML(w io.Writer, b *Bundle) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>crev bundle</title>\n")
+	bw.WriteString("<style>\n" + htmlStyle + "</style>\n</head>\n<body>\n")
+	for _, section := range b.renderedSections() {
+		switch section.Kind {
+		case TreeSection:
+			if b.Tree != "" {
+				writeHTMLSection(bw, "Project Directory Structure", "", html.EscapeString(b.Tree), true)
+			}
+		case FilesSection:
+			for _, file := range b.Files {
+				if !hasTextContent(file) {
+					continue
+				}
+				meta := "1 line"
+				if lines := countLines(file.Content); lines != 1 {
+					meta = fmt.Sprintf("%d lines", lines)
+				}
+				writeHTMLSection(bw, file.Path, meta, highlight(fenceLanguage(file.Path, file.Content), file.Content), true)
+			}
+		case SymbolsSection:
+			if b.Symbols != "" {
+				writeHTMLSection(bw, "Symbol Index", "", html.EscapeString(b.Symbols), false)
+			}
+		case ImportsSection:
+			if b.Imports != "" {
+				writeHTMLSection(bw, "Import Graph", "", html.EscapeString(b.Imports), false)
+			}
+		case CustomSection:
+			writeHTMLSection(bw, section.Title, "", html.EscapeString(section.Content), true)
+		}
+	}
+	bw.WriteString("</body>\n</html>\n")
+	// bufio.Writer keeps the first error, so it is enough to check it on Flush
+	return bw.Flush()
+}
+
+// writeHTMLSection writes a collapsible section with the escaped title, the optional meta data
+// shown next to it, and the content, which must already be HTML.
+func writeHTMLSection(bw *bufio.Writer, title, meta, content string, open bool) {
+	bw.WriteString("<details")
+	if open {
+		bw.WriteString(" open")
+	}
+	bw.WriteString("><summary>" + html.EscapeString(title))
+	if meta != "" {
+		bw.WriteString(`<span class="meta">` + html.EscapeString(meta) + "</span>")
+	}
+	bw.WriteString("</summary>\n<pre><code>" + content + "</code></pre>\n</details>\n")
+}
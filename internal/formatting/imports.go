@@ -0,0 +1,60 @@
+package formatting
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/symbols"
+)
+
+// textImportsHeader is the heading of the import graph in the plain text format.
+const textImportsHeader = "Import Graph:" + "\n"
+
+// GenerateImportGraph returns the import graph of the files: each file importing other files of
+// the bundle and each imported file or Go package, followed by the indented lists of the modules
+// it imports and the files importing it, e.g.
+//
+//	cmd/root.go
+//	  imports: internal/files
+//	internal/files
+//	  imported by: cmd/root.go
+//
+// Imports of modules outside the bundle are left out, see symbols.Imports for goModule. The graph
+// is empty if no file imports another.
+func GenerateImportGraph(files []FileEntry, goModule string) string {
+	srcFiles := make([]symbols.File, len(files))
+	for i, file := range files {
+		srcFiles[i] = symbols.File{Path: file.Path, Content: file.Content}
+	}
+	imports := symbols.Imports(srcFiles, goModule)
+
+	importedBy := make(map[string][]string)
+	for importer, targets := range imports {
+		for _, target := range targets {
+			importedBy[target] = append(importedBy[target], importer)
+		}
+	}
+	nodes := make([]string, 0, len(imports)+len(importedBy))
+	for node := range imports {
+		nodes = append(nodes, node)
+	}
+	for node := range importedBy {
+		if _, ok := imports[node]; !ok {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+
+	var sb strings.Builder
+	for _, node := range nodes {
+		sb.WriteString(node + "\n")
+		if targets := imports[node]; len(targets) > 0 {
+			sb.WriteString("  imports: " + strings.Join(targets, ", ") + "\n")
+		}
+		if importers := importedBy[node]; len(importers) > 0 {
+			sort.Strings(importers)
+			sb.WriteString("  imported by: " + strings.Join(importers, ", ") + "\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,71 @@
+package formatting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IndexEntry locates the content of a single file inside a bundle in the plain text format.
+type IndexEntry struct {
+	Path string `json:"path"`
+	// Offset and Length are the position of the content in bytes.
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+	// Line is the 1-based line the content starts on and Lines the number of lines it spans.
+	Line  int `json:"line"`
+	Lines int `json:"lines"`
+	// SHA256 is the checksum of the file when it was bundled, to detect changes since.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Index maps the files of a bundle to their position in the rendered bundle.
+type Index struct {
+	Bundle string `json:"bundle"`
+	// Dir is the absolute directory the relative paths of the files are resolved against: the
+	// bundled root directory for bundles with relative paths, and the working directory of the
+	// bundle run otherwise. Indexes written before it was recorded leave it empty.
+	Dir   string       `json:"dir,omitempty"`
+	Files []IndexEntry `json:"files"`
+}
+
+// NewIndex creates the index of a bundle in the plain text format, as written by WriteText.
+// Files without content are not part of the rendered bundle and are left out.
+func NewIndex(bundleName string, b *Bundle) *Index {
+	index := &Index{Bundle: bundleName, Files: []IndexEntry{}}
+
+	offset, line := 0, 1
+	renderText(b, func(text string, file *FileEntry) {
+		if file != nil {
+			index.Files = append(index.Files, IndexEntry{
+				Path:   file.Path,
+				Offset: offset,
+				Length: len(file.Content),
+				Line:   line,
+				Lines:  strings.Count(strings.TrimSuffix(file.Content, "\n"), "\n") + 1,
+				SHA256: file.SHA256,
+			})
+		}
+		offset += len(text)
+		line += strings.Count(text, "\n")
+	})
+
+	return index
+}
+
+// WriteJSON writes the index as indented JSON to w.
+func (i *Index) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(i)
+}
+
+// ReadIndex reads an index written by WriteJSON from r.
+func ReadIndex(r io.Reader) (*Index, error) {
+	var index Index
+	if err := json.NewDecoder(r).Decode(&index); err != nil {
+		return nil, fmt.Errorf("invalid index: %w", err)
+	}
+	return &index, nil
+}
@@ -0,0 +1,81 @@
+package formatting
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/devinbarry/crev/internal/budget"
+)
+
+// JSONDocument is a bundle in the JSON format, a single document for tooling that post-processes
+// bundles. The content of the files is not wrapped in code fences or untrusted content markers.
+type JSONDocument struct {
+	// Tree is the project tree, omitted if the bundle has none.
+	Tree  string     `json:"tree,omitempty"`
+	Files []JSONFile `json:"files"`
+	// Sections are the custom sections of the bundle, e.g. review instructions.
+	Sections []JSONSection `json:"sections,omitempty"`
+	Symbols  string        `json:"symbols,omitempty"`
+	Imports  string        `json:"imports,omitempty"`
+	Stats    JSONStats     `json:"stats"`
+}
+
+// JSONFile is a file of a bundle in the JSON format.
+type JSONFile struct {
+	Path string `json:"path"`
+	Size int    `json:"size"`
+	// Tokens is the upper estimate of the number of tokens of the content.
+	Tokens  int    `json:"tokens"`
+	SHA256  string `json:"sha256,omitempty"`
+	Content string `json:"content"`
+}
+
+// JSONSection is a custom section of a bundle in the JSON format.
+type JSONSection struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// JSONStats summarizes the files of a bundle in the JSON format.
+type JSONStats struct {
+	Files  int `json:"files"`
+	Bytes  int `json:"bytes"`
+	Tokens int `json:"tokens"`
+}
+
+// NewJSONDocument creates the JSON document of a bundle. Unlike the plain text format, it lists
+// the files without content too.
+func NewJSONDocument(b *Bundle) *JSONDocument {
+	doc := &JSONDocument{
+		Tree:    b.Tree,
+		Files:   make([]JSONFile, 0, len(b.Files)),
+		Symbols: b.Symbols,
+		Imports: b.Imports,
+	}
+	for _, file := range b.Files {
+		doc.Files = append(doc.Files, JSONFile{
+			Path:    file.Path,
+			Size:    file.Size,
+			Tokens:  budget.Tokens(len(file.Content)),
+			SHA256:  file.SHA256,
+			Content: file.Content,
+		})
+		doc.Stats.Bytes += len(file.Content)
+	}
+	doc.Stats.Files = len(doc.Files)
+	doc.Stats.Tokens = budget.Tokens(doc.Stats.Bytes)
+	for _, section := range b.Sections {
+		if section.Kind == CustomSection {
+			doc.Sections = append(doc.Sections, JSONSection{Title: section.Title, Content: section.Content})
+		}
+	}
+	return doc
+}
+
+// WriteJSON writes a bundle as an indented JSON document to w.
+func WriteJSON(w io.Writer, b *Bundle) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewJSONDocument(b))
+}
@@ -0,0 +1,70 @@
+package formatting
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/devinbarry/crev/internal/files"
+)
+
+// NDJSONRecord is a file of a bundle in the NDJSON format, where each line is the JSON object of
+// one file, so bundles can be processed file by file without loading the whole document.
+type NDJSONRecord struct {
+	Path     string `json:"path"`
+	Size     int    `json:"size"`
+	Lines    int    `json:"lines"`
+	Language string `json:"language,omitempty"`
+	Mode     string `json:"mode,omitempty"`
+	// ModTime is the time of the last modification in RFC 3339 format.
+	ModTime string `json:"mod_time,omitempty"`
+	// Coverage is the test coverage percentage of the file, if a coverage report is given.
+	Coverage *float64 `json:"coverage,omitempty"`
+	Content  string   `json:"content"`
+}
+
+// NewNDJSONRecord returns the record of a file of the bundle with the metadata of its entry.
+// Size and Lines describe the content as bundled, after redaction and truncation.
+func NewNDJSONRecord(filePath, content string, entry files.Entry) NDJSONRecord {
+	record := NDJSONRecord{
+		Path:     filePath,
+		Size:     len(content),
+		Lines:    countLines(content),
+		Language: files.Language(filePath),
+		Content:  content,
+	}
+	if entry.Mode != 0 {
+		record.Mode = entry.Mode.String()
+	}
+	if !entry.ModTime.IsZero() {
+		record.ModTime = entry.ModTime.UTC().Format(time.RFC3339)
+	}
+	return record
+}
+
+// NDJSONWriter writes NDJSON records, one line each.
+type NDJSONWriter struct {
+	enc *json.Encoder
+}
+
+// NewNDJSONWriter returns a writer of NDJSON records to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &NDJSONWriter{enc: enc}
+}
+
+// Write writes record as one line.
+func (w *NDJSONWriter) Write(record NDJSONRecord) error {
+	return w.enc.Encode(record)
+}
+
+// countLines returns the number of lines of content, including a last line without newline.
+func countLines(content string) int {
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
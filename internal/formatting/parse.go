@@ -0,0 +1,101 @@
+package formatting
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// sectionHeaderRegex matches the headers written before the project tree, the symbol index, the
+// content of each file and each custom section in the text format.
+var sectionHeaderRegex = regexp.MustCompile(`(?m)^(?:(Project Directory Structure|Symbol Index|Import Graph):\n|(File|Section): \n(.+)\nContent: \n)`)
+
+// ParseText parses a bundle rendered in the plain text format back into a Bundle.
+// Sections are recognised by their headers, e.g. "File:" and "Content:", so a file whose content
+// itself contains such a header can not be recovered exactly. Code fences wrapped around the
+// content of files and untrusted content markers are removed. The project tree section is
+// optional, a bundle rendered without it has an empty Tree. The order of the sections and the
+// custom sections are kept in Sections unless they are the default ones.
+func ParseText(content string) (*Bundle, error) {
+	headers := sectionHeaderRegex.FindAllStringSubmatchIndex(content, -1)
+	if len(headers) == 0 || headers[0][0] != 0 {
+		return nil, fmt.Errorf("not a crev bundle: missing %q header", strings.TrimSpace(textTreeHeader))
+	}
+
+	b := &Bundle{}
+	var sections []Section
+	for i, header := range headers {
+		contentEnd := len(content)
+		if i+1 < len(headers) {
+			contentEnd = headers[i+1][0]
+		}
+		sectionContent := content[header[1]:contentEnd]
+
+		if header[2] >= 0 {
+			text := strings.TrimRight(sectionContent, "\n") + "\n"
+			switch content[header[2]:header[3]] + ":\n" {
+			case textSymbolsHeader:
+				b.Symbols = text
+				sections = append(sections, Section{Kind: SymbolsSection})
+			case textImportsHeader:
+				b.Imports = text
+				sections = append(sections, Section{Kind: ImportsSection})
+			default:
+				b.Tree = text
+				sections = append(sections, Section{Kind: TreeSection})
+			}
+			continue
+		}
+
+		// Each section's content is followed by a blank line, file contents may be fenced
+		sectionContent = strings.TrimSuffix(sectionContent, "\n\n")
+		title := content[header[6]:header[7]]
+		if content[header[4]:header[5]] == "Section" {
+			sections = append(sections, Section{Kind: CustomSection, Title: title, Content: sectionContent})
+			continue
+		}
+		if len(sections) == 0 || sections[len(sections)-1].Kind != FilesSection {
+			sections = append(sections, Section{Kind: FilesSection})
+		}
+		sectionContent = unfence(unwrapSentinel(sectionContent))
+		b.Files = append(b.Files, FileEntry{
+			Path:    title,
+			Size:    len(sectionContent),
+			Content: sectionContent,
+		})
+		b.Stats.FileCount++
+		b.Stats.TotalBytes += len(sectionContent)
+	}
+
+	if !isDefaultLayout(sections) {
+		b.Sections = sections
+	}
+	return b, nil
+}
+
+// isDefaultLayout reports whether the parsed sections are rendered like DefaultSections,
+// possibly without project tree and followed by the symbol index and the import graph.
+func isDefaultLayout(sections []Section) bool {
+	appendices := 0
+	for i, section := range sections {
+		switch section.Kind {
+		case CustomSection:
+			return false
+		case TreeSection:
+			if i > 0 {
+				return false
+			}
+		case SymbolsSection, ImportsSection:
+			appendices++
+		default:
+			if appendices > 0 {
+				return false
+			}
+		}
+	}
+	// The symbol index is rendered before the import graph
+	if appendices == 2 && sections[len(sections)-1].Kind != ImportsSection {
+		return false
+	}
+	return true
+}
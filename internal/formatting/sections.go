@@ -0,0 +1,140 @@
+package formatting
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SectionKind is the kind of a section of a rendered bundle.
+type SectionKind string
+
+const (
+	// TreeSection is the project tree.
+	TreeSection SectionKind = "tree"
+	// FilesSection is the content of the files.
+	FilesSection SectionKind = "files"
+	// SymbolsSection is the symbol index of the files.
+	SymbolsSection SectionKind = "symbols"
+	// ImportsSection is the import graph of the files.
+	ImportsSection SectionKind = "imports"
+	// CustomSection is a static section from the config, e.g. review instructions.
+	CustomSection SectionKind = "custom"
+)
+
+// Section is a section of a rendered bundle. Title and Content are only used by custom sections.
+type Section struct {
+	Kind    SectionKind
+	Title   string
+	Content string
+}
+
+// DefaultSections is the order of the sections of a bundle without configured sections.
+var DefaultSections = []Section{{Kind: TreeSection}, {Kind: FilesSection}}
+
+// ValidateSections checks that sections only contain known kinds, the built-in sections at most
+// once, and custom sections with a title.
+func ValidateSections(sections []Section) error {
+	seen := make(map[SectionKind]bool)
+	for _, section := range sections {
+		switch section.Kind {
+		case TreeSection, FilesSection, SymbolsSection, ImportsSection:
+			if seen[section.Kind] {
+				return fmt.Errorf("section %q is listed more than once", section.Kind)
+			}
+			seen[section.Kind] = true
+		case CustomSection:
+			if section.Title == "" {
+				return fmt.Errorf("custom section without title")
+			}
+		default:
+			return fmt.Errorf("unknown section %q (supported: tree, files, symbols, imports or a custom section)", section.Kind)
+		}
+	}
+	return nil
+}
+
+// textSectionHeader returns the lines preceding the content of a custom section in the plain text format.
+func textSectionHeader(title string) string {
+	return "Section: " + "\n" + title + "\n" + "Content: " + "\n"
+}
+
+// contentDelimiters returns the text written before and after the content of a file: the code
+// fence and the untrusted content markers, if enabled. The content is always followed by a newline
+// before a closing delimiter, so the trailing newline of the content is kept when the bundle is parsed.
+func (b *Bundle) contentDelimiters(file FileEntry) (opening, closing string) {
+	if b.Fence != nil {
+		fence := b.Fence.fenceFor(file.Content)
+		language := ""
+		if b.Fence.Language {
+			language = fenceLanguage(file.Path, file.Content)
+		}
+		opening, closing = fence+language+"\n", "\n"+fence
+	}
+	if b.Sentinel != nil {
+		opening = b.Sentinel.Open() + "\n" + opening
+		closing = closing + "\n" + b.Sentinel.Close()
+	}
+	return opening, closing
+}
+
+// renderedSections returns the sections of a bundle in the order they are rendered: the
+// untrusted content notice, the configured sections, or DefaultSections, and the generated
+// appendices that are not placed by the configured sections.
+func (b *Bundle) renderedSections() []Section {
+	sections := b.Sections
+	if len(sections) == 0 {
+		sections = DefaultSections
+	}
+	if b.Sentinel != nil {
+		sections = append([]Section{b.Sentinel.Notice()}, sections...)
+	}
+	// The generated appendices are rendered at the end unless they are placed by the sections
+	for _, appendix := range []struct {
+		kind SectionKind
+		text string
+	}{{SymbolsSection, b.Symbols}, {ImportsSection, b.Imports}} {
+		if appendix.text != "" && !slices.ContainsFunc(sections, func(s Section) bool { return s.Kind == appendix.kind }) {
+			sections = append(slices.Clip(sections), Section{Kind: appendix.kind})
+		}
+	}
+	return sections
+}
+
+// renderText passes the plain text format of a bundle to emit, piece by piece. The content of
+// each file is passed separately, together with the file, so its position can be recorded.
+func renderText(b *Bundle, emit func(text string, file *FileEntry)) {
+	for _, section := range b.renderedSections() {
+		switch section.Kind {
+		case TreeSection:
+			if b.Tree != "" {
+				emit(textTreeHeader, nil)
+				emit(b.Tree+"\n\n", nil)
+			}
+		case FilesSection:
+			for i := range b.Files {
+				file := &b.Files[i]
+				// Skip displaying the file if it has no content
+				if !hasTextContent(*file) {
+					continue
+				}
+				opening, closing := b.contentDelimiters(*file)
+				emit(textFileHeader(file.Path)+opening, nil)
+				emit(file.Content, file)
+				emit(closing+"\n\n", nil)
+			}
+		case SymbolsSection:
+			if b.Symbols != "" {
+				emit(textSymbolsHeader, nil)
+				emit(b.Symbols+"\n", nil)
+			}
+		case ImportsSection:
+			if b.Imports != "" {
+				emit(textImportsHeader, nil)
+				emit(b.Imports+"\n", nil)
+			}
+		case CustomSection:
+			emit(textSectionHeader(section.Title), nil)
+			emit(section.Content+"\n\n", nil)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package formatting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/symbols"
+)
+
+// textSymbolsHeader is the heading of the symbol index in the plain text format.
+const textSymbolsHeader = "Symbol Index:" + "\n"
+
+// GenerateSymbolIndex returns the symbol index of the files: the path of each file defining
+// symbols, followed by one indented line per symbol with its kind, name and line, e.g.
+// "  func main (line 3)". Files without symbols are left out, the index is empty if no file
+// defines symbols.
+func GenerateSymbolIndex(files []FileEntry) string {
+	var sb strings.Builder
+	for _, file := range files {
+		fileSymbols := symbols.Extract(file.Path, file.Content)
+		if len(fileSymbols) == 0 {
+			continue
+		}
+		sb.WriteString(file.Path + "\n")
+		for _, symbol := range fileSymbols {
+			sb.WriteString(fmt.Sprintf("  %s %s (line %d)\n", symbol.Kind, symbol.Name, symbol.Line))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package formatting
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tree node types
+const (
+	TreeNodeFile = "file"
+	TreeNodeDir  = "dir"
+)
+
+// TreeNode is a file or directory in the machine readable project tree.
+type TreeNode struct {
+	Name string `json:"name" yaml:"name"`
+	Path string `json:"path" yaml:"path"`
+	Type string `json:"type" yaml:"type"`
+	// Size is the size of a file in bytes, or the total size of the files beneath a directory.
+	Size     int64       `json:"size" yaml:"size"`
+	Children []*TreeNode `json:"children,omitempty" yaml:"children,omitempty"`
+	// Collapsed is the number of files beneath a directory whose children were removed by Collapse.
+	Collapsed int `json:"collapsed,omitempty" yaml:"collapsed,omitempty"`
+}
+
+// BuildTree creates the nested project tree from a list of paths and the sizes of the files
+// among them. Paths that are not in sizes, or that have other paths beneath them, are directories.
+// The children of each directory are sorted by name, like in GeneratePathTree.
+func BuildTree(rootName string, paths []string, sizes map[string]int64) *TreeNode {
+	root := &TreeNode{Name: rootName, Path: ".", Type: TreeNodeDir}
+	nodes := map[string]*TreeNode{".": root}
+
+	for _, path := range paths {
+		cleanedPath := filepath.ToSlash(filepath.Clean(path))
+		if cleanedPath == "." {
+			continue
+		}
+		parent := root
+		parts := strings.Split(cleanedPath, "/")
+		for i, part := range parts {
+			nodePath := strings.Join(parts[:i+1], "/")
+			n, exists := nodes[nodePath]
+			if !exists {
+				n = &TreeNode{Name: part, Path: nodePath, Type: TreeNodeFile}
+				nodes[nodePath] = n
+				parent.Children = append(parent.Children, n)
+			}
+			parent = n
+		}
+	}
+
+	finishTree(root, sizes)
+	return root
+}
+
+// finishTree sets the type and size of n and its descendants and sorts their children.
+func finishTree(n *TreeNode, sizes map[string]int64) {
+	size, isFile := sizes[n.Path]
+	if len(n.Children) > 0 || !isFile {
+		n.Type = TreeNodeDir
+		size = 0
+	}
+
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, child := range n.Children {
+		finishTree(child, sizes)
+		size += child.Size
+	}
+	n.Size = size
+}
+
+// Collapse removes the children of the directories deeper than depth levels below n, recording
+// the number of files they contained. A depth of 0 or less leaves the tree unchanged.
+func (n *TreeNode) Collapse(depth int) {
+	if depth <= 0 {
+		return
+	}
+	for _, child := range n.Children {
+		if depth == 1 && len(child.Children) > 0 {
+			child.Collapsed = child.countFiles()
+			child.Children = nil
+			continue
+		}
+		child.Collapse(depth - 1)
+	}
+}
+
+// countFiles returns the number of files beneath n.
+func (n *TreeNode) countFiles() int {
+	count := n.Collapsed
+	for _, child := range n.Children {
+		if child.Type == TreeNodeFile {
+			count++
+		} else {
+			count += child.countFiles()
+		}
+	}
+	return count
+}
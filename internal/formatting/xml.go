@@ -0,0 +1,96 @@
+package formatting
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteXML writes a bundle with XML document tags to w, the layout recommended for long documents
+// in prompts: each file is enclosed in a <document> tag with its 1-based index and path, inside a
+// <documents> tag, e.g.
+//
+//	<documents>
+//	<document index="1" path="main.go">
+//	<![CDATA[package main
+//	]]>
+//	</document>
+//	</documents>
+//
+// The project tree, custom sections and appendices are enclosed in tags too, in the order of the
+// sections. The content is written in CDATA sections, see writeCDATA, so a file containing
+// "</document>" cannot close its tag, and the attributes are escaped. With a Sentinel the content
+// of each file is enclosed in its markers inside the CDATA section. Files without content are left
+// out, like in the plain text format.
+func WriteXML(w io.Writer, b *Bundle) error {
+	bw := bufio.NewWriter(w)
+	for _, section := range b.renderedSections() {
+		switch section.Kind {
+		case TreeSection:
+			if b.Tree != "" {
+				writeXMLElement(bw, "project_tree", "", b.Tree)
+			}
+		case FilesSection:
+			bw.WriteString("<documents>\n")
+			index := 0
+			for _, file := range b.Files {
+				if !hasTextContent(file) {
+					continue
+				}
+				index++
+				bw.WriteString(`<document index="` + strconv.Itoa(index) + `" path="` + escapeXMLAttr(file.Path) + `">` + "\n")
+				content := withTrailingNewline(file.Content)
+				if b.Sentinel != nil {
+					content = b.Sentinel.Open() + "\n" + content + b.Sentinel.Close() + "\n"
+				}
+				writeCDATA(bw, content)
+				bw.WriteString("</document>\n")
+			}
+			bw.WriteString("</documents>\n\n")
+		case SymbolsSection:
+			if b.Symbols != "" {
+				writeXMLElement(bw, "symbol_index", "", b.Symbols)
+			}
+		case ImportsSection:
+			if b.Imports != "" {
+				writeXMLElement(bw, "import_graph", "", b.Imports)
+			}
+		case CustomSection:
+			writeXMLElement(bw, "section", ` title="`+escapeXMLAttr(section.Title)+`"`, section.Content)
+		}
+	}
+	// bufio.Writer keeps the first error, so it is enough to check it on Flush
+	return bw.Flush()
+}
+
+// writeXMLElement writes content enclosed in the tag name with the given attributes, followed by
+// an empty line.
+func writeXMLElement(bw *bufio.Writer, name, attrs, content string) {
+	bw.WriteString("<" + name + attrs + ">\n")
+	writeCDATA(bw, withTrailingNewline(content))
+	bw.WriteString("</" + name + ">\n\n")
+}
+
+// writeCDATA writes content as a CDATA section on its own lines, so markup in it is not taken for
+// tags. A "]]>" in content would end the section, so it is split across two sections.
+func writeCDATA(bw *bufio.Writer, content string) {
+	bw.WriteString("<![CDATA[" + strings.ReplaceAll(content, "]]>", "]]]]><![CDATA[>") + "]]>\n")
+}
+
+// withTrailingNewline returns content ending with a newline, so closing tags start on their own line.
+func withTrailingNewline(content string) string {
+	if strings.HasSuffix(content, "\n") {
+		return content
+	}
+	return content + "\n"
+}
+
+// escapeXMLAttr escapes s for use in a double-quoted XML attribute.
+func escapeXMLAttr(s string) string {
+	var sb strings.Builder
+	// Writing to a strings.Builder never fails
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
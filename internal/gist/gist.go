@@ -0,0 +1,82 @@
+// Package gist publishes bundles as GitHub gists.
+package gist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultURL is the endpoint of the GitHub API creating gists.
+const DefaultURL = "https://api.github.com/gists"
+
+// httpClient is the client used to create gists
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// Gist is a gist to create.
+type Gist struct {
+	Description string
+	// Public makes the gist listed on the profile of its owner, gists are secret by default.
+	Public bool
+	// Files maps the names of the files of the gist to their content.
+	Files map[string]string
+}
+
+// createRequest is the body of a request creating a gist
+type createRequest struct {
+	Description string                 `json:"description"`
+	Public      bool                   `json:"public"`
+	Files       map[string]fileContent `json:"files"`
+}
+
+type fileContent struct {
+	Content string `json:"content"`
+}
+
+// createResponse contains the fields used from the response creating a gist
+type createResponse struct {
+	HTMLURL string `json:"html_url"`
+}
+
+// Create creates g with the API at apiURL, authenticated with token, and returns the URL of the
+// created gist. The token needs the gist scope.
+func Create(apiURL, token string, g Gist) (string, error) {
+	body := createRequest{Description: g.Description, Public: g.Public, Files: make(map[string]fileContent)}
+	for name, content := range g.Files {
+		body.Files[name] = fileContent{Content: content}
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to create gist: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode == http.StatusUnauthorized {
+			return "", fmt.Errorf("unable to create gist: invalid GitHub token (%s)", resp.Status)
+		}
+		return "", fmt.Errorf("unable to create gist: %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+
+	var created createResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("unable to decode the created gist: %w", err)
+	}
+	return created.HTMLURL, nil
+}
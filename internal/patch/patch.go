@@ -0,0 +1,309 @@
+// Package patch extracts file changes suggested in the answer of a model, as unified diffs or
+// full file replacements, and applies them to the content of files.
+package patch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of change made to a file.
+type Kind string
+
+const (
+	// Modify changes an existing file with the hunks of a unified diff.
+	Modify Kind = "modify"
+	// Replace replaces the whole content of a file, creating it if needed.
+	Replace Kind = "replace"
+	// Create creates a new file from a unified diff against /dev/null.
+	Create Kind = "create"
+	// Delete removes a file, from a unified diff against /dev/null.
+	Delete Kind = "delete"
+)
+
+// Change is a change to a single file.
+type Change struct {
+	Kind Kind
+	// Path is the slash-separated path of the file, without the a/ and b/ prefixes of git diffs.
+	Path string
+	// Hunks are the hunks of a unified diff, for Modify and Create changes.
+	Hunks []Hunk
+	// Content is the new content of the file, for Replace changes.
+	Content string
+}
+
+// Hunk is a hunk of a unified diff.
+type Hunk struct {
+	// OldStart is the line of the original file the hunk starts at, starting at 1.
+	OldStart int
+	// Lines are the lines of the hunk, each starting with ' ', '-' or '+'.
+	Lines []string
+}
+
+var (
+	fencePattern    = regexp.MustCompile("^\\s*(```+|~~~+)\\s*(\\S*)\\s*(.*)$")
+	hunkPattern     = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+	pathLinePattern = regexp.MustCompile("^(?:#+\\s*)?(?:File:\\s*)?[*_`]*([\\w./-]+)[*_`]*:?$")
+)
+
+// Extract returns the changes in text. Unified diffs are found anywhere in the text, in fenced
+// diff blocks or not. A fenced code block of another language is a full file replacement if the
+// path of the file is given after the language (```go path/to/file.go) or on the line before
+// the block (e.g. "File: path/to/file.go" or "**path/to/file.go**").
+func Extract(text string) ([]Change, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var changes []Change
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// Unified diff outside of a fenced block
+		if isDiffHeader(lines, i) {
+			change, next, err := parseDiff(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+			i = next - 1
+			continue
+		}
+
+		// Full file replacement
+		match := fencePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		end := closingFence(lines, i+1, match[1])
+		lang := strings.ToLower(match[2])
+		if lang == "diff" || lang == "patch" {
+			diffs, err := extractDiffs(lines[i+1 : end])
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, diffs...)
+			i = end
+			continue
+		}
+		path := strings.TrimSpace(match[3])
+		if path == "" && i > 0 {
+			if pathMatch := pathLinePattern.FindStringSubmatch(strings.TrimSpace(lines[i-1])); pathMatch != nil && looksLikePath(pathMatch[1]) {
+				path = pathMatch[1]
+			}
+		}
+		if path != "" && looksLikePath(path) {
+			content := strings.Join(lines[i+1:end], "\n")
+			if end > i+1 {
+				content += "\n"
+			}
+			changes = append(changes, Change{Kind: Replace, Path: path, Content: content})
+		}
+		i = end
+	}
+
+	for _, change := range changes {
+		if err := validatePath(change.Path); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// extractDiffs returns the unified diffs in lines
+func extractDiffs(lines []string) ([]Change, error) {
+	var changes []Change
+	for i := 0; i < len(lines); {
+		if !isDiffHeader(lines, i) {
+			i++
+			continue
+		}
+		change, next, err := parseDiff(lines, i)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+		i = next
+	}
+	return changes, nil
+}
+
+// isDiffHeader reports whether lines[i] starts the ---/+++ header of a unified diff
+func isDiffHeader(lines []string, i int) bool {
+	return strings.HasPrefix(lines[i], "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
+
+// closingFence returns the index of the line closing a fenced block opened with fence,
+// or the number of lines if the block is not closed.
+func closingFence(lines []string, start int, fence string) int {
+	for j := start; j < len(lines); j++ {
+		line := strings.TrimSpace(lines[j])
+		if strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == "" {
+			return j
+		}
+	}
+	return len(lines)
+}
+
+// looksLikePath reports whether s is plausibly a file path rather than a word of prose
+func looksLikePath(s string) bool {
+	return !strings.ContainsAny(s, " \t") && (strings.Contains(s, "/") || strings.Contains(strings.TrimPrefix(s, "."), "."))
+}
+
+// validatePath rejects paths that would change files outside of the current directory
+func validatePath(path string) error {
+	if path == "" || strings.HasPrefix(path, "/") || path == ".." || strings.HasPrefix(path, "../") || strings.Contains(path, "/../") {
+		return fmt.Errorf("refusing to change a file outside of the current directory: %q", path)
+	}
+	return nil
+}
+
+// diffPath returns the path of a ---/+++ header line, without the a/ or b/ prefix and timestamp
+func diffPath(header string) string {
+	path := strings.TrimSpace(header[4:])
+	if tab := strings.IndexByte(path, '\t'); tab >= 0 {
+		path = path[:tab]
+	}
+	if path == "/dev/null" {
+		return path
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
+
+// parseDiff parses the unified diff of a single file starting at lines[start], and returns
+// the change and the index of the first line after it.
+func parseDiff(lines []string, start int) (Change, int, error) {
+	oldPath, newPath := diffPath(lines[start]), diffPath(lines[start+1])
+	change := Change{Kind: Modify, Path: newPath}
+	switch {
+	case oldPath == "/dev/null":
+		change.Kind = Create
+	case newPath == "/dev/null":
+		change.Kind, change.Path = Delete, oldPath
+	}
+
+	i := start + 2
+	for i < len(lines) {
+		match := hunkPattern.FindStringSubmatch(lines[i])
+		if match == nil {
+			break
+		}
+		hunk := Hunk{}
+		hunk.OldStart, _ = strconv.Atoi(match[1])
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if line == "" {
+				// Models often drop the space of empty context lines
+				line = " "
+			}
+			if line[0] != ' ' && line[0] != '-' && line[0] != '+' && line[0] != '\\' {
+				break
+			}
+			if isDiffHeader(lines, i) {
+				break
+			}
+			if line[0] != '\\' {
+				hunk.Lines = append(hunk.Lines, line)
+			}
+			i++
+		}
+		// Trailing empty lines belong to the text after the diff
+		for len(hunk.Lines) > 0 && hunk.Lines[len(hunk.Lines)-1] == " " && lines[i-1] == "" {
+			hunk.Lines = hunk.Lines[:len(hunk.Lines)-1]
+			i--
+		}
+		change.Hunks = append(change.Hunks, hunk)
+	}
+	if len(change.Hunks) == 0 && change.Kind != Delete {
+		return Change{}, 0, fmt.Errorf("diff of %s has no hunks", change.Path)
+	}
+	return change, i, nil
+}
+
+// ConflictError is returned when a hunk does not match the content of the file.
+type ConflictError struct {
+	Path string
+	Hunk int
+	Line int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: hunk %d does not apply at line %d", e.Path, e.Hunk, e.Line)
+}
+
+// Apply returns original with the change applied. A hunk is applied at its line if the context
+// and removed lines match there, otherwise at the nearest line where they match. A hunk that
+// matches nowhere is a conflict, returned as a *ConflictError.
+func Apply(change Change, original string) (string, error) {
+	switch change.Kind {
+	case Replace:
+		return change.Content, nil
+	case Delete:
+		return "", nil
+	}
+
+	var lines []string
+	if original != "" {
+		lines = strings.Split(strings.TrimSuffix(original, "\n"), "\n")
+	}
+
+	offset := 0 // lines added minus lines removed by the previous hunks
+	for n, hunk := range change.Hunks {
+		var oldLines, newLines []string
+		for _, line := range hunk.Lines {
+			switch line[0] {
+			case ' ':
+				oldLines = append(oldLines, line[1:])
+				newLines = append(newLines, line[1:])
+			case '-':
+				oldLines = append(oldLines, line[1:])
+			case '+':
+				newLines = append(newLines, line[1:])
+			}
+		}
+
+		expected := hunk.OldStart - 1 + offset
+		if len(oldLines) == 0 && hunk.OldStart > 0 {
+			// A pure addition after line OldStart
+			expected++
+		}
+		at := findLines(lines, oldLines, max(expected, 0))
+		if at < 0 {
+			return "", &ConflictError{Path: change.Path, Hunk: n + 1, Line: hunk.OldStart}
+		}
+		lines = append(lines[:at], append(newLines, lines[at+len(oldLines):]...)...)
+		offset += len(newLines) - len(oldLines)
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// findLines returns the index of the occurrence of block in lines nearest to expected, or -1
+func findLines(lines, block []string, expected int) int {
+	matches := func(at int) bool {
+		if at < 0 || at+len(block) > len(lines) {
+			return false
+		}
+		for i, line := range block {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+	for distance := 0; distance <= len(lines); distance++ {
+		if matches(expected - distance) {
+			return expected - distance
+		}
+		if matches(expected + distance) {
+			return expected + distance
+		}
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+// Package redact scrubs file content with user defined regex replacement rules.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Rule is a single redaction rule from the "redact" config section. Every match of Pattern is
+// replaced with Replacement, which may reference capture groups with $1 or ${name}.
+type Rule struct {
+	Pattern     string `mapstructure:"pattern"`
+	Replacement string `mapstructure:"replacement"`
+}
+
+// Redactor applies a list of compiled redaction rules to content.
+type Redactor struct {
+	patterns     []*regexp.Regexp
+	replacements []string
+}
+
+// New compiles the given rules into a Redactor.
+func New(rules []Rule) (*Redactor, error) {
+	r := &Redactor{}
+	for _, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("redact rule with replacement %q has an empty pattern", rule.Replacement)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", rule.Pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+		r.replacements = append(r.replacements, rule.Replacement)
+	}
+	return r, nil
+}
+
+// Apply returns content with all rules applied in order.
+func (r *Redactor) Apply(content string) string {
+	for i, re := range r.patterns {
+		content = re.ReplaceAllString(content, r.replacements[i])
+	}
+	return content
+}
+
+// ApplyAll applies the rules to every value of fileContentMap in place.
+func (r *Redactor) ApplyAll(fileContentMap map[string]string) {
+	if len(r.patterns) == 0 {
+		return
+	}
+	for path, content := range fileContentMap {
+		fileContentMap[path] = r.Apply(content)
+	}
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Log formats supported by NewLogger.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// NewLogger creates the logger used to report the progress and diagnostics of a run.
+//
+// The text format prints only the message of each event through the standard log package,
+// prefixing warnings with "Warning: ", and shows debug events only if verbose is true.
+// The json format writes every event, including debug events, as one JSON object per line to w
+// so that wrappers and CI systems can parse them.
+func NewLogger(w io.Writer, format string, verbose bool) (*slog.Logger, error) {
+	switch format {
+	case "", LogFormatText:
+		level := slog.LevelInfo
+		if verbose {
+			level = slog.LevelDebug
+		}
+		return slog.New(&textHandler{logger: log.Default(), level: level}), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q (supported: %s, %s)", format, LogFormatText, LogFormatJSON)
+	}
+}
+
+// CountWarnings returns a logger passing all events to the handler of logger, and a function
+// returning the number of warnings and errors logged through it so far.
+func CountWarnings(logger *slog.Logger) (*slog.Logger, func() int) {
+	h := &countingHandler{Handler: logger.Handler(), count: &atomic.Int64{}}
+	return slog.New(h), func() int { return int(h.count.Load()) }
+}
+
+// countingHandler is a slog.Handler counting the warnings and errors passed to the wrapped handler.
+type countingHandler struct {
+	slog.Handler
+	count *atomic.Int64
+}
+
+func (h *countingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		h.count.Add(1)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *countingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &countingHandler{Handler: h.Handler.WithAttrs(attrs), count: h.count}
+}
+
+func (h *countingHandler) WithGroup(name string) slog.Handler {
+	return &countingHandler{Handler: h.Handler.WithGroup(name), count: h.count}
+}
+
+// Log emits the summary as a single structured event.
+func (s Summary) Log(logger *slog.Logger) {
+	largest := make([]any, 0, largestFilesCount)
+	for _, file := range s.largestFiles() {
+		largest = append(largest, map[string]any{"path": file.Path, "size": file.Size})
+	}
+	output := s.Output
+	if output == "" {
+		output = "-"
+	}
+	lower, upper := s.tokens()
+	logger.Info("Bundle complete",
+		slog.String("phase", "summary"),
+		slog.String("output", output),
+		slog.Int("files", s.Files),
+		slog.Int("excluded", s.Excluded),
+		slog.Int("too_long", s.TooLong),
+		slog.Int("bytes", s.BytesWritten),
+		slog.Int("tokens_min", lower),
+		slog.Int("tokens_max", upper),
+		slog.Int64("duration_ms", s.Elapsed.Milliseconds()),
+		slog.Any("largest_files", largest),
+	)
+}
+
+// Duration returns the attribute used to report how long a phase took.
+func Duration(d time.Duration) slog.Attr {
+	return slog.Int64("duration_ms", d.Milliseconds())
+}
+
+// textHandler is a slog.Handler printing plain messages through a log.Logger.
+// Attributes are only emitted by the json format.
+type textHandler struct {
+	logger *log.Logger
+	level  slog.Level
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	msg := r.Message
+	if r.Level >= slog.LevelWarn {
+		msg = "Warning: " + msg
+	}
+	return h.logger.Output(2, msg)
+}
+
+func (h *textHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *textHandler) WithGroup(string) slog.Handler { return h }
@@ -0,0 +1,147 @@
+// Package report renders the end-of-run summary and the progress log of a bundle run.
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devinbarry/crev/internal/budget"
+)
+
+// ANSI escape codes used for the colored summary
+const (
+	ansiReset = "\033[0m"
+	ansiBold  = "\033[1m"
+	ansiGreen = "\033[32m"
+	ansiCyan  = "\033[36m"
+)
+
+// largestFilesCount is the number of largest files listed in the summary.
+const largestFilesCount = 5
+
+// FileSize is the size of a single bundled file.
+type FileSize struct {
+	Path string
+	Size int
+}
+
+// Summary contains the results of a bundle run.
+type Summary struct {
+	// Output is the file the bundle was saved to, or empty if it was written to stdout.
+	Output string
+	// Files is the number of included files and Excluded the number of excluded paths.
+	Files    int
+	Excluded int
+	// TooLong is the number of the excluded files that were left out for having too many lines.
+	TooLong      int
+	BytesWritten int
+	Elapsed      time.Duration
+	// LargestFiles lists the bundled files, the largest of which are shown in the summary.
+	LargestFiles []FileSize
+}
+
+// tokens returns the estimated range of the number of tokens of the bundle. The upper estimate is
+// the one the --max-tokens and --split-tokens budgets are checked against.
+func (s Summary) tokens() (lower, upper int) {
+	return s.BytesWritten / 4, budget.Tokens(s.BytesWritten)
+}
+
+// ColorEnabled reports whether colored output should be written to w. Colors are only used for
+// terminals, and never when the NO_COLOR environment variable is set (https://no-color.org) or
+// TERM is "dumb".
+func ColorEnabled(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Write writes the summary to w, using ANSI colors if color is true.
+func (s Summary) Write(w io.Writer, color bool) error {
+	paint := func(code, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + ansiReset
+	}
+
+	var sb strings.Builder
+	if s.Output == "" {
+		sb.WriteString(paint(ansiGreen, "✔ Project overview successfully written to stdout") + "\n")
+	} else {
+		sb.WriteString(paint(ansiGreen, "✔ Project overview successfully saved to: ") + paint(ansiBold, s.Output) + "\n")
+	}
+
+	lower, upper := s.tokens()
+	rows := [][2]string{
+		{"Files included", fmt.Sprintf("%d", s.Files)},
+		{"Paths excluded", fmt.Sprintf("%d", s.Excluded)},
+		{"Size", FormatBytes(s.BytesWritten)},
+		{"Estimated tokens", fmt.Sprintf("%d - %d", lower, upper)},
+		{"Execution time", s.Elapsed.Round(time.Millisecond).String()},
+	}
+	if s.TooLong > 0 {
+		rows = slices.Insert(rows, 2, [2]string{"Files too long", fmt.Sprintf("%d", s.TooLong)})
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row[0]))
+	}
+	for _, row := range rows {
+		label := fmt.Sprintf("%-*s", width+1, row[0]+":")
+		sb.WriteString("  " + paint(ansiCyan, label) + " " + row[1] + "\n")
+	}
+
+	largest := s.largestFiles()
+	if len(largest) > 0 {
+		sb.WriteString("  " + paint(ansiCyan, "Largest files:") + "\n")
+		for _, file := range largest {
+			sb.WriteString(fmt.Sprintf("    %9s  %s\n", FormatBytes(file.Size), file.Path))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// largestFiles returns the largest files in descending order of size.
+func (s Summary) largestFiles() []FileSize {
+	largest := append([]FileSize(nil), s.LargestFiles...)
+	sort.SliceStable(largest, func(i, j int) bool {
+		return largest[i].Size > largest[j].Size
+	})
+	if len(largest) > largestFilesCount {
+		largest = largest[:largestFilesCount]
+	}
+	return largest
+}
+
+// FormatBytes formats a byte count in human readable units.
+func FormatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
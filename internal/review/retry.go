@@ -0,0 +1,54 @@
+package review
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxAttempts is the number of times a review request is sent before giving up
+	maxAttempts = 5
+	// baseRetryDelay is the delay before the first retry, it doubles with every attempt
+	baseRetryDelay = time.Second
+	// maxRetryDelay caps the delay between two attempts
+	maxRetryDelay = time.Minute
+)
+
+// sleep waits between two attempts, replaced in tests
+var sleep = time.Sleep
+
+// isRetryable reports whether a request that failed with statusCode may succeed when sent again
+func isRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// retryDelay returns how long to wait before sending the request again after the given attempt.
+// The Retry-After header of a rate limited response is respected, otherwise the delay grows
+// exponentially from baseRetryDelay.
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(delay, maxRetryDelay)
+		}
+	}
+	delay := baseRetryDelay << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,86 @@
+package review
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test that rate limited and failed requests are sent again with the same body
+func TestSendRequestRetries(t *testing.T) {
+	var delays []time.Duration
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+	t.Cleanup(func() { sleep = time.Sleep })
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch len(bodies) {
+		case 1:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"review": "LGTM"}`))
+		}
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("POST", server.URL, strings.NewReader(`{"code": "package main"}`))
+	require.NoError(t, err)
+	resp, err := sendRequest(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []time.Duration{7 * time.Second, 2 * time.Second}, delays)
+	require.Equal(t, []string{`{"code": "package main"}`, `{"code": "package main"}`, `{"code": "package main"}`}, bodies)
+}
+
+// Test the delay before sending a request again
+func TestRetryDelay(t *testing.T) {
+	rateLimited := func(retryAfter string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{retryAfter}}}
+	}
+
+	require.Equal(t, time.Second, retryDelay(1, nil))
+	require.Equal(t, 4*time.Second, retryDelay(3, &http.Response{Header: http.Header{}}))
+	require.Equal(t, maxRetryDelay, retryDelay(20, nil))
+	require.Equal(t, 30*time.Second, retryDelay(1, rateLimited("30")))
+	require.Equal(t, maxRetryDelay, retryDelay(1, rateLimited("3600")))
+	require.Equal(t, 2*time.Second, retryDelay(2, rateLimited("soon")))
+	require.Equal(t, time.Duration(0), retryDelay(1, rateLimited(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))))
+}
+
+// Test which status codes are retried
+func TestIsRetryable(t *testing.T) {
+	require.True(t, isRetryable(http.StatusTooManyRequests))
+	require.True(t, isRetryable(http.StatusInternalServerError))
+	require.False(t, isRetryable(http.StatusUnauthorized))
+	require.False(t, isRetryable(http.StatusBadRequest))
+}
+
+// Test that no request is sent in offline mode
+func TestSendRequestOffline(t *testing.T) {
+	Offline = func() bool { return true }
+	t.Cleanup(func() { Offline = func() bool { return false } })
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("POST", server.URL, strings.NewReader(`{"code": "package main"}`))
+	require.NoError(t, err)
+	_, err = sendRequest(req)
+	require.ErrorContains(t, err, "review request to "+server.URL+" cannot be sent in offline mode")
+	require.Zero(t, requests)
+}
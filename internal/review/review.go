@@ -3,6 +3,7 @@ package review
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -20,6 +21,10 @@ type ReviewOutput struct {
 
 const reviewURL = "https://reviewcode-qcgl4feadq-uc.a.run.app"
 
+// Offline reports whether network access is blocked, in which case no review request is sent.
+// It is set to the offline mode of the command line.
+var Offline = func() bool { return false }
+
 func prepareRequest(codeToReview string, apiKey string) (*http.Request, error) {
 	input := ReviewInput{
 		Code: codeToReview,
@@ -39,14 +44,44 @@ func prepareRequest(codeToReview string, apiKey string) (*http.Request, error) {
 	return req, nil
 }
 
+// sendRequest sends the review request, retrying transient errors and rate limited requests
+// with a backoff, see retryDelay.
 func sendRequest(req *http.Request) (*http.Response, error) {
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatalf("Error sending review request to %s: %v", reviewURL, err)
+	if Offline() {
+		return nil, fmt.Errorf("review request to %s cannot be sent in offline mode", req.URL.Redacted())
 	}
+	client := &http.Client{}
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		if attempt < maxAttempts && (err != nil || isRetryable(resp.StatusCode)) {
+			var reason string
+			if err != nil {
+				reason = err.Error()
+			} else {
+				reason = resp.Status
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			delay := retryDelay(attempt, resp)
+			log.Printf("Review request failed (%s), retrying in %s (attempt %d of %d)", reason, delay, attempt+1, maxAttempts)
+			sleep(delay)
+
+			// The body was consumed by the previous attempt
+			if req.GetBody != nil {
+				if req.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
 
-	if resp.StatusCode != http.StatusOK {
+		if err != nil {
+			log.Fatalf("Error sending review request to %s: %v", reviewURL, err)
+		}
 		body, _ := io.ReadAll(resp.Body)
 		log.Printf("Error: received status code %d: %s", resp.StatusCode, string(body))
 		if resp.StatusCode == http.StatusUnauthorized {
@@ -56,7 +91,6 @@ func sendRequest(req *http.Request) (*http.Response, error) {
 		}
 		return nil, err
 	}
-	return resp, nil
 }
 
 func saveReviewToFile(output ReviewOutput) error {
@@ -0,0 +1,118 @@
+// Package stats breaks down the size of the files of a bundle per file and per directory, to
+// find what takes up the most of the context window.
+package stats
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/budget"
+	"github.com/devinbarry/crev/internal/report"
+)
+
+// Stat is the size of a file, or of all files in a directory.
+type Stat struct {
+	// Path is the slash-separated path of the file or directory.
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+	Lines int    `json:"lines"`
+	Bytes int    `json:"bytes"`
+	// Tokens is the upper estimate of the number of tokens of Bytes, see budget.Tokens. The tokens
+	// of a directory are estimated from its total bytes, like the --max-tokens budget, so they can be
+	// less than the sum of the tokens of its files.
+	Tokens int `json:"tokens"`
+}
+
+// add adds the size of o to s.
+func (s *Stat) add(o Stat) {
+	s.Files += o.Files
+	s.Lines += o.Lines
+	s.Bytes += o.Bytes
+	s.Tokens = budget.Tokens(s.Bytes)
+}
+
+// Report is the size of the files and directories of a bundle, sorted by decreasing tokens.
+type Report struct {
+	Files []Stat `json:"files"`
+	// Dirs are all directories containing files, at any depth, without the root directory.
+	Dirs  []Stat `json:"dirs"`
+	Total Stat   `json:"total"`
+}
+
+// New creates the report of the files in fileContentMap, by slash-separated path.
+func New(fileContentMap map[string]string) *Report {
+	r := &Report{Files: []Stat{}, Dirs: []Stat{}}
+	dirs := make(map[string]*Stat)
+	for filePath, content := range fileContentMap {
+		file := Stat{Path: filePath, Files: 1, Lines: countLines(content), Bytes: len(content), Tokens: budget.Tokens(len(content))}
+		r.Files = append(r.Files, file)
+		r.Total.add(file)
+		for dir := path.Dir(filePath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if dirs[dir] == nil {
+				dirs[dir] = &Stat{Path: dir}
+			}
+			dirs[dir].add(file)
+		}
+	}
+	for _, dir := range dirs {
+		r.Dirs = append(r.Dirs, *dir)
+	}
+	sortByTokens(r.Files)
+	sortByTokens(r.Dirs)
+	return r
+}
+
+// sortByTokens sorts stats by decreasing tokens, then by path.
+func sortByTokens(stats []Stat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Tokens != stats[j].Tokens {
+			return stats[i].Tokens > stats[j].Tokens
+		}
+		return stats[i].Path < stats[j].Path
+	})
+}
+
+// countLines returns the number of lines of content, including a last line without newline.
+func countLines(content string) int {
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
+
+// Write writes the report as tables of the directories and the files to w, followed by the
+// totals. Only the top largest directories and files are listed, all of them if top is 0.
+func (r *Report) Write(w io.Writer, top int) error {
+	var sb strings.Builder
+	writeTable(&sb, "Directories", r.Dirs, top, "/")
+	writeTable(&sb, "Files", r.Files, top, "")
+	fmt.Fprintf(&sb, "Total: %d files, %d lines, %s, about %d tokens\n",
+		r.Total.Files, r.Total.Lines, report.FormatBytes(r.Total.Bytes), r.Total.Tokens)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeTable writes the top stats with a title to sb, with suffix after each path. Tables
+// without stats are left out.
+func writeTable(sb *strings.Builder, title string, stats []Stat, top int, suffix string) {
+	if len(stats) == 0 {
+		return
+	}
+	shown := stats
+	if top > 0 && len(shown) > top {
+		shown = shown[:top]
+	}
+	sb.WriteString(title + ":\n")
+	fmt.Fprintf(sb, "  %8s  %9s  %7s  %s\n", "TOKENS", "SIZE", "LINES", "PATH")
+	for _, s := range shown {
+		fmt.Fprintf(sb, "  %8d  %9s  %7d  %s%s\n", s.Tokens, report.FormatBytes(s.Bytes), s.Lines, s.Path, suffix)
+	}
+	if hidden := len(stats) - len(shown); hidden > 0 {
+		fmt.Fprintf(sb, "  ... and %d more\n", hidden)
+	}
+	sb.WriteString("\n")
+}
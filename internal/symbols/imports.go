@@ -0,0 +1,271 @@
+package symbols
+
+import (
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+)
+
+// File is a source file scanned for imports, with its slash-separated path.
+type File struct {
+	Path    string
+	Content string
+}
+
+// Imports returns the modules imported by each of srcFiles that resolve to one of srcFiles, keyed
+// by the path of the importing file. Imports of external modules are left out. An import is
+// resolved to the imported file, e.g. "./util" to "src/util.ts", except for Go, whose imports are
+// resolved to the directory of the imported package, e.g. "internal/files". goModule is the module
+// path of the Go files from go.mod, if known; without it, Go imports are matched against the
+// directories by their last two path elements.
+// The imports are found with regular expressions for most languages, see importPatterns.
+func Imports(srcFiles []File, goModule string) map[string][]string {
+	r := newResolver(srcFiles, goModule)
+	imports := make(map[string][]string)
+	for _, file := range srcFiles {
+		seen := make(map[string]bool)
+		for _, spec := range importSpecs(file.Path, file.Content) {
+			target := r.resolve(file.Path, spec)
+			if target == "" || target == file.Path || seen[target] {
+				continue
+			}
+			seen[target] = true
+			imports[file.Path] = append(imports[file.Path], target)
+		}
+		sort.Strings(imports[file.Path])
+	}
+	return imports
+}
+
+// moduleDirective matches the module directive of a go.mod file.
+var moduleDirective = regexp.MustCompile(`(?m)^module[ \t]+"?([^"\s]+)"?`)
+
+// GoModulePath returns the module path declared in the content of a go.mod file, or an empty
+// string if there is none.
+func GoModulePath(goMod string) string {
+	if match := moduleDirective.FindStringSubmatch(goMod); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// importPatterns match the import statements of the supported languages, by the name returned by
+// files.Language. The first group of each pattern is the imported module.
+var importPatterns = map[string][]*regexp.Regexp{
+	"python": {
+		regexp.MustCompile(`(?m)^[ \t]*from[ \t]+(\.*[\w.]*)[ \t]+import\b`),
+		regexp.MustCompile(`(?m)^[ \t]*import[ \t]+([\w.]+(?:[ \t]*,[ \t]*[\w.]+)*)`),
+	},
+	"javascript": jsImportPatterns,
+	"jsx":        jsImportPatterns,
+	"typescript": jsImportPatterns,
+	"tsx":        jsImportPatterns,
+	"rust": {
+		regexp.MustCompile(`(?m)^[ \t]*(?:pub(?:\([^)]*\))?[ \t]+)?mod[ \t]+(\w+)[ \t]*;`),
+		regexp.MustCompile(`(?m)^[ \t]*(?:pub(?:\([^)]*\))?[ \t]+)?use[ \t]+((?:crate|super|self)(?:::\w+)+)`),
+	},
+	"java":   {jvmImportPattern},
+	"kotlin": {jvmImportPattern},
+	"scala":  {jvmImportPattern},
+	"c":      {cIncludePattern},
+	"cpp":    {cIncludePattern},
+	"ruby": {
+		regexp.MustCompile(`(?m)^[ \t]*require_relative[ \t(]+['"]([^'"]+)['"]`),
+		regexp.MustCompile(`(?m)^[ \t]*require[ \t(]+['"]([^'"]+)['"]`),
+	},
+}
+
+var (
+	jsImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^[ \t]*(?:import|export)\b[^'"\n;]*?\bfrom[ \t]*['"]([^'"]+)['"]`),
+		regexp.MustCompile(`(?m)^[ \t]*import[ \t]*['"]([^'"]+)['"]`),
+		regexp.MustCompile(`\b(?:require|import)\(\s*['"]([^'"]+)['"]\s*\)`),
+	}
+	jvmImportPattern = regexp.MustCompile(`(?m)^[ \t]*import[ \t]+(?:static[ \t]+)?([\w.]+)`)
+	cIncludePattern  = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*include[ \t]*"([^"]+)"`)
+)
+
+// importSpecs returns the modules imported by a file, as written in the source, prefixed with the
+// language and a colon, e.g. "python:.models".
+func importSpecs(filePath, content string) []string {
+	language := files.Language(filePath)
+	if language == "go" {
+		file, _ := parser.ParseFile(token.NewFileSet(), "", content, parser.ImportsOnly)
+		if file == nil {
+			return nil
+		}
+		var specs []string
+		for _, spec := range file.Imports {
+			if importPath, err := strconv.Unquote(spec.Path.Value); err == nil {
+				specs = append(specs, "go:"+importPath)
+			}
+		}
+		return specs
+	}
+
+	var specs []string
+	for _, re := range importPatterns[language] {
+		for _, match := range re.FindAllStringSubmatch(content, -1) {
+			// Python imports can list several modules
+			for _, module := range strings.Split(match[1], ",") {
+				specs = append(specs, language+":"+strings.TrimSpace(module))
+			}
+		}
+	}
+	return specs
+}
+
+// resolver resolves import specs to the files of a bundle.
+type resolver struct {
+	files    map[string]bool
+	paths    []string
+	goDirs   []string
+	goModule string
+}
+
+func newResolver(srcFiles []File, goModule string) *resolver {
+	r := &resolver{files: make(map[string]bool), goModule: goModule}
+	goDirs := make(map[string]bool)
+	for _, file := range srcFiles {
+		r.files[file.Path] = true
+		r.paths = append(r.paths, file.Path)
+		if files.Language(file.Path) == "go" && !strings.HasSuffix(file.Path, "_test.go") {
+			goDirs[path.Dir(file.Path)] = true
+		}
+	}
+	sort.Strings(r.paths)
+	for dir := range goDirs {
+		r.goDirs = append(r.goDirs, dir)
+	}
+	sort.Strings(r.goDirs)
+	return r
+}
+
+// jsExtensions are tried in order to resolve JavaScript and TypeScript imports without extension.
+var jsExtensions = []string{"", ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", "/index.ts", "/index.tsx", "/index.js", "/index.jsx"}
+
+// resolve returns the file or Go package directory a spec of importer resolves to, or an empty
+// string if it is not part of the bundle.
+func (r *resolver) resolve(importer, spec string) string {
+	language, module, _ := strings.Cut(spec, ":")
+	dir := path.Dir(importer)
+	switch language {
+	case "go":
+		return r.resolveGo(module)
+	case "python":
+		relative := strings.TrimLeft(module, ".")
+		modulePath := strings.ReplaceAll(relative, ".", "/")
+		if dots := len(module) - len(relative); dots > 0 {
+			base := dir
+			for i := 1; i < dots; i++ {
+				base = path.Dir(base)
+			}
+			return r.first(path.Join(base, modulePath)+".py", path.Join(base, modulePath, "__init__.py"))
+		}
+		return r.suffix(modulePath+".py", modulePath+"/__init__.py")
+	case "javascript", "jsx", "typescript", "tsx":
+		if !strings.HasPrefix(module, "./") && !strings.HasPrefix(module, "../") {
+			return ""
+		}
+		base := path.Join(dir, module)
+		candidates := make([]string, len(jsExtensions))
+		for i, ext := range jsExtensions {
+			candidates[i] = base + ext
+		}
+		return r.first(candidates...)
+	case "rust":
+		if name, ok := strings.CutPrefix(module, "crate::"); ok {
+			parts := strings.Split(name, "::")
+			for n := len(parts); n > 0; n-- {
+				modulePath := strings.Join(parts[:n], "/")
+				if target := r.suffix("src/"+modulePath+".rs", "src/"+modulePath+"/mod.rs"); target != "" {
+					return target
+				}
+			}
+			return ""
+		}
+		if strings.Contains(module, "::") {
+			return ""
+		}
+		// mod declarations of main.rs, lib.rs and mod.rs are next to them, other modules below them
+		base := dir
+		if name := path.Base(importer); name != "main.rs" && name != "lib.rs" && name != "mod.rs" {
+			base = strings.TrimSuffix(importer, ".rs")
+		}
+		return r.first(path.Join(base, module)+".rs", path.Join(base, module, "mod.rs"))
+	case "java", "kotlin", "scala":
+		modulePath := strings.ReplaceAll(module, ".", "/")
+		return r.suffix(modulePath+".java", modulePath+".kt", modulePath+".scala")
+	case "c", "cpp":
+		return r.first(path.Join(dir, module), module)
+	case "ruby":
+		if !strings.HasSuffix(module, ".rb") {
+			module += ".rb"
+		}
+		if target := r.first(path.Join(dir, module)); target != "" {
+			return target
+		}
+		return r.suffix(module, "lib/"+module)
+	}
+	return ""
+}
+
+// resolveGo returns the directory of the bundled Go package with the import path.
+func (r *resolver) resolveGo(importPath string) string {
+	if r.goModule != "" {
+		rest, ok := strings.CutPrefix(importPath, r.goModule+"/")
+		if !ok {
+			return ""
+		}
+		return r.matchDir(rest)
+	}
+	elements := strings.Split(importPath, "/")
+	if len(elements) < 2 {
+		return ""
+	}
+	return r.matchDir(strings.Join(elements[len(elements)-2:], "/"))
+}
+
+// matchDir returns the Go package directory that is dir or ends with dir, the shortest one if
+// several do.
+func (r *resolver) matchDir(dir string) string {
+	match := ""
+	for _, goDir := range r.goDirs {
+		if (goDir == dir || strings.HasSuffix(goDir, "/"+dir)) && (match == "" || len(goDir) < len(match)) {
+			match = goDir
+		}
+	}
+	return match
+}
+
+// first returns the first of the candidate paths that is a file of the bundle.
+func (r *resolver) first(candidates ...string) string {
+	for _, candidate := range candidates {
+		if r.files[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// suffix returns the first file of the bundle that is or ends with one of the candidate paths,
+// trying the candidates in order.
+func (r *resolver) suffix(candidates ...string) string {
+	for _, candidate := range candidates {
+		if r.files[candidate] {
+			return candidate
+		}
+		for _, filePath := range r.paths {
+			if strings.HasSuffix(filePath, "/"+candidate) {
+				return filePath
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+// Package symbols extracts the definitions of source files, such as functions, types and classes,
+// and the imports between them, for the symbol index and the import graph of a bundle.
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/devinbarry/crev/internal/files"
+)
+
+// Symbol is a definition in a source file.
+type Symbol struct {
+	// Kind is the kind of definition, e.g. "func", "method", "type" or "class".
+	Kind string
+	// Name is the name of the definition, methods of Go types are prefixed with the type, e.g. "Bundle.Write".
+	Name string
+	// Line is the 1-based line of the definition.
+	Line int
+}
+
+// Extract returns the symbols defined in content, in the order of their lines. The language is
+// detected from filePath, see files.Language. Go files are parsed, other languages are scanned
+// line by line for definitions with regular expressions, so definitions in strings or comments can
+// be reported too. Files of unsupported languages have no symbols.
+func Extract(filePath, content string) []Symbol {
+	language := files.Language(filePath)
+	if language == "go" {
+		return extractGo(content)
+	}
+	return extractPatterns(content, languagePatterns[language])
+}
+
+// extractGo returns the functions, methods and types of Go source code. Source code with syntax
+// errors, e.g. because it was truncated, returns the symbols before the first error.
+func extractGo(content string) []Symbol {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			symbol := Symbol{Kind: "func", Name: decl.Name.Name, Line: fset.Position(decl.Pos()).Line}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				symbol.Kind = "method"
+				symbol.Name = receiverType(decl.Recv.List[0].Type) + "." + symbol.Name
+			}
+			symbols = append(symbols, symbol)
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				kind := "type"
+				switch typeSpec.Type.(type) {
+				case *ast.StructType:
+					kind = "struct"
+				case *ast.InterfaceType:
+					kind = "interface"
+				}
+				symbols = append(symbols, Symbol{Kind: kind, Name: typeSpec.Name.Name, Line: fset.Position(typeSpec.Pos()).Line})
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverType returns the name of the type of a method receiver, without pointer and type parameters.
+func receiverType(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(expr.X)
+	case *ast.IndexExpr:
+		return receiverType(expr.X)
+	case *ast.IndexListExpr:
+		return receiverType(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return "?"
+}
+
+// pattern matches definitions of a language. The name group of re is the name of the symbol and
+// the kind group, if any, its kind, otherwise kind is used.
+type pattern struct {
+	re   *regexp.Regexp
+	kind string
+}
+
+// newPattern compiles a pattern matching at the start of lines.
+func newPattern(kind, re string) pattern {
+	return pattern{re: regexp.MustCompile(`(?m)` + re), kind: kind}
+}
+
+// Definitions shared by several languages
+var (
+	jsPatterns = []pattern{
+		newPattern("func", `^[ \t]*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>[\w$]+)`),
+		newPattern("class", `^[ \t]*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>[\w$]+)`),
+		newPattern("func", `^[ \t]*(?:export\s+)?(?:const|let|var)\s+(?P<name>[\w$]+)\s*=\s*(?:async\s+)?(?:function\b|(?:\([^)]*\)|[\w$]+)\s*=>)`),
+	}
+	tsPatterns = append([]pattern{
+		newPattern("", `^[ \t]*(?:export\s+)?(?:declare\s+)?(?P<kind>interface|enum)\s+(?P<name>[\w$]+)`),
+		newPattern("type", `^[ \t]*(?:export\s+)?(?:declare\s+)?type\s+(?P<name>[\w$]+)\s*(?:<[^=]*>)?\s*=`),
+	}, jsPatterns...)
+	typeDeclarations = newPattern("", `^[ \t]*(?:(?:public|private|protected|internal|abstract|final|static|sealed|partial|data|open|inner|export|fileprivate)\s+)*(?P<kind>class|interface|enum|struct|record|trait|object|protocol)\s+(?P<name>\w+)`)
+	shellPatterns    = []pattern{
+		newPattern("func", `^[ \t]*function\s+(?P<name>[\w:.-]+)`),
+		newPattern("func", `^[ \t]*(?P<name>[\w:.-]+)\s*\(\)\s*\{?`),
+	}
+	cPatterns = []pattern{
+		newPattern("", `^(?:typedef\s+)?(?P<kind>struct|union|enum|class|namespace)\s+(?P<name>\w+)\s*(?::[^{;]*)?\{?\s*$`),
+		newPattern("func", `^[A-Za-z_][\w \t*&:<>,]*?[ \t*&]+(?P<name>[A-Za-z_][\w:~]*)\s*\([^;]*\)\s*(?:const\s*)?(?:noexcept\s*)?\{?\s*$`),
+	}
+)
+
+// languagePatterns are the patterns of the definitions of the supported languages, by the
+// name returned by files.Language.
+var languagePatterns = map[string][]pattern{
+	"python": {
+		newPattern("func", `^(?:async\s+)?def\s+(?P<name>\w+)`),
+		newPattern("method", `^[ \t]+(?:async\s+)?def\s+(?P<name>\w+)`),
+		newPattern("class", `^[ \t]*class\s+(?P<name>\w+)`),
+	},
+	"javascript": jsPatterns,
+	"jsx":        jsPatterns,
+	"typescript": tsPatterns,
+	"tsx":        tsPatterns,
+	"ruby": {
+		newPattern("", `^[ \t]*(?P<kind>class|module)\s+(?P<name>[\w:]+)`),
+		newPattern("def", `^[ \t]*def\s+(?:self\.)?(?P<name>[\w?!=]+)`),
+	},
+	"rust": {
+		newPattern("fn", `^[ \t]*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?fn\s+(?P<name>\w+)`),
+		newPattern("", `^[ \t]*(?:pub(?:\([^)]*\))?\s+)?(?P<kind>struct|enum|trait|union|mod)\s+(?P<name>\w+)`),
+		newPattern("type", `^[ \t]*(?:pub(?:\([^)]*\))?\s+)?type\s+(?P<name>\w+)`),
+		newPattern("impl", `^[ \t]*impl(?:<[^>]*>)?\s+(?P<name>[\w:]+(?:<[^>]*>)?(?:\s+for\s+[\w:]+)?)`),
+	},
+	"java":   {typeDeclarations},
+	"csharp": {typeDeclarations},
+	"kotlin": {typeDeclarations, newPattern("fun", `^[ \t]*(?:\w+\s+)*fun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?(?P<name>\w+)`)},
+	"scala":  {typeDeclarations, newPattern("def", `^[ \t]*(?:\w+\s+)*def\s+(?P<name>\w+)`)},
+	"swift":  {typeDeclarations, newPattern("func", `^[ \t]*(?:@\w+\s+)*(?:\w+\s+)*func\s+(?P<name>\w+)`)},
+	"dart":   {typeDeclarations},
+	"php": {
+		typeDeclarations,
+		newPattern("function", `^[ \t]*(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+(?P<name>\w+)`),
+	},
+	"c":    cPatterns,
+	"cpp":  cPatterns,
+	"bash": shellPatterns,
+	"zsh":  shellPatterns,
+	"lua":  {newPattern("function", `^[ \t]*(?:local\s+)?function\s+(?P<name>[\w.:]+)`)},
+	"perl": {
+		newPattern("package", `^[ \t]*package\s+(?P<name>[\w:]+)`),
+		newPattern("sub", `^[ \t]*sub\s+(?P<name>\w+)`),
+	},
+	"r": {newPattern("function", `^[ \t]*(?P<name>[\w.]+)\s*(?:<-|=)\s*function\b`)},
+	"sql": {
+		newPattern("", `(?i)^[ \t]*create\s+(?:or\s+replace\s+)?(?:temporary\s+|temp\s+|materialized\s+)?(?P<kind>table|view|function|procedure|index|trigger|type)\s+(?:if\s+not\s+exists\s+)?(?P<name>[\w."]+)`),
+	},
+	"protobuf": {
+		newPattern("", `^[ \t]*(?P<kind>message|service|enum)\s+(?P<name>\w+)`),
+		newPattern("rpc", `^[ \t]*rpc\s+(?P<name>\w+)`),
+	},
+	"hcl": {
+		newPattern("", `^(?P<kind>resource|data|module|variable|output|provider)\s+(?P<name>"[^"]+"(?:\s+"[^"]+")?)`),
+	},
+}
+
+// controlKeywords are C keywords followed by parentheses, which are not function definitions.
+var controlKeywords = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "return": true, "sizeof": true, "catch": true}
+
+// extractPatterns returns the definitions matched by patterns in content. If several patterns
+// match the same line, the first one wins.
+func extractPatterns(content string, patterns []pattern) []Symbol {
+	if len(patterns) == 0 {
+		return nil
+	}
+	lineStarts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	byLine := make(map[int]Symbol)
+	for _, p := range patterns {
+		nameGroup, kindGroup := p.re.SubexpIndex("name"), p.re.SubexpIndex("kind")
+		for _, match := range p.re.FindAllStringSubmatchIndex(content, -1) {
+			line := sort.SearchInts(lineStarts, match[0]+1)
+			if _, ok := byLine[line]; ok {
+				continue
+			}
+			symbol := Symbol{Kind: p.kind, Name: content[match[2*nameGroup]:match[2*nameGroup+1]], Line: line}
+			if kindGroup >= 0 {
+				symbol.Kind = strings.ToLower(content[match[2*kindGroup]:match[2*kindGroup+1]])
+			}
+			if controlKeywords[symbol.Name] {
+				continue
+			}
+			byLine[line] = symbol
+		}
+	}
+
+	symbols := make([]Symbol, 0, len(byLine))
+	for _, symbol := range byLine {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Line < symbols[j].Line })
+	return symbols
+}
@@ -0,0 +1,49 @@
+package budget_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/budget"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPriority tests that lock files, data, tests and docs have a lower priority than source files.
+func TestPriority(t *testing.T) {
+	order := []string{"go.sum", "vendor/lib/lib.go", "api/service.pb.go", "testdata/users.json", "cmd/main_test.go", "docs/guide.md", "internal/deep/store.go", "main.go", "README.md"}
+	for i := 1; i < len(order); i++ {
+		require.Less(t, budget.Priority(order[i-1]), budget.Priority(order[i]), "%s before %s", order[i-1], order[i])
+	}
+}
+
+// TestDrop tests dropping the lowest priority files first, never the pinned ones.
+func TestDrop(t *testing.T) {
+	candidates := []budget.File{
+		{Path: "main.go", Size: 100},
+		{Path: "go.sum", Size: 500},
+		{Path: "fixtures/a.json", Size: 300},
+		{Path: "fixtures/b.json", Size: 400},
+		{Path: "notes.txt", Size: 50, Pinned: true},
+	}
+	dropped, ok := budget.Drop(candidates, 700)
+	require.True(t, ok)
+	require.Equal(t, []string{"go.sum", "fixtures/b.json"}, dropped)
+
+	_, ok = budget.Drop(candidates, 1320)
+	require.False(t, ok)
+}
+
+// TestTruncate tests cutting the largest files to a common size.
+func TestTruncate(t *testing.T) {
+	candidates := []budget.File{
+		{Path: "a.go", Size: 1000},
+		{Path: "b.go", Size: 600},
+		{Path: "c.go", Size: 100},
+		{Path: "d.go", Size: 2000, Pinned: true},
+	}
+	limits, ok := budget.Truncate(candidates, 800)
+	require.True(t, ok)
+	require.Equal(t, map[string]int{"a.go": 400, "b.go": 400}, limits)
+
+	_, ok = budget.Truncate(candidates, 1800)
+	require.False(t, ok)
+}
@@ -0,0 +1,74 @@
+package coverage_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/coverage"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseGoProfile tests counting the covered statements of each file of a Go coverage profile,
+// with blocks repeated by merged profiles counted once.
+func TestParseGoProfile(t *testing.T) {
+	profile := `mode: set
+example.com/app/store/store.go:5.20,7.2 2 1
+example.com/app/store/store.go:9.20,12.2 3 0
+example.com/app/main.go:3.13,5.2 1 0
+example.com/app/main.go:3.13,5.2 1 1
+example.com/app/main.go:7.13,8.2 1 0
+`
+	report, err := coverage.Parse(strings.NewReader(profile))
+	require.NoError(t, err)
+	require.Equal(t, coverage.Report{
+		"example.com/app/store/store.go": {Covered: 2, Total: 5},
+		"example.com/app/main.go":        {Covered: 1, Total: 2},
+	}, report)
+	require.InDelta(t, 40.0, report["example.com/app/store/store.go"].Percent(), 0.001)
+
+	_, err = coverage.Parse(strings.NewReader("mode: set\nmain.go:3.13,5.2 one 1\n"))
+	require.ErrorContains(t, err, "invalid block")
+}
+
+// TestParseLcov tests reading the line coverage of the files of an lcov tracefile, from the LF and
+// LH records or the DA records.
+func TestParseLcov(t *testing.T) {
+	tracefile := `TN:
+SF:src/app.js
+DA:1,1
+DA:2,0
+LF:4
+LH:3
+end_of_record
+SF:/home/dev/app/src/util.js
+DA:1,5
+DA:2,0
+DA:3,0
+end_of_record
+`
+	report, err := coverage.Parse(strings.NewReader(tracefile))
+	require.NoError(t, err)
+	require.Equal(t, coverage.Report{
+		"src/app.js":                {Covered: 3, Total: 4},
+		"/home/dev/app/src/util.js": {Covered: 1, Total: 3},
+	}, report)
+
+	_, err = coverage.Parse(strings.NewReader("not a report\n"))
+	require.ErrorContains(t, err, "not a Go coverage profile or lcov tracefile")
+}
+
+// TestMatch tests matching the files of a report to the bundled files by relative path, absolute
+// path and Go import path.
+func TestMatch(t *testing.T) {
+	report := coverage.Report{
+		"example.com/app/store/store.go": {Covered: 2, Total: 5},
+		"src/app.js":                     {Covered: 3, Total: 4},
+		"/home/dev/app/src/util.js":      {Covered: 1, Total: 3},
+		"example.com/app/other.go":       {Covered: 1, Total: 1},
+	}
+	require.Equal(t, map[string]coverage.File{
+		"store/store.go": {Covered: 2, Total: 5},
+		"src/app.js":     {Covered: 3, Total: 4},
+		"src/util.js":    {Covered: 1, Total: 3},
+	}, report.Match("/home/dev/app", []string{"store/store.go", "src/app.js", "src/util.js", "main.go"}))
+}
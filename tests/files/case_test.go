@@ -0,0 +1,19 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCaseCollisions tests finding the paths that only differ by case.
+func TestCaseCollisions(t *testing.T) {
+	paths := []string{"src/main.go", "README.md", "src/Main.go", "docs/readme.md", "readme.md", "src/MAIN.go"}
+	require.Equal(t, [][]string{
+		{"README.md", "readme.md"},
+		{"src/MAIN.go", "src/Main.go", "src/main.go"},
+	}, files.CaseCollisions(paths))
+
+	require.Empty(t, files.CaseCollisions([]string{"a.go", "b.go"}))
+}
@@ -0,0 +1,25 @@
+package files_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFilterByContent tests that files not matching the pattern and their empty directories are removed.
+func TestFilterByContent(t *testing.T) {
+	filePaths := []string{"a", "a/b", "a/b/match.go", "a/other.go", "c", "c/other.go", "kept.md"}
+	fileContentMap := map[string]string{
+		"a/b/match.go": "PaymentService",
+		"a/other.go":   "Invoice",
+		"c/other.go":   "Invoice",
+		"kept.md":      "docs",
+	}
+
+	filtered := files.FilterByContent(filePaths, fileContentMap, regexp.MustCompile("Payment"), []string{"kept.md"})
+
+	require.Equal(t, []string{"a", "a/b", "a/b/match.go", "kept.md"}, filtered)
+	require.Equal(t, map[string]string{"a/b/match.go": "PaymentService", "kept.md": "docs"}, fileContentMap)
+}
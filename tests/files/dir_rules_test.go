@@ -0,0 +1,42 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDirRulesFilter tests that nested rules only apply to the subtree of their directory.
+func TestDirRulesFilter(t *testing.T) {
+	filter := files.DirRulesFilter([]files.DirRules{
+		{Dir: "api", ExcludePatterns: []string{"fixtures/", "**/*.md"}},
+		{Dir: "web", IncludePatterns: []string{"src/**"}},
+		{Dir: "web/src/legacy", ExcludePatterns: []string{"*.js"}},
+	})
+
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"main.go", true},
+		{"README.md", true},
+		{"fixtures/data.json", true},
+		{"api/main.go", true},
+		{"api/fixtures/data.json", false},
+		{"api/docs/api.md", false},
+		{"apis/docs/api.md", true},
+		{"web/src/app.ts", true},
+		{"web/scripts/build.sh", false},
+		{"web/src/legacy/old.js", false},
+		{"web/src/legacy/old.ts", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			selected, err := filter(tc.path, nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, selected)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSelectEntries tests that the selected paths are returned with their metadata.
+func TestSelectEntries(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"src/main.go":  "package main",
+		"src/skip.log": "log",
+		"run.sh":       "#!/bin/sh",
+	})
+	require.NoError(t, os.Chmod(filepath.Join(rootDir, "run.sh"), 0755))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(rootDir, "src/main.go"), modTime, modTime))
+
+	entries, err := files.SelectEntries(rootDir, files.Selection{
+		IncludePatterns: []string{"**/*"},
+		ExcludePatterns: []string{"**/*.log"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"run.sh", "src", "src/main.go"}, files.EntryPaths(entries))
+
+	byPath := make(map[string]files.Entry)
+	for _, entry := range entries {
+		byPath[entry.Path] = entry
+	}
+	require.True(t, byPath["src"].IsDir)
+	require.False(t, byPath["src/main.go"].IsDir)
+	require.Equal(t, int64(len("package main")), byPath["src/main.go"].Size)
+	require.True(t, byPath["src/main.go"].ModTime.Equal(modTime))
+	require.Equal(t, os.FileMode(0755), byPath["run.sh"].Mode.Perm())
+}
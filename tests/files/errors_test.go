@@ -0,0 +1,49 @@
+package files_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateExplicitFiles tests that missing explicit files are reported with their paths.
+func TestValidateExplicitFiles(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{"main.go": "package main"})
+	existing := filepath.Join(rootDir, "main.go")
+	missing := filepath.Join(rootDir, "missing.go")
+
+	require.NoError(t, files.ValidateExplicitFiles([]string{existing}))
+
+	err := files.ValidateExplicitFiles([]string{existing, missing})
+	require.ErrorIs(t, err, files.ErrMissingExplicitFiles)
+	var missingErr *files.MissingExplicitFilesError
+	require.True(t, errors.As(err, &missingErr))
+	require.Equal(t, []string{missing}, missingErr.Paths)
+}
+
+// TestResolveExplicitFiles tests that explicit files are resolved against the root before the working directory.
+func TestResolveExplicitFiles(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{"cmd/main.go": "package main"})
+	absolute := filepath.Join(t.TempDir(), "other.go")
+
+	resolved := files.ResolveExplicitFiles(rootDir, []string{"cmd/main.go", "missing.go", absolute})
+	require.Equal(t, []string{filepath.Join(rootDir, "cmd/main.go"), "missing.go", absolute}, resolved)
+}
+
+// TestSelectBadPattern tests that invalid patterns are reported with the pattern.
+func TestSelectBadPattern(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{"main.go": "package main"})
+
+	_, err := files.Select(rootDir, files.Selection{IncludePatterns: []string{"**/*.go"}, ExcludePatterns: []string{"[main"}})
+	require.ErrorIs(t, err, files.ErrBadPattern)
+	var patternErr *files.BadPatternError
+	require.True(t, errors.As(err, &patternErr))
+	require.Equal(t, "[main", patternErr.Pattern)
+	require.EqualError(t, err, `invalid pattern "[main": syntax error in pattern`)
+}
@@ -0,0 +1,104 @@
+package files_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGitTrackedFiles tests listing the files tracked by git relative to a subdirectory of the repository.
+func TestGitTrackedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"README.md":           "readme",
+		"app/main.go":         "package main",
+		"app/internal/db.go":  "package internal",
+		"app/local/notes.txt": "scratch",
+	})
+	for _, args := range [][]string{{"init", "-q"}, {"add", "README.md", "app/main.go", "app/internal/db.go"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = rootDir
+		require.NoError(t, cmd.Run())
+	}
+
+	tracked, err := files.GitTrackedFiles(rootDir + "/app")
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"main.go": true, "internal/db.go": true}, tracked)
+
+	_, err = files.GitTrackedFiles(t.TempDir())
+	require.ErrorContains(t, err, "unable to list the files tracked by git")
+}
+
+// TestGitTrackedFilesWorktree tests that linked worktrees are resolved from their .git file, even
+// if a git hook points git at another repository, and that bare repositories are reported.
+func TestGitTrackedFilesWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{"main/main.go": "package main"})
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git(rootDir+"/main", "init", "-q")
+	git(rootDir+"/main", "add", "main.go")
+	git(rootDir+"/main", "commit", "-q", "-m", "initial")
+	git(rootDir+"/main", "worktree", "add", "-q", rootDir+"/feature")
+	createFiles(t, rootDir, map[string]string{"feature/feature.go": "package main"})
+	git(rootDir+"/feature", "add", "feature.go")
+
+	t.Setenv("GIT_DIR", rootDir+"/main/.git")
+	tracked, err := files.GitTrackedFiles(rootDir + "/feature")
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"main.go": true, "feature.go": true}, tracked)
+
+	os.Unsetenv("GIT_DIR")
+	git(rootDir, "clone", "-q", "--bare", rootDir+"/main", rootDir+"/bare.git")
+	_, err = files.GitTrackedFiles(rootDir + "/bare.git")
+	require.ErrorContains(t, err, "is in a bare repository without working tree")
+}
+
+// TestGitLog tests listing the messages of the last commits changing the files below a directory.
+func TestGitLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	rootDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = rootDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	for i, commit := range []struct{ file, message string }{
+		{"app/main.go", "Add app"},
+		{"docs/guide.md", "Add guide"},
+		{"app/util.go", "Add util\n\nShared helpers."},
+	} {
+		createFiles(t, rootDir, map[string]string{commit.file: fmt.Sprint(i)})
+		git("add", commit.file)
+		git("commit", "-q", "-m", commit.message)
+	}
+
+	log, err := files.GitLog(rootDir+"/app", 5)
+	require.NoError(t, err)
+	require.Regexp(t, `^\w+ \d{4}-\d{2}-\d{2}\n  Add util\n\n  Shared helpers.\n\n\w+ \d{4}-\d{2}-\d{2}\n  Add app$`, log)
+
+	log, err = files.GitLog(rootDir, 1)
+	require.NoError(t, err)
+	require.Contains(t, log, "Add util")
+	require.NotContains(t, log, "Add guide")
+}
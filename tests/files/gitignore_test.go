@@ -0,0 +1,32 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddToGitignore tests that only names not listed yet are appended to .gitignore.
+func TestAddToGitignore(t *testing.T) {
+	dir := t.TempDir()
+
+	added, err := files.AddToGitignore(dir, "crev-project.txt")
+	require.NoError(t, err)
+	require.Equal(t, []string{"crev-project.txt"}, added)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("/crev-project.txt\nbin/"), 0644))
+	added, err = files.AddToGitignore(dir, "crev-project.txt", "crev-project.index.json")
+	require.NoError(t, err)
+	require.Equal(t, []string{"crev-project.index.json"}, added)
+
+	added, err = files.AddToGitignore(dir, "crev-project.index.json")
+	require.NoError(t, err)
+	require.Empty(t, added)
+
+	content, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	require.NoError(t, err)
+	require.Equal(t, "/crev-project.txt\nbin/\ncrev-project.index.json\n", string(content))
+}
@@ -236,3 +236,93 @@ func TestGetAllFilePathsExcludeSymlink(t *testing.T) {
 	assertFileSetMatches(t, filePaths, expected, notExpected,
 		"Symlinked directories should be excluded correctly")
 }
+
+// TestSelectPatternStats tests that the number of paths matched by each pattern and the number of excluded paths are recorded.
+func TestSelectPatternStats(t *testing.T) {
+	rootDir := t.TempDir()
+
+	fileStructure := map[string]string{
+		"src/main.go":          "package main",
+		"src/util.go":          "package main",
+		"src/util_test.go":     "package main",
+		"docs/readme.md":       "# Readme",
+		"vendor/lib/module.go": "package lib",
+		"Makefile":             "build:",
+	}
+	createFiles(t, rootDir, fileStructure)
+
+	stats := &files.SelectionStats{}
+	_, err := files.Select(rootDir, files.Selection{
+		IncludePatterns: []string{"src/**", "**/*.md", "scr/**"},
+		ExcludePatterns: []string{"vendor", "**/*_test.go", "**/*.py"},
+		Stats:           stats,
+	})
+	require.NoError(t, err, "Select failed")
+
+	require.Equal(t, map[string]int{"src/**": 2, "**/*.md": 1, "scr/**": 0}, stats.Include)
+	require.Equal(t, map[string]int{"vendor": 1, "**/*_test.go": 1, "**/*.py": 0}, stats.Exclude)
+	require.Equal(t, 1, stats.NotIncluded, "Makefile matches no include pattern")
+	require.Equal(t, 3, stats.Excluded())
+}
+
+// TestSelectMaxLines tests that files with more lines than the limit are left out, except explicit files.
+func TestSelectMaxLines(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"short.go":         "package a\n",
+		"exact.go":         "package b\n\nfunc b() {}",
+		"data/rows.csv":    "1\n2\n3\n4\n",
+		"data/pinned.json": "[\n1,\n2\n]\n",
+	})
+
+	stats := &files.SelectionStats{}
+	paths, err := files.Select(rootDir, files.Selection{
+		IncludePatterns: []string{"**/*"},
+		ExplicitFiles:   []string{filepath.Join(rootDir, "data/pinned.json")},
+		MaxLines:        3,
+		Stats:           stats,
+	})
+	require.NoError(t, err)
+	assertFileSetMatches(t, paths, []string{"data", "data/pinned.json", "exact.go", "short.go"}, []string{"data/rows.csv"})
+	require.Equal(t, 1, stats.TooLong)
+	require.Equal(t, 1, stats.Excluded())
+}
+
+// TestSelectMaxFiles tests that the selection stops once more files than the limit are selected.
+func TestSelectMaxFiles(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"a.go":     "package a",
+		"b.go":     "package b",
+		"sub/c.go": "package c",
+	})
+
+	_, err := files.Select(rootDir, files.Selection{IncludePatterns: []string{"**/*"}, MaxFiles: 2})
+	require.ErrorIs(t, err, files.ErrTooManyFiles)
+
+	// Directories do not count towards the limit
+	paths, err := files.Select(rootDir, files.Selection{IncludePatterns: []string{"**/*"}, MaxFiles: 3})
+	require.NoError(t, err)
+	require.Len(t, paths, 4)
+}
+
+// TestSelectExplicitDirectory tests that an explicit directory selects its whole tree, regardless of the exclude patterns.
+func TestSelectExplicitDirectory(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"internal/auth/auth.go":      "package auth",
+		"internal/auth/debug.log":    "log",
+		"internal/auth/.env":         "KEY=value",
+		"internal/auth/jwt/token.go": "package jwt",
+		"internal/db/db.go":          "package db",
+	})
+	paths, err := files.SelectFS(os.DirFS(rootDir), files.Selection{
+		ExcludePatterns: []string{"**/*.log", "**/.*", "internal"},
+		ExplicitFiles:   []string{"internal/auth"},
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		"internal/auth", "internal/auth/auth.go", "internal/auth/debug.log", "internal/auth/.env",
+		"internal/auth/jwt", "internal/auth/jwt/token.go",
+	}, paths)
+}
@@ -0,0 +1,103 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseDockerignore tests matching paths against .dockerignore patterns, with negations.
+func TestParseDockerignore(t *testing.T) {
+	ignore, err := files.ParseDockerignore(`# build context
+node_modules
+/dist/
+**/*.log
+docs/*
+!docs/api.md
+*.md
+!README.md
+`)
+	require.NoError(t, err)
+
+	for relPath, expected := range map[string]bool{
+		"node_modules/lib/index.js": true,
+		"src/node_modules/a.js":     false,
+		"dist/app.js":               true,
+		"logs/today.log":            true,
+		"app.log":                   true,
+		"docs/guide.txt":            true,
+		"docs/api.md":               false,
+		"CHANGELOG.md":              true,
+		"README.md":                 false,
+		"src/README.md":             false,
+		"src/main.go":               false,
+	} {
+		ignored, err := ignore.Ignored(relPath)
+		require.NoError(t, err)
+		require.Equal(t, expected, ignored, relPath)
+	}
+
+	_, err = files.ParseDockerignore("src/[a-")
+	require.Error(t, err)
+}
+
+// TestReadDockerignore tests that a missing .dockerignore is not an error.
+func TestReadDockerignore(t *testing.T) {
+	dir := t.TempDir()
+	ignore, err := files.ReadDockerignore(dir)
+	require.NoError(t, err)
+	require.Nil(t, ignore)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("*.tmp\n"), 0644))
+	ignore, err = files.ReadDockerignore(dir)
+	require.NoError(t, err)
+	ignored, err := ignore.Ignored("scratch.tmp")
+	require.NoError(t, err)
+	require.True(t, ignored)
+}
+
+// TestParseGitignore tests matching paths against patterns with the semantics of .gitignore files:
+// unanchored patterns, directory-only patterns, negations and ignored parent directories.
+func TestParseGitignore(t *testing.T) {
+	ignore, err := files.ParseGitignore(`# generated
+*.log
+!keep.log
+/dist
+build/
+docs/internal/
+tmp
+!tmp/notes.txt
+\#hash.txt
+\!bang.txt
+trailing\ 
+`)
+	require.NoError(t, err)
+
+	for relPath, expected := range map[string]bool{
+		"app.log":             true,
+		"logs/deep/today.log": true,
+		"logs/keep.log":       false,
+		"dist/app.js":         true,
+		"src/dist/app.js":     false,
+		"build/out.o":         true,
+		"src/build/out.o":     true,
+		"build":               false,
+		"docs/internal/a.md":  true,
+		"docs/guide.md":       false,
+		"tmp/notes.txt":       true,
+		"#hash.txt":           true,
+		"!bang.txt":           true,
+		"trailing ":           true,
+		"src/main.go":         false,
+	} {
+		ignored, err := ignore.Ignored(relPath)
+		require.NoError(t, err)
+		require.Equal(t, expected, ignored, relPath)
+	}
+
+	_, err = files.ParseGitignore("src/[a-")
+	require.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package files_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIsMinified tests the heuristic detection of minified JS and CSS assets.
+func TestIsMinified(t *testing.T) {
+	minifiedJS := strings.Repeat("function a(b){return b+1};var c=a(2);", 100)
+	formattedJS := strings.Repeat("function add(value) {\n    return value + 1;\n}\n\n", 100)
+
+	testCases := []struct {
+		name     string
+		path     string
+		content  string
+		expected bool
+	}{
+		{"minified js", "static/app.js", minifiedJS, true},
+		{"minified css", "static/app.css", strings.Repeat(".a{color:red;margin:0}", 100), true},
+		{"formatted js", "src/app.js", formattedJS, false},
+		{"min suffix", "vendor/jquery.min.js", "short", true},
+		{"small minified js", "src/tiny.js", "var a=1;var b=2;", false},
+		{"long line in other extension", "data/blob.txt", minifiedJS, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, files.IsMinified(tc.path, tc.content))
+		})
+	}
+}
+
+// TestReplaceMinified tests that minified files are replaced with a placeholder.
+func TestReplaceMinified(t *testing.T) {
+	fileContentMap := map[string]string{
+		"app.min.js": "var a=1;",
+		"main.go":    "package main",
+	}
+
+	replaced := files.ReplaceMinified(fileContentMap)
+
+	require.Equal(t, []string{"app.min.js"}, replaced)
+	require.Equal(t, "minified file skipped (8 bytes)", fileContentMap["app.min.js"])
+	require.Equal(t, "package main", fileContentMap["main.go"])
+}
@@ -0,0 +1,63 @@
+package files_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFilePragma tests finding pragmas in the comments of the first lines of a file.
+func TestFilePragma(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected files.Pragma
+	}{
+		{"// crev:ignore\npackage main", files.PragmaIgnore},
+		{"#!/usr/bin/env python\n# crev:include\n", files.PragmaInclude},
+		{"-- crev:ignore generated schema\n", files.PragmaIgnore},
+		{"<!-- crev:ignore -->\n<html>", files.PragmaIgnore},
+		{"/*\n * crev:include\n */", files.PragmaInclude},
+		{"package main\n\n//crev:ignore", files.PragmaIgnore},
+		{"// crev:ignored\n", ""},
+		{`fmt.Println("crev:ignore")`, ""},
+		{strings.Repeat("\n", 10) + "// crev:ignore", ""},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, files.FilePragma(tc.content), tc.content)
+	}
+}
+
+// TestSelectPragmas tests excluding and pinning files with pragmas.
+func TestSelectPragmas(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"main.go":               "package main",
+		"gen/types.go":          "// crev:ignore\npackage gen",
+		"docs/design.md":        "<!-- crev:include -->\n# Design",
+		"docs/notes.md":         "# Notes",
+		"scripts/setup.sh":      "#!/bin/sh\n# crev:include\n",
+		"vendor/lib/lib.go":     "// crev:include\npackage lib",
+		"explicit/ignored.go":   "// crev:ignore\npackage explicit",
+		"explicit/unrelated.go": "package explicit",
+	})
+
+	sel := files.Selection{
+		IncludePatterns: []string{"**/*.go", "**/*.md"},
+		ExcludePatterns: []string{"docs/*.md", "vendor"},
+		ExplicitFiles:   []string{rootDir + "/explicit/ignored.go"},
+	}
+	filePaths, err := files.Select(rootDir, sel)
+	require.NoError(t, err)
+	assertFileSetMatches(t, filePaths, []string{"main.go", "gen/types.go", "explicit/ignored.go", "explicit/unrelated.go"}, []string{"docs/design.md", "scripts/setup.sh"})
+
+	sel.Pragmas = true
+	filePaths, err = files.Select(rootDir, sel)
+	require.NoError(t, err)
+	assertFileSetMatches(t, filePaths,
+		[]string{"main.go", "docs/design.md", "scripts/setup.sh", "explicit/ignored.go", "explicit/unrelated.go"},
+		[]string{"gen/types.go", "docs/notes.md", "vendor/lib/lib.go"})
+}
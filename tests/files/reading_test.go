@@ -1,6 +1,7 @@
 package files_test
 
 import (
+	"fmt"
 	"github.com/devinbarry/crev/internal/files"
 	"github.com/stretchr/testify/require"
 	"path/filepath"
@@ -40,3 +41,24 @@ func TestGetContentMapOfFiles(t *testing.T) {
 	require.NotContains(t, fileContentMap, subDir1, "Non-empty directory should not be in map")
 	require.Equal(t, "empty directory", fileContentMap[subDir2], "Empty directory not properly marked")
 }
+
+// TestGetContentMapOfFilesWorkerPool tests reading more files than workers, and that a missing file fails the read.
+func TestGetContentMapOfFilesWorkerPool(t *testing.T) {
+	rootDir := t.TempDir()
+	var filePaths []string
+	for i := 0; i < 50; i++ {
+		filePath := filepath.Join(rootDir, fmt.Sprintf("file%d.txt", i))
+		createFile(t, filePath, fmt.Sprintf("content%d", i))
+		filePaths = append(filePaths, filePath)
+	}
+
+	for _, maxConcurrency := range []int{0, 1, 7} {
+		fileContentMap, err := files.GetContentMapOfFiles(filePaths, maxConcurrency)
+		require.NoError(t, err)
+		require.Len(t, fileContentMap, len(filePaths))
+		require.Equal(t, "content42", fileContentMap[filePaths[42]])
+	}
+
+	_, err := files.GetContentMapOfFiles(append(filePaths, filepath.Join(rootDir, "missing.txt")), 4)
+	require.Error(t, err)
+}
@@ -0,0 +1,122 @@
+package files_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// selectWithExpr returns the paths of the files of rootDir matching expr, without directories.
+func selectWithExpr(t *testing.T, rootDir, expr string) []string {
+	t.Helper()
+	parsed, err := files.ParseSelectExpr(expr)
+	require.NoError(t, err)
+	filter, err := parsed.Filter(rootDir)
+	require.NoError(t, err)
+	entries, err := files.SelectEntries(rootDir, files.Selection{
+		IncludePatterns: []string{"**/*"},
+		Filters:         []files.FileFilter{filter},
+	})
+	require.NoError(t, err)
+	var filePaths []string
+	for _, entry := range entries {
+		if !entry.IsDir {
+			filePaths = append(filePaths, entry.Path)
+		}
+	}
+	return filePaths
+}
+
+// TestSelectExpr tests combining the path, language, test, size and mtime predicates.
+func TestSelectExpr(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"main.go":           "package main",
+		"main_test.go":      "package main",
+		"big.go":            "package main\n" + strings.Repeat("// padding\n", 200),
+		"old.go":            "package main",
+		"app/app.py":        "print('app')",
+		"app/test_app.py":   "print('test')",
+		"web/index.tsx":     "export {}",
+		"web/index.spec.ts": "test()",
+		"tests/data.json":   "{}",
+	})
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(rootDir, "old.go"), old, old))
+
+	testCases := []struct {
+		expr     string
+		expected []string
+	}{
+		{"lang:go and not test and size<1kb", []string{"main.go", "old.go"}},
+		{"lang:go and mtime<7d", []string{"main.go", "main_test.go", "big.go"}},
+		{"LANG:GO AND MTIME>7D", []string{"old.go"}},
+		{"test", []string{"main_test.go", "app/test_app.py", "web/index.spec.ts", "tests/data.json"}},
+		{"(lang:py or lang:tsx) and not test", []string{"app/app.py", "web/index.tsx"}},
+		{"not (lang:go or glob:web/**) and not glob:tests/*", []string{"app/app.py", "app/test_app.py"}},
+		{"size>=1kb", []string{"big.go"}},
+	}
+	for _, tc := range testCases {
+		filePaths := selectWithExpr(t, rootDir, tc.expr)
+		require.ElementsMatch(t, tc.expected, filePaths, tc.expr)
+	}
+}
+
+// TestSelectExprGit tests the git status predicates.
+func TestSelectExprGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"committed.go": "package main",
+		"changed.go":   "package main",
+		"staged.go":    "package main",
+		"new.go":       "package main",
+		"ignored.go":   "package main",
+		".gitignore":   "ignored.go\n",
+	})
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = rootDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	git("add", "committed.go", "changed.go", ".gitignore")
+	git("commit", "-q", "-m", "initial")
+	createFiles(t, rootDir, map[string]string{"changed.go": "package changed"})
+	git("add", "staged.go")
+
+	require.ElementsMatch(t, []string{"changed.go", "staged.go"}, selectWithExpr(t, rootDir, "git:modified"))
+	require.ElementsMatch(t, []string{"staged.go"}, selectWithExpr(t, rootDir, "git:staged"))
+	require.ElementsMatch(t, []string{"new.go", "ignored.go"}, selectWithExpr(t, rootDir, "not git:tracked and lang:go"))
+	require.ElementsMatch(t, []string{"new.go"}, selectWithExpr(t, rootDir, "git:untracked and lang:go"))
+}
+
+// TestParseSelectExprErrors tests that invalid expressions are reported.
+func TestParseSelectExprErrors(t *testing.T) {
+	testCases := map[string]string{
+		"":                 "empty expression",
+		"lang:go and":      "unexpected end of expression",
+		"(lang:go or test": "missing closing parenthesis",
+		"lang:go test":     `unexpected "test"`,
+		"and test":         `unexpected "and"`,
+		"size<10tb":        `unsupported size unit "tb"`,
+		"mtime<3y":         `unsupported duration unit "y"`,
+		"git:ignored":      `unsupported git status "ignored"`,
+		"lang:":            `missing value of predicate "lang:"`,
+		"glob:[src":        `invalid pattern "[src"`,
+		"name:main.go":     `unknown predicate "name:main.go"`,
+	}
+	for expr, expected := range testCases {
+		_, err := files.ParseSelectExpr(expr)
+		require.ErrorContains(t, err, expected, expr)
+	}
+}
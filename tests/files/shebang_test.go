@@ -0,0 +1,55 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShebangExtension tests detecting the language of a script from its shebang line.
+func TestShebangExtension(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected string
+	}{
+		{"#!/usr/bin/env python\nprint('hi')", ".py"},
+		{"#!/usr/bin/python3.12\n", ".py"},
+		{"#!/bin/bash -e\n", ".sh"},
+		{"#! /bin/sh\n", ".sh"},
+		{"#!/usr/bin/env -S node --no-warnings\n", ".js"},
+		{"#!/usr/bin/env FOO=1 ruby\n", ".rb"},
+		{"#!/usr/bin/env\n", ""},
+		{"#!/usr/bin/unknown\n", ""},
+		{"print('no shebang')\n", ""},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, files.ShebangExtension(tc.content), tc.content)
+	}
+}
+
+// TestSelectDetectShebang tests matching extensionless scripts by the language of their shebang.
+func TestSelectDetectShebang(t *testing.T) {
+	rootDir := t.TempDir()
+	createFiles(t, rootDir, map[string]string{
+		"bin/deploy":   "#!/usr/bin/env python3\nprint('deploy')",
+		"bin/setup":    "#!/bin/bash\necho setup",
+		"bin/README":   "Scripts",
+		"src/app.py":   "print('app')",
+		"src/build.sh": "#!/usr/bin/env python\n",
+	})
+
+	sel := files.Selection{IncludePatterns: []string{"**/*.py"}}
+	filePaths, err := files.Select(rootDir, sel)
+	require.NoError(t, err)
+	assertFileSetMatches(t, filePaths, []string{"src/app.py"}, []string{"bin/deploy"})
+
+	sel.DetectShebang = true
+	filePaths, err = files.Select(rootDir, sel)
+	require.NoError(t, err)
+	assertFileSetMatches(t, filePaths,
+		[]string{"bin/deploy", "src/app.py"},
+		[]string{"bin/setup", "bin/README", "src/build.sh"})
+}
@@ -0,0 +1,28 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseSize tests parsing sizes with and without units.
+func TestParseSize(t *testing.T) {
+	for value, expected := range map[string]int64{
+		"1048576": 1048576,
+		"500kb":   500 << 10,
+		"1.5 MB":  3 << 19,
+		"2gb":     2 << 30,
+		"10b":     10,
+	} {
+		size, err := files.ParseSize(value)
+		require.NoError(t, err, value)
+		require.Equal(t, expected, size, value)
+	}
+
+	_, err := files.ParseSize("big")
+	require.ErrorContains(t, err, `invalid size "big"`)
+	_, err = files.ParseSize("1tb")
+	require.ErrorContains(t, err, `unsupported size unit "tb"`)
+}
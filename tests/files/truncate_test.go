@@ -0,0 +1,30 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTruncateLines tests cutting files after a number of lines with a marker.
+func TestTruncateLines(t *testing.T) {
+	fileContentMap := map[string]string{
+		"long.go":     "1\n2\n3\n4\n5\n",
+		"no-eol.go":   "1\n2\n3\n4",
+		"exact.go":    "1\n2\n3\n",
+		"short.go":    "1",
+		"empty.go":    "",
+		"newlines.go": "\n\n\n\n",
+	}
+
+	truncated := files.TruncateLines(fileContentMap, 3)
+	require.ElementsMatch(t, []string{"long.go", "no-eol.go", "newlines.go"}, truncated)
+	require.Equal(t, "1\n2\n3\n… truncated (2 more lines)", fileContentMap["long.go"])
+	require.Equal(t, "1\n2\n3\n… truncated (1 more line)", fileContentMap["no-eol.go"])
+	require.Equal(t, "\n\n\n… truncated (1 more line)", fileContentMap["newlines.go"])
+	require.Equal(t, "1\n2\n3\n", fileContentMap["exact.go"])
+	require.Equal(t, "1", fileContentMap["short.go"])
+
+	require.Empty(t, files.TruncateLines(fileContentMap, 0))
+}
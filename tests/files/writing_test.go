@@ -1,11 +1,13 @@
 package files_test
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/devinbarry/crev/internal/files"
+	"github.com/stretchr/testify/require"
 )
 
 // Tests the functionality to save the project string to a file.
@@ -27,3 +29,105 @@ func TestSaveStringToFile(t *testing.T) {
 		t.Errorf("expected content %s, got %s", content, string(savedContent))
 	}
 }
+
+// Tests that a file sink creates the file and writes the content to it.
+func TestOpenSinkFile(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "bundle.txt")
+
+	sink, err := files.OpenSink(tempFile)
+	require.NoError(t, err)
+	_, err = io.WriteString(sink, "bundle content")
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+
+	savedContent, err := os.ReadFile(tempFile)
+	require.NoError(t, err)
+	require.Equal(t, "bundle content", string(savedContent))
+}
+
+// Tests that a file sink keeps the permissions of the file it replaces, and creates new files
+// with the permissions of os.Create.
+func TestOpenSinkFileMode(t *testing.T) {
+	dir := t.TempDir()
+	reference, err := os.Create(filepath.Join(dir, "reference.txt"))
+	require.NoError(t, err)
+	require.NoError(t, reference.Close())
+	referenceInfo, err := os.Stat(reference.Name())
+	require.NoError(t, err)
+
+	target := filepath.Join(dir, "bundle.txt")
+	for _, expected := range []os.FileMode{referenceInfo.Mode().Perm(), 0600} {
+		sink, err := files.OpenSink(target)
+		require.NoError(t, err)
+		_, err = io.WriteString(sink, "bundle content")
+		require.NoError(t, err)
+		require.NoError(t, sink.Close())
+
+		info, err := os.Stat(target)
+		require.NoError(t, err)
+		require.Equal(t, expected, info.Mode().Perm())
+		require.NoError(t, os.Chmod(target, 0600))
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "No temporary or probe files should be left")
+}
+
+// Tests that a pipe sink writes to the standard input of the command and waits for it on Close.
+func TestOpenSinkPipe(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "piped.txt")
+
+	sink, err := files.OpenSink("| cat > " + tempFile)
+	require.NoError(t, err)
+	_, err = io.WriteString(sink, "piped content")
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+
+	savedContent, err := os.ReadFile(tempFile)
+	require.NoError(t, err)
+	require.Equal(t, "piped content", string(savedContent))
+}
+
+// Tests that a pipe sink without a command is rejected.
+func TestOpenSinkEmptyPipe(t *testing.T) {
+	_, err := files.OpenSink("|")
+	require.Error(t, err)
+}
+
+// Tests that a file sink only replaces the target on Close, so concurrent writers never interleave.
+func TestOpenSinkFileConcurrentWriters(t *testing.T) {
+	tempDir := t.TempDir()
+	tempFile := filepath.Join(tempDir, "bundle.txt")
+
+	first, err := files.OpenSink(tempFile)
+	require.NoError(t, err)
+	second, err := files.OpenSink(tempFile)
+	require.NoError(t, err)
+
+	_, err = io.WriteString(first, "first ")
+	require.NoError(t, err)
+	_, err = io.WriteString(second, "second ")
+	require.NoError(t, err)
+	_, err = io.WriteString(first, "bundle")
+	require.NoError(t, err)
+	_, err = io.WriteString(second, "bundle")
+	require.NoError(t, err)
+
+	require.NoFileExists(t, tempFile, "Target should not exist before the sink is closed")
+
+	require.NoError(t, first.Close())
+	savedContent, err := os.ReadFile(tempFile)
+	require.NoError(t, err)
+	require.Equal(t, "first bundle", string(savedContent))
+
+	require.NoError(t, second.Close())
+	savedContent, err = os.ReadFile(tempFile)
+	require.NoError(t, err)
+	require.Equal(t, "second bundle", string(savedContent))
+
+	// No temporary files should be left behind
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
@@ -0,0 +1,81 @@
+package findings_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/findings"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseJSON tests parsing findings from structured model output.
+func TestParseJSON(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+	}{
+		{"array", `[{"file": "main.go", "line": 3, "end_line": 5, "severity": "Warning", "message": "Unused variable"}]`},
+		{"object", `{"findings": [{"file": "main.go", "line": 3, "end_line": 5, "severity": "warning", "message": "Unused variable"}]}`},
+		{"code block", "Here is my review:\n\n```json\n[{\"file\": \"main.go\", \"line\": 3, \"end_line\": 5, \"severity\": \"warning\", \"message\": \"Unused variable\"}]\n```\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := findings.Parse(tc.text)
+			require.NoError(t, err)
+			require.Equal(t, []findings.Finding{
+				{File: "main.go", Line: 3, EndLine: 5, Severity: "warning", Message: "Unused variable"},
+			}, result)
+		})
+	}
+}
+
+// TestParseLines tests parsing findings written one per line.
+func TestParseLines(t *testing.T) {
+	text := `## Review
+
+The code looks good overall.
+
+- ` + "`cmd/root.go:12-14`" + `: [error] The error of ReadInConfig is ignored
+- internal/files/globbing.go:40: Patterns are compiled on every call
+See also https://example.com/docs for details.
+`
+
+	result, err := findings.Parse(text)
+	require.NoError(t, err)
+	require.Equal(t, []findings.Finding{
+		{File: "cmd/root.go", Line: 12, EndLine: 14, Severity: "error", Message: "The error of ReadInConfig is ignored"},
+		{File: "internal/files/globbing.go", Line: 40, EndLine: 40, Message: "Patterns are compiled on every call"},
+	}, result)
+}
+
+// TestParseInvalid tests that findings without a file or line are rejected.
+func TestParseInvalid(t *testing.T) {
+	_, err := findings.Parse(`[{"file": "main.go", "message": "No line"}]`)
+	require.ErrorContains(t, err, "finding 1 (main.go) has no valid line number")
+
+	_, err = findings.Parse(`[{"line": 3, "message": "No file"}]`)
+	require.ErrorContains(t, err, "finding 1 has no file")
+}
+
+// TestWriteReport tests that findings are grouped by file and ordered by line.
+func TestWriteReport(t *testing.T) {
+	var sb strings.Builder
+	err := findings.WriteReport(&sb, []findings.Finding{
+		{File: "main.go", Line: 40, EndLine: 40, Message: "Missing error check"},
+		{File: "cmd/root.go", Line: 12, EndLine: 14, Severity: "error", Message: "Ignored error"},
+		{File: "main.go", Line: 3, EndLine: 5, Severity: "warning", Message: "Unused variable"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `cmd/root.go (1 finding)
+  12-14  error  Ignored error
+
+main.go (2 findings)
+  3-5  warning  Unused variable
+  40            Missing error check
+`, sb.String())
+
+	sb.Reset()
+	require.NoError(t, findings.WriteReport(&sb, nil))
+	require.Equal(t, "No findings.\n", sb.String())
+}
@@ -0,0 +1,26 @@
+package formatting_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewBundle tests that the bundle model orders file entries and computes stats.
+func TestNewBundle(t *testing.T) {
+	paths := []string{"src", "src/main.go", "go.mod"}
+	fileContentMap := map[string]string{
+		"src/main.go": "package main\n",
+		"go.mod":      "module x\n",
+	}
+
+	b := formatting.NewBundle(paths, fileContentMap)
+
+	require.Equal(t, []string{"src", "src/main.go", "go.mod"}, paths, "NewBundle should not reorder its input")
+	require.Equal(t, "├── go.mod\n└── src (1 file)\n    └── main.go\n", b.Tree)
+	require.Len(t, b.Files, 2)
+	require.Equal(t, formatting.FileEntry{Path: "go.mod", Size: 9, Content: "module x\n"}, b.Files[0])
+	require.Equal(t, "src/main.go", b.Files[1].Path)
+	require.Equal(t, formatting.Stats{FileCount: 2, TotalBytes: 22}, b.Stats)
+}
@@ -0,0 +1,47 @@
+package formatting_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFence tests wrapping file contents in code fences, lengthened for contents containing fences.
+func TestFence(t *testing.T) {
+	fileContentMap := map[string]string{
+		"main.go":   "package main\n",
+		"README.md": "# Readme\n\n```go\nfmt.Println()\n```",
+		"bin/run":   "#!/usr/bin/env python3\nprint('run')\n",
+		"data.bin":  "raw",
+	}
+	b := formatting.NewBundle([]string{"README.md", "bin/run", "data.bin", "main.go"}, fileContentMap)
+	b.Tree = ""
+	b.Fence = &formatting.Fence{Char: '`', Length: 3, Language: true}
+
+	rendered := formatting.RenderText(b)
+	require.Equal(t, "File: \nREADME.md\nContent: \n````markdown\n# Readme\n\n```go\nfmt.Println()\n```\n````\n\n"+
+		"File: \nbin/run\nContent: \n```python\n#!/usr/bin/env python3\nprint('run')\n\n```\n\n"+
+		"File: \ndata.bin\nContent: \n```\nraw\n```\n\n"+
+		"File: \nmain.go\nContent: \n```go\npackage main\n\n```\n\n", rendered)
+
+	// The fences are removed when the bundle is parsed
+	parsed, err := formatting.ParseText(rendered)
+	require.NoError(t, err)
+	require.Equal(t, b.Files, parsed.Files)
+
+	index := formatting.NewIndex("crev-project.txt", b)
+	for _, entry := range index.Files {
+		require.Equal(t, fileContentMap[entry.Path], rendered[entry.Offset:entry.Offset+entry.Length], entry.Path)
+	}
+
+	b.Fence = &formatting.Fence{Char: '~', Length: 4}
+	require.Contains(t, formatting.RenderText(b), "Content: \n~~~~\npackage main\n\n~~~~\n\n")
+}
+
+// TestValidateFence tests the validation of the fence options.
+func TestValidateFence(t *testing.T) {
+	require.NoError(t, formatting.ValidateFence(formatting.Fence{Char: '~', Length: 3}))
+	require.ErrorContains(t, formatting.ValidateFence(formatting.Fence{Char: '*', Length: 3}), "invalid fence character")
+	require.ErrorContains(t, formatting.ValidateFence(formatting.Fence{Char: '`', Length: 2}), "invalid fence length 2")
+}
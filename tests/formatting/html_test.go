@@ -0,0 +1,41 @@
+package formatting_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTMLFormatter tests rendering a bundle as a page with collapsible, highlighted files.
+func TestHTMLFormatter(t *testing.T) {
+	b := formatting.NewBundle([]string{"main.go", "notes.txt", "empty.txt"}, map[string]string{
+		"main.go":   "package main\n\n// Run it\nfunc main() { println(\"<a>\", 42) }\n",
+		"notes.txt": "if <b> & \"c\"\n",
+		"empty.txt": "",
+	})
+	b.Sections = []formatting.Section{
+		{Kind: formatting.CustomSection, Title: "Review <focus>", Content: "Check main.go"},
+		{Kind: formatting.TreeSection},
+		{Kind: formatting.FilesSection},
+	}
+
+	var buf bytes.Buffer
+	var formatter formatting.Formatter = formatting.HTMLFormatter{}
+	require.NoError(t, formatter.Write(&buf, b))
+	page := buf.String()
+
+	require.Contains(t, page, "<!DOCTYPE html>\n")
+	require.NotContains(t, page, "<script")
+	require.NotContains(t, page, "empty.txt<")
+	require.Contains(t, page, "<details open><summary>Review &lt;focus&gt;</summary>\n<pre><code>Check main.go</code></pre>\n</details>\n"+
+		"<details open><summary>Project Directory Structure</summary>\n")
+	require.Contains(t, page, `<details open><summary>main.go<span class="meta">4 lines</span></summary>`+"\n<pre><code>"+
+		`<span class="k">package</span> main`+"\n\n"+
+		`<span class="c">// Run it</span>`+"\n"+
+		`<span class="k">func</span> main() { println(<span class="s">&#34;&lt;a&gt;&#34;</span>, <span class="n">42</span>) }`+"\n</code></pre>")
+	// Files of unknown languages are only escaped
+	require.Contains(t, page, "<pre><code>if &lt;b&gt; &amp; &#34;c&#34;\n</code></pre>")
+	require.Less(t, bytes.Index(buf.Bytes(), []byte("main.go<span")), bytes.Index(buf.Bytes(), []byte("notes.txt<span")))
+}
@@ -0,0 +1,34 @@
+package formatting_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportGraph tests rendering who imports whom after the symbol index and parsing it back.
+func TestImportGraph(t *testing.T) {
+	fileContentMap := map[string]string{
+		"src/app.js":  "import { a } from './a'\nimport { b } from './b'\n",
+		"src/a.js":    "import { b } from './b'\n",
+		"src/b.js":    "export function b() {}\n",
+		"src/util.js": "import lodash from 'lodash'\n",
+	}
+	b := formatting.NewBundle([]string{"src/a.js", "src/app.js", "src/b.js", "src/util.js"}, fileContentMap)
+	b.Imports = formatting.GenerateImportGraph(b.Files, "")
+	require.Equal(t, "src/a.js\n  imports: src/b.js\n  imported by: src/app.js\n"+
+		"src/app.js\n  imports: src/a.js, src/b.js\n"+
+		"src/b.js\n  imported by: src/a.js, src/app.js\n", b.Imports)
+
+	b.Symbols = formatting.GenerateSymbolIndex(b.Files)
+	rendered := formatting.RenderText(b)
+	require.Regexp(t, "(?s)\n\nSymbol Index:\n.*\n\nImport Graph:\nsrc/a.js\n.*imported by: src/a.js, src/app.js\n\n$", rendered)
+
+	parsed, err := formatting.ParseText(rendered)
+	require.NoError(t, err)
+	require.Equal(t, b.Files, parsed.Files)
+	require.Equal(t, b.Symbols, parsed.Symbols)
+	require.Equal(t, b.Imports, parsed.Imports)
+	require.Empty(t, parsed.Sections)
+}
@@ -0,0 +1,65 @@
+package formatting_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewIndex tests that the index offsets point at the file contents of the rendered bundle.
+func TestNewIndex(t *testing.T) {
+	paths := []string{"src", "src/main.go", "src/empty.go", "README.md"}
+	fileContentMap := map[string]string{
+		"src/main.go":  "package main\n\nfunc main() {}\n",
+		"src/empty.go": "",
+		"README.md":    "# Readme",
+	}
+	b := formatting.NewBundle(paths, fileContentMap)
+	rendered := formatting.RenderText(b)
+	lines := strings.Split(rendered, "\n")
+
+	index := formatting.NewIndex("crev-project.txt", b)
+	require.Equal(t, "crev-project.txt", index.Bundle)
+	require.Len(t, index.Files, 2, "files without content are not rendered")
+
+	for _, entry := range index.Files {
+		content := fileContentMap[entry.Path]
+		require.Equal(t, content, rendered[entry.Offset:entry.Offset+entry.Length], entry.Path)
+		require.Equal(t, strings.Split(content, "\n")[0], lines[entry.Line-1], entry.Path)
+	}
+	require.Equal(t, 1, index.Files[0].Lines, "README.md")
+	require.Equal(t, 3, index.Files[1].Lines, "src/main.go")
+}
+
+// TestNewIndexWithoutTree tests the offsets of a bundle rendered without the project tree section.
+func TestNewIndexWithoutTree(t *testing.T) {
+	b := formatting.NewBundle([]string{"main.go"}, map[string]string{"main.go": "package main"})
+	b.Tree = ""
+	rendered := formatting.RenderText(b)
+
+	index := formatting.NewIndex("crev-project.txt", b)
+	require.Len(t, index.Files, 1)
+	require.Equal(t, "package main", rendered[index.Files[0].Offset:index.Files[0].Offset+index.Files[0].Length])
+	require.Equal(t, 4, index.Files[0].Line)
+}
+
+// TestIndexWriteJSON tests the JSON encoding of the index.
+func TestIndexWriteJSON(t *testing.T) {
+	index := &formatting.Index{
+		Bundle: "crev-project.txt",
+		Files:  []formatting.IndexEntry{{Path: "a.go", Offset: 10, Length: 5, Line: 3, Lines: 1}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, index.WriteJSON(&buf))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, "crev-project.txt", decoded["bundle"])
+	require.Equal(t, map[string]any{"path": "a.go", "offset": 10.0, "length": 5.0, "line": 3.0, "lines": 1.0},
+		decoded["files"].([]any)[0])
+}
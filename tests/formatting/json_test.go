@@ -0,0 +1,55 @@
+package formatting_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJSONFormatter tests writing a bundle as a single JSON document with the custom sections.
+func TestJSONFormatter(t *testing.T) {
+	b := formatting.NewBundle([]string{"main.go", "empty.txt"}, map[string]string{
+		"main.go":   "package main\n\nfunc main() { _ = a < b && c }\n",
+		"empty.txt": "",
+	})
+	b.Sections = []formatting.Section{
+		{Kind: formatting.CustomSection, Title: "Instructions", Content: "Review it"},
+		{Kind: formatting.TreeSection},
+		{Kind: formatting.FilesSection},
+	}
+
+	var buf bytes.Buffer
+	var formatter formatting.Formatter = formatting.JSONFormatter{}
+	require.NoError(t, formatter.Write(&buf, b))
+	require.Equal(t, `{
+  "tree": "├── empty.txt\n└── main.go\n",
+  "files": [
+    {
+      "path": "empty.txt",
+      "size": 0,
+      "tokens": 0,
+      "content": ""
+    },
+    {
+      "path": "main.go",
+      "size": 45,
+      "tokens": 15,
+      "content": "package main\n\nfunc main() { _ = a < b && c }\n"
+    }
+  ],
+  "sections": [
+    {
+      "title": "Instructions",
+      "content": "Review it"
+    }
+  ],
+  "stats": {
+    "files": 2,
+    "bytes": 45,
+    "tokens": 15
+  }
+}
+`, buf.String())
+}
@@ -0,0 +1,28 @@
+package formatting_test
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/devinbarry/crev/internal/files"
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNDJSONWriter tests writing one JSON object per file with its metadata.
+func TestNDJSONWriter(t *testing.T) {
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	w := formatting.NewNDJSONWriter(&buf)
+	require.NoError(t, w.Write(formatting.NewNDJSONRecord("main.go", "package main\n\nfunc main() {}",
+		files.Entry{Path: "main.go", Mode: 0o644, ModTime: modTime})))
+	require.NoError(t, w.Write(formatting.NewNDJSONRecord("empty.txt", "", files.Entry{Path: "empty.txt"})))
+	require.NoError(t, w.Write(formatting.NewNDJSONRecord("run", "<a> & b\n", files.Entry{Path: "run", Mode: fs.ModePerm})))
+
+	require.Equal(t, `{"path":"main.go","size":28,"lines":3,"language":"go","mode":"-rw-r--r--","mod_time":"2024-05-01T12:00:00Z","content":"package main\n\nfunc main() {}"}
+{"path":"empty.txt","size":0,"lines":0,"content":""}
+{"path":"run","size":8,"lines":1,"mode":"-rwxrwxrwx","content":"<a> & b\n"}
+`, buf.String())
+}
@@ -0,0 +1,45 @@
+package formatting_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSectionsRoundTrip tests rendering a bundle with custom sections in a configured order and parsing it back.
+func TestSectionsRoundTrip(t *testing.T) {
+	b := formatting.NewBundle([]string{"main.go"}, map[string]string{"main.go": "package main"})
+	b.Sections = []formatting.Section{
+		{Kind: formatting.CustomSection, Title: "Review instructions", Content: "Focus on error handling."},
+		{Kind: formatting.FilesSection},
+		{Kind: formatting.TreeSection},
+	}
+	rendered := formatting.RenderText(b)
+	require.True(t, strings.HasPrefix(rendered,
+		"Section: \nReview instructions\nContent: \nFocus on error handling.\n\nFile: \nmain.go\nContent: \npackage main\n\nProject Directory Structure:\n"))
+
+	parsed, err := formatting.ParseText(rendered)
+	require.NoError(t, err)
+	require.Equal(t, b.Tree, parsed.Tree)
+	require.Equal(t, b.Files, parsed.Files)
+	require.Equal(t, b.Sections, parsed.Sections)
+
+	index := formatting.NewIndex("crev-project.txt", b)
+	require.Equal(t, "package main", rendered[index.Files[0].Offset:index.Files[0].Offset+index.Files[0].Length])
+	require.Equal(t, 9, index.Files[0].Line)
+
+	// The default layout is not recorded
+	parsed, err = formatting.ParseText(formatting.RenderText(formatting.NewBundle([]string{"main.go"}, map[string]string{"main.go": "x"})))
+	require.NoError(t, err)
+	require.Nil(t, parsed.Sections)
+}
+
+// TestValidateSections tests the validation of configured sections.
+func TestValidateSections(t *testing.T) {
+	require.NoError(t, formatting.ValidateSections(formatting.DefaultSections))
+	require.ErrorContains(t, formatting.ValidateSections([]formatting.Section{{Kind: "tree"}, {Kind: "tree"}}), "more than once")
+	require.ErrorContains(t, formatting.ValidateSections([]formatting.Section{{Kind: "metadata"}}), `unknown section "metadata"`)
+	require.ErrorContains(t, formatting.ValidateSections([]formatting.Section{{Kind: formatting.CustomSection}}), "without title")
+}
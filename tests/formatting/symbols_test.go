@@ -0,0 +1,39 @@
+package formatting_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSymbolIndex tests rendering the symbol index at the end of the bundle and parsing it back.
+func TestSymbolIndex(t *testing.T) {
+	fileContentMap := map[string]string{
+		"main.go":   "package main\n\nfunc main() {}\n",
+		"README.md": "# Readme\n",
+		"app.py":    "class App:\n    pass\n",
+	}
+	b := formatting.NewBundle([]string{"README.md", "app.py", "main.go"}, fileContentMap)
+	b.Tree = ""
+	b.Symbols = formatting.GenerateSymbolIndex(b.Files)
+	require.Equal(t, "app.py\n  class App (line 1)\nmain.go\n  func main (line 3)\n", b.Symbols)
+
+	rendered := formatting.RenderText(b)
+	require.Contains(t, rendered, "File: \nmain.go\nContent: \npackage main\n\nfunc main() {}\n\n\n"+
+		"Symbol Index:\napp.py\n  class App (line 1)\nmain.go\n  func main (line 3)\n\n")
+
+	parsed, err := formatting.ParseText(rendered)
+	require.NoError(t, err)
+	require.Equal(t, b.Files, parsed.Files)
+	require.Equal(t, b.Symbols, parsed.Symbols)
+	require.Empty(t, parsed.Sections)
+
+	// The sections can place the symbol index elsewhere
+	b.Sections = []formatting.Section{{Kind: formatting.SymbolsSection}, {Kind: formatting.FilesSection}}
+	rendered = formatting.RenderText(b)
+	require.Regexp(t, "^Symbol Index:\n", rendered)
+	parsed, err = formatting.ParseText(rendered)
+	require.NoError(t, err)
+	require.Equal(t, b.Files, parsed.Files)
+}
@@ -0,0 +1,71 @@
+package formatting_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/formatting"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildTree tests the nested tree with sorted children, empty directories and summed sizes.
+func TestBuildTree(t *testing.T) {
+	paths := []string{"src/main.go", "src", "empty", "README.md", "src/a.go"}
+	sizes := map[string]int64{"src/main.go": 12, "src/a.go": 3, "README.md": 8}
+
+	tree := formatting.BuildTree("project", paths, sizes)
+
+	require.Equal(t, "project", tree.Name)
+	require.EqualValues(t, 23, tree.Size)
+	require.Len(t, tree.Children, 3)
+
+	require.Equal(t, &formatting.TreeNode{Name: "README.md", Path: "README.md", Type: formatting.TreeNodeFile, Size: 8}, tree.Children[0])
+	require.Equal(t, &formatting.TreeNode{Name: "empty", Path: "empty", Type: formatting.TreeNodeDir}, tree.Children[1])
+
+	src := tree.Children[2]
+	require.Equal(t, formatting.TreeNodeDir, src.Type)
+	require.EqualValues(t, 15, src.Size)
+	require.Equal(t, "a.go", src.Children[0].Name)
+	require.Equal(t, "src/main.go", src.Children[1].Path)
+}
+
+// TestGeneratePathTreeDepth tests that directories below the depth limit are collapsed with their file count.
+func TestGeneratePathTreeDepth(t *testing.T) {
+	paths := []string{"README.md", "src", "src/main.go", "src/util", "src/util/a.go", "src/util/b.go", "docs", "docs/x.md"}
+
+	expected := "├── README.md\n" +
+		"├── docs (1 file)\n" +
+		"│   └── … (1 file)\n" +
+		"└── src (3 files)\n" +
+		"    └── … (3 files)\n"
+	require.Equal(t, expected, formatting.GeneratePathTreeDepth(paths, 1))
+
+	expected = "├── README.md\n" +
+		"├── docs (1 file)\n" +
+		"│   └── x.md\n" +
+		"└── src (3 files)\n" +
+		"    ├── main.go\n" +
+		"    └── util (2 files)\n" +
+		"        └── … (2 files)\n"
+	require.Equal(t, expected, formatting.GeneratePathTreeDepth(paths, 2))
+	require.Equal(t, formatting.GeneratePathTree(paths), formatting.GeneratePathTreeDepth(paths, 0))
+}
+
+// TestTreeNodeCollapse tests that collapsed directories drop their children but keep their size and file count.
+func TestTreeNodeCollapse(t *testing.T) {
+	paths := []string{"src/main.go", "src/util/a.go", "src/util/b.go", "README.md"}
+	sizes := map[string]int64{"src/main.go": 1, "src/util/a.go": 2, "src/util/b.go": 3, "README.md": 4}
+
+	tree := formatting.BuildTree("project", paths, sizes)
+	tree.Collapse(2)
+
+	src := tree.Children[1]
+	require.Len(t, src.Children, 2)
+	util := src.Children[1]
+	require.Nil(t, util.Children)
+	require.Equal(t, 2, util.Collapsed)
+	require.EqualValues(t, 5, util.Size)
+
+	tree.Collapse(1)
+	require.Nil(t, src.Children)
+	require.Equal(t, 3, src.Collapsed)
+}
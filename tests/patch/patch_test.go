@@ -0,0 +1,89 @@
+package patch_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/patch"
+	"github.com/stretchr/testify/require"
+)
+
+const answer = "I suggest the following changes.\n" +
+	"\n" +
+	"```diff\n" +
+	"--- a/cmd/root.go\n" +
+	"+++ b/cmd/root.go\n" +
+	"@@ -2,3 +2,3 @@\n" +
+	" import (\n" +
+	"-\t\"fmt\"\n" +
+	"+\t\"errors\"\n" +
+	" )\n" +
+	"--- /dev/null\n" +
+	"+++ b/docs/NOTES.md\n" +
+	"@@ -0,0 +1,2 @@\n" +
+	"+# Notes\n" +
+	"+Hello\n" +
+	"```\n" +
+	"\n" +
+	"And replace the helper:\n" +
+	"\n" +
+	"**internal/util.go**\n" +
+	"```go\n" +
+	"package internal\n" +
+	"```\n" +
+	"\n" +
+	"```python scripts/run.py\n" +
+	"print(1)\n" +
+	"```\n" +
+	"\n" +
+	"```bash\n" +
+	"go test ./...\n" +
+	"```\n"
+
+// TestExtract tests finding diffs and file replacements in the answer of a model.
+func TestExtract(t *testing.T) {
+	changes, err := patch.Extract(answer)
+	require.NoError(t, err)
+	require.Equal(t, []patch.Change{
+		{Kind: patch.Modify, Path: "cmd/root.go", Hunks: []patch.Hunk{
+			{OldStart: 2, Lines: []string{" import (", "-\t\"fmt\"", "+\t\"errors\"", " )"}},
+		}},
+		{Kind: patch.Create, Path: "docs/NOTES.md", Hunks: []patch.Hunk{
+			{OldStart: 0, Lines: []string{"+# Notes", "+Hello"}},
+		}},
+		{Kind: patch.Replace, Path: "internal/util.go", Content: "package internal\n"},
+		{Kind: patch.Replace, Path: "scripts/run.py", Content: "print(1)\n"},
+	}, changes)
+}
+
+// TestExtractRejectsOutsidePaths tests that changes outside of the current directory are rejected.
+func TestExtractRejectsOutsidePaths(t *testing.T) {
+	_, err := patch.Extract("--- a/../secret.txt\n+++ b/../secret.txt\n@@ -1 +1 @@\n-a\n+b\n")
+	require.ErrorContains(t, err, "outside of the current directory")
+}
+
+// TestApply tests applying hunks, including hunks whose line numbers are off.
+func TestApply(t *testing.T) {
+	original := "package main\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {\n\tfmt.Println(1)\n}\n"
+	change := patch.Change{Kind: patch.Modify, Path: "main.go", Hunks: []patch.Hunk{
+		{OldStart: 1, Lines: []string{" package main", "+// comment"}},
+		// The line numbers of this hunk are off by two
+		{OldStart: 5, Lines: []string{" func main() {", "-\tfmt.Println(1)", "+\tfmt.Println(2)", " }"}},
+	}}
+
+	updated, err := patch.Apply(change, original)
+	require.NoError(t, err)
+	require.Equal(t, "package main\n// comment\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {\n\tfmt.Println(2)\n}\n", updated)
+}
+
+// TestApplyConflict tests that a hunk that matches nowhere is a conflict.
+func TestApplyConflict(t *testing.T) {
+	change := patch.Change{Kind: patch.Modify, Path: "main.go", Hunks: []patch.Hunk{
+		{OldStart: 1, Lines: []string{"-package lib", "+package main"}},
+	}}
+
+	_, err := patch.Apply(change, "package main\n")
+	var conflict *patch.ConflictError
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, "main.go: hunk 1 does not apply at line 1", err.Error())
+}
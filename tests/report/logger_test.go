@@ -0,0 +1,62 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/report"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTextLogger tests that the text logger prints plain messages, prefixes warnings and hides debug events.
+func TestTextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	flags := log.Flags()
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	})
+
+	logger, err := report.NewLogger(&buf, report.LogFormatText, false)
+	require.NoError(t, err)
+	logger.Debug("hidden", "phase", "read")
+	logger.Info("shown", "phase", "read")
+	logger.Warn("pattern did not match", "phase", "select")
+
+	require.Equal(t, "shown\nWarning: pattern did not match\n", buf.String())
+}
+
+// TestJSONLogger tests that the json logger writes every event with its attributes as a JSON object.
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := report.NewLogger(&buf, report.LogFormatJSON, false)
+	require.NoError(t, err)
+	logger.Debug("Read 3 files", "phase", "read", "files", 3)
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &event))
+	require.Equal(t, "DEBUG", event["level"])
+	require.Equal(t, "Read 3 files", event["msg"])
+	require.Equal(t, "read", event["phase"])
+	require.EqualValues(t, 3, event["files"])
+}
+
+// TestCountWarnings tests that warnings and errors are counted, including those of derived loggers.
+func TestCountWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := report.NewLogger(&buf, report.LogFormatJSON, false)
+	require.NoError(t, err)
+
+	logger, warnings := report.CountWarnings(logger)
+	logger.Info("Read 3 files")
+	logger.Warn("pattern did not match")
+	logger.With("phase", "write").Error("unable to write")
+
+	require.Equal(t, 2, warnings())
+	require.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,85 @@
+package report_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/devinbarry/crev/internal/report"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSummaryWrite tests the plain summary output with aligned labels and the five largest files.
+func TestSummaryWrite(t *testing.T) {
+	summary := report.Summary{
+		Output:       "crev-project.txt",
+		Files:        6,
+		Excluded:     4,
+		BytesWritten: 2048,
+		Elapsed:      1500 * time.Microsecond,
+		LargestFiles: []report.FileSize{
+			{Path: "a.go", Size: 10},
+			{Path: "b.go", Size: 600},
+			{Path: "c.go", Size: 30},
+			{Path: "d.go", Size: 1536},
+			{Path: "e.go", Size: 20},
+			{Path: "f.go", Size: 5},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, summary.Write(&buf, false))
+
+	expected := "✔ Project overview successfully saved to: crev-project.txt\n" +
+		"  Files included:   6\n" +
+		"  Paths excluded:   4\n" +
+		"  Size:             2.0 KB\n" +
+		"  Estimated tokens: 512 - 683\n" +
+		"  Execution time:   2ms\n" +
+		"  Largest files:\n" +
+		"       1.5 KB  d.go\n" +
+		"        600 B  b.go\n" +
+		"         30 B  c.go\n" +
+		"         20 B  e.go\n" +
+		"         10 B  a.go\n"
+	require.Equal(t, expected, buf.String())
+}
+
+// TestSummaryWriteTooLong tests that files left out for their line count are only shown if there are any.
+func TestSummaryWriteTooLong(t *testing.T) {
+	summary := report.Summary{Files: 1, Excluded: 3, TooLong: 2, BytesWritten: 10}
+
+	var buf bytes.Buffer
+	require.NoError(t, summary.Write(&buf, false))
+	require.Contains(t, buf.String(), "  Paths excluded:   3\n  Files too long:   2\n  Size:")
+}
+
+// TestSummaryWriteColor tests that colors are only written when enabled.
+func TestSummaryWriteColor(t *testing.T) {
+	summary := report.Summary{Files: 1, BytesWritten: 10}
+
+	var buf bytes.Buffer
+	require.NoError(t, summary.Write(&buf, true))
+	require.Contains(t, buf.String(), "\033[32m✔ Project overview successfully written to stdout\033[0m")
+}
+
+// TestColorEnabled tests that colors are disabled for non-terminals and with NO_COLOR.
+func TestColorEnabled(t *testing.T) {
+	require.False(t, report.ColorEnabled(&bytes.Buffer{}), "Buffers are not terminals")
+
+	f, err := os.Create(t.TempDir() + "/out.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	require.False(t, report.ColorEnabled(f), "Regular files are not terminals")
+
+	t.Setenv("NO_COLOR", "")
+	require.False(t, report.ColorEnabled(os.Stderr), "NO_COLOR disables colors even when empty")
+}
+
+// TestFormatBytes tests human readable byte formatting.
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "512 B", report.FormatBytes(512))
+	require.Equal(t, "1.5 KB", report.FormatBytes(1536))
+	require.Equal(t, "3.0 MB", report.FormatBytes(3*1024*1024))
+}
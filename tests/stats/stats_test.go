@@ -0,0 +1,56 @@
+package stats_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/devinbarry/crev/internal/stats"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNew tests the breakdown per file and the aggregation per directory at any depth.
+func TestNew(t *testing.T) {
+	r := stats.New(map[string]string{
+		"main.go":             "package main\n",
+		"internal/a/a.go":     "package a\n\nfunc A() {}",
+		"internal/b/b.go":     "package b\n",
+		"internal/b/data.sql": "select 1;\nselect 2;\n",
+	})
+
+	require.Equal(t, []stats.Stat{
+		{Path: "internal/a/a.go", Files: 1, Lines: 3, Bytes: 22, Tokens: 8},
+		{Path: "internal/b/data.sql", Files: 1, Lines: 2, Bytes: 20, Tokens: 7},
+		{Path: "main.go", Files: 1, Lines: 1, Bytes: 13, Tokens: 5},
+		{Path: "internal/b/b.go", Files: 1, Lines: 1, Bytes: 10, Tokens: 4},
+	}, r.Files)
+	require.Equal(t, []stats.Stat{
+		{Path: "internal", Files: 3, Lines: 6, Bytes: 52, Tokens: 18},
+		{Path: "internal/b", Files: 2, Lines: 3, Bytes: 30, Tokens: 10},
+		{Path: "internal/a", Files: 1, Lines: 3, Bytes: 22, Tokens: 8},
+	}, r.Dirs)
+	require.Equal(t, stats.Stat{Files: 4, Lines: 7, Bytes: 65, Tokens: 22}, r.Total)
+}
+
+// TestWrite tests the tables of the largest directories and files.
+func TestWrite(t *testing.T) {
+	r := stats.New(map[string]string{
+		"src/big.go":   string(bytes.Repeat([]byte("// x\n"), 300)),
+		"src/small.go": "package src\n",
+		"README.md":    "# Readme\n",
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf, 2))
+	require.Equal(t, `Directories:
+    TOKENS       SIZE    LINES  PATH
+       504     1.5 KB      301  src/
+
+Files:
+    TOKENS       SIZE    LINES  PATH
+       500     1.5 KB      300  src/big.go
+         4       12 B        1  src/small.go
+  ... and 1 more
+
+Total: 3 files, 302 lines, 1.5 KB, about 507 tokens
+`, buf.String())
+}
@@ -0,0 +1,47 @@
+package symbols_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/symbols"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImports tests resolving the imports of several languages to the files of the bundle.
+func TestImports(t *testing.T) {
+	srcFiles := []symbols.File{
+		{Path: "cmd/main.go", Content: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/store\"\n\t\"github.com/spf13/cobra\"\n)\n"},
+		{Path: "internal/store/store.go", Content: "package store\n"},
+		{Path: "web/src/app.ts", Content: "import { api } from './api'\nimport React from 'react'\nimport './styles.css'\nconst util = require('../lib/util')\n"},
+		{Path: "web/src/api/index.ts", Content: "export * from \"./client\"\n"},
+		{Path: "web/src/api/client.ts", Content: ""},
+		{Path: "web/lib/util.js", Content: ""},
+		{Path: "svc/app/main.py", Content: "import os, app.models\nfrom .views import index\nfrom ..shared import config\n"},
+		{Path: "svc/app/models.py", Content: ""},
+		{Path: "svc/app/views.py", Content: ""},
+		{Path: "svc/shared/__init__.py", Content: ""},
+		{Path: "src/main.rs", Content: "mod config;\nuse crate::net::client::Client;\nuse std::io;\n"},
+		{Path: "src/config.rs", Content: ""},
+		{Path: "src/net/client.rs", Content: ""},
+		{Path: "native/parse.c", Content: "#include <stdio.h>\n#include \"parse.h\"\n"},
+		{Path: "native/parse.h", Content: ""},
+	}
+	require.Equal(t, map[string][]string{
+		"cmd/main.go":          {"internal/store"},
+		"web/src/app.ts":       {"web/lib/util.js", "web/src/api/index.ts"},
+		"web/src/api/index.ts": {"web/src/api/client.ts"},
+		"svc/app/main.py":      {"svc/app/models.py", "svc/app/views.py", "svc/shared/__init__.py"},
+		"src/main.rs":          {"src/config.rs", "src/net/client.rs"},
+		"native/parse.c":       {"native/parse.h"},
+	}, symbols.Imports(srcFiles, "example.com/app"))
+
+	// Without the module path, Go imports are matched by their last path elements
+	require.Equal(t, map[string][]string{"cmd/main.go": {"internal/store"}}, symbols.Imports(srcFiles[:2], ""))
+}
+
+// TestGoModulePath tests reading the module path of a go.mod file.
+func TestGoModulePath(t *testing.T) {
+	require.Equal(t, "github.com/devinbarry/crev", symbols.GoModulePath("// comment\nmodule github.com/devinbarry/crev\n\ngo 1.23\n"))
+	require.Equal(t, "example.com/app", symbols.GoModulePath("module \"example.com/app\"\n"))
+	require.Empty(t, symbols.GoModulePath(""))
+}
@@ -0,0 +1,72 @@
+package symbols_test
+
+import (
+	"testing"
+
+	"github.com/devinbarry/crev/internal/symbols"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractGo tests extracting the functions, methods and types of Go files, also when truncated.
+func TestExtractGo(t *testing.T) {
+	content := `package main
+
+type Server struct{}
+
+type Handler interface {
+	Serve()
+}
+
+type ID = string
+
+func (s *Server) Start() {}
+
+func (l List[T]) Len() int { return 0 }
+
+func main() {
+	var x = "func fake() {}"
+… truncated (10 more lines)`
+	require.Equal(t, []symbols.Symbol{
+		{Kind: "struct", Name: "Server", Line: 3},
+		{Kind: "interface", Name: "Handler", Line: 5},
+		{Kind: "type", Name: "ID", Line: 9},
+		{Kind: "method", Name: "Server.Start", Line: 11},
+		{Kind: "method", Name: "List.Len", Line: 13},
+		{Kind: "func", Name: "main", Line: 15},
+	}, symbols.Extract("cmd/main.go", content))
+}
+
+// TestExtractPatterns tests finding the definitions of other languages line by line.
+func TestExtractPatterns(t *testing.T) {
+	tests := []struct {
+		path     string
+		content  string
+		expected []symbols.Symbol
+	}{
+		{"app.py", "import os\n\nclass App:\n    def run(self):\n        pass\n\nasync def main():\n    pass\n", []symbols.Symbol{
+			{Kind: "class", Name: "App", Line: 3},
+			{Kind: "method", Name: "run", Line: 4},
+			{Kind: "func", Name: "main", Line: 7},
+		}},
+		{"src/api.ts", "export interface User {}\nexport type ID = string\nexport default class Api {}\nexport const load = async (id: ID) => {}\nfunction helper() {}\n", []symbols.Symbol{
+			{Kind: "interface", Name: "User", Line: 1},
+			{Kind: "type", Name: "ID", Line: 2},
+			{Kind: "class", Name: "Api", Line: 3},
+			{Kind: "func", Name: "load", Line: 4},
+			{Kind: "func", Name: "helper", Line: 5},
+		}},
+		{"lib.rs", "pub struct Config {}\n\nimpl Config {\n    pub fn new() -> Self {}\n}\n", []symbols.Symbol{
+			{Kind: "struct", Name: "Config", Line: 1},
+			{Kind: "impl", Name: "Config", Line: 3},
+			{Kind: "fn", Name: "new", Line: 4},
+		}},
+		{"main.c", "#include <stdio.h>\n\nstruct point {\n\tint x;\n};\n\nint main(int argc, char **argv) {\n\tif (argc) {\n\t}\n}\n", []symbols.Symbol{
+			{Kind: "struct", Name: "point", Line: 3},
+			{Kind: "func", Name: "main", Line: 7},
+		}},
+		{"README.md", "# Title\n", nil},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, symbols.Extract(test.path, test.content), test.path)
+	}
+}